@@ -79,6 +79,9 @@ query getUser ($Login: String!) {
 }
 `
 
+// The SHA-256 hash of getUser_Operation, used for automatic persisted queries.
+const getUser_OperationHash = "021acc3fea53acb76f2e61b747c757186f755c727dbb256e0ef636a150b75933"
+
 // getUser gets the given user's name from their username.
 func getUser(
 	ctx_ context.Context,
@@ -86,8 +89,9 @@ func getUser(
 	Login string,
 ) (data_ *getUserResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "getUser",
-		Query:  getUser_Operation,
+		OpName:    "getUser",
+		Query:     getUser_Operation,
+		QueryHash: getUser_OperationHash,
 		Variables: &__getUserInput{
 			Login: Login,
 		},
@@ -115,13 +119,17 @@ query getViewer {
 }
 `
 
+// The SHA-256 hash of getViewer_Operation, used for automatic persisted queries.
+const getViewer_OperationHash = "c9cb6dd4002fe7d8d11fcf72c8331af428dc5db6e6d5c98a859994315d68ab58"
+
 func getViewer(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *getViewerResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "getViewer",
-		Query:  getViewer_Operation,
+		OpName:    "getViewer",
+		Query:     getViewer_Operation,
+		QueryHash: getViewer_OperationHash,
 	}
 
 	data_ = &getViewerResponse{}
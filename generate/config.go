@@ -22,20 +22,24 @@ type Config struct {
 	// The following fields are documented in the [genqlient.yaml docs].
 	//
 	// [genqlient.yaml docs]: https://github.com/Khan/genqlient/blob/main/docs/genqlient.yaml
-	Schema              StringList              `yaml:"schema"`
-	Operations          StringList              `yaml:"operations"`
-	Generated           string                  `yaml:"generated"`
-	Package             string                  `yaml:"package"`
-	ExportOperations    string                  `yaml:"export_operations"`
-	ContextType         string                  `yaml:"context_type"`
-	ClientGetter        string                  `yaml:"client_getter"`
-	Bindings            map[string]*TypeBinding `yaml:"bindings"`
-	PackageBindings     []*PackageBinding       `yaml:"package_bindings"`
-	Casing              Casing                  `yaml:"casing"`
-	Optional            string                  `yaml:"optional"`
-	OptionalGenericType string                  `yaml:"optional_generic_type"`
-	StructReferences    bool                    `yaml:"use_struct_references"`
-	Extensions          bool                    `yaml:"use_extensions"`
+	Schema                 StringList              `yaml:"schema"`
+	Operations             StringList              `yaml:"operations"`
+	Generated              string                  `yaml:"generated"`
+	Package                string                  `yaml:"package"`
+	ExportOperations       string                  `yaml:"export_operations"`
+	ExportOperationsFormat string                  `yaml:"export_operations_format"`
+	ContextType            string                  `yaml:"context_type"`
+	ClientGetter           string                  `yaml:"client_getter"`
+	Bindings               map[string]*TypeBinding `yaml:"bindings"`
+	PackageBindings        []*PackageBinding       `yaml:"package_bindings"`
+	Casing                 Casing                  `yaml:"casing"`
+	Optional               string                  `yaml:"optional"`
+	OptionalGenericType    string                  `yaml:"optional_generic_type"`
+	StructReferences       bool                    `yaml:"use_struct_references"`
+	Extensions             bool                    `yaml:"use_extensions"`
+	DocumentIDs            bool                    `yaml:"use_document_ids"`
+	FieldErrorMethods      bool                    `yaml:"field_error_methods"`
+	AddCacheKeys           bool                    `yaml:"add_cache_keys"`
 
 	// The directory of the config-file (relative to which all the other paths
 	// are resolved).  Set by ValidateAndFillDefaults.
@@ -194,6 +198,15 @@ func (c *Config) ValidateAndFillDefaults(baseDir string) error {
 		c.ExportOperations = pathJoin(baseDir, c.ExportOperations)
 	}
 
+	if c.ExportOperationsFormat != "" && c.ExportOperationsFormat != "genqlient" &&
+		c.ExportOperationsFormat != "apollo" && c.ExportOperationsFormat != "relay" {
+		return errorf(nil, "export_operations_format must be one of: "+
+			"'genqlient' (default), 'apollo', or 'relay'")
+	}
+	if c.ExportOperationsFormat != "" && c.ExportOperations == "" {
+		return errorf(nil, "export_operations_format has no effect unless export_operations is set")
+	}
+
 	if c.ContextType == "" {
 		c.ContextType = "context.Context"
 	}
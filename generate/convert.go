@@ -12,6 +12,7 @@ package generate
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/vektah/gqlparser/v2/ast"
 )
@@ -130,14 +131,46 @@ func (g *generator) convertOperation(
 			GraphQLName: baseType.Name,
 			// omit the GraphQL description for baseType; it's uninteresting.
 		},
-		Fields:    fields,
-		Selection: operation.SelectionSet,
-		Generator: g,
+		Fields:            fields,
+		Selection:         operation.SelectionSet,
+		Generator:         g,
+		FieldErrorMethods: g.Config.FieldErrorMethods,
+	}
+	if goType.FieldErrorMethods {
+		if err := validateFieldErrorMethods(goType, operation.Position); err != nil {
+			return nil, err
+		}
 	}
 
 	return g.addType(goType, goType.GoName, operation.Position)
 }
 
+// validateFieldErrorMethods checks that the <Field>Failed methods we'll
+// write for the given response type (see Config.FieldErrorMethods) don't
+// conflict with its fields or their getters, as they would if the query
+// selects both user and userFailed, say.
+func validateFieldErrorMethods(typ *goStructType, pos *ast.Position) error {
+	fields, err := typ.FlattenedFields()
+	if err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for _, field := range fields {
+		names[field.GoName] = true
+		names["Get"+field.GoName] = true
+	}
+	for _, field := range fields {
+		if method := field.GoName + "Failed"; names[method] {
+			return errorf(pos,
+				"field_error_methods: the method %s.%s, for field %s, would "+
+					"conflict with another field or its getter; alias one "+
+					"of the fields to avoid the conflict",
+				typ.GoName, method, field.JSONName)
+		}
+	}
+	return nil
+}
+
 var builtinTypes = map[string]string{
 	// GraphQL guarantees int32 is enough, but using int seems more idiomatic
 	"Int":     "int",
@@ -147,6 +180,10 @@ var builtinTypes = map[string]string{
 	"ID":      "string",
 }
 
+// uploadScalar is the name of the scalar used for file uploads by the
+// GraphQL multipart request spec.
+const uploadScalar = "Upload"
+
 // convertArguments builds the type of the GraphQL arguments to the given
 // operation.
 //
@@ -331,6 +368,12 @@ func (g *generator) convertDefinition(
 	if ok && options.TypeName == "" {
 		return &goOpaqueType{GoRef: goBuiltinName, GraphQLName: def.Name}, nil
 	}
+	if def.Kind == ast.Scalar && def.Name == uploadScalar && options.TypeName == "" {
+		// The multipart request spec's Upload scalar is bound to
+		// graphql.Upload by default, which the client knows how to send.
+		goRef, err := g.ref("github.com/Khan/genqlient/graphql.Upload")
+		return &goOpaqueType{GoRef: goRef, GraphQLName: def.Name}, err
+	}
 
 	// Determine the name to use for this type.
 	var name string
@@ -752,11 +795,16 @@ func (g *generator) convertInlineFragment(
 	containingTypedef *ast.Definition,
 	queryOptions *genqlientDirective,
 ) ([]*goStructField, error) {
-	// You might think fragmentTypedef is just fragment.ObjectDefinition, but
-	// actually that's the type into which the fragment is spread.
-	fragmentTypedef := g.schema.Types[fragment.TypeCondition]
-	if !fragmentMatches(containingTypedef, fragmentTypedef) {
-		return nil, nil
+	// A fragment with no type-condition, e.g. `... @include(if: $x) { f }`,
+	// always applies.  (genqlient also generates these for @defer; see
+	// addDeferredMarkers.)
+	if fragment.TypeCondition != "" {
+		// You might think fragmentTypedef is just fragment.ObjectDefinition,
+		// but actually that's the type into which the fragment is spread.
+		fragmentTypedef := g.schema.Types[fragment.TypeCondition]
+		if !fragmentMatches(containingTypedef, fragmentTypedef) {
+			return nil, nil
+		}
 	}
 	return g.convertSelectionSet(namePrefix, fragment.SelectionSet,
 		containingTypedef, queryOptions)
@@ -917,6 +965,20 @@ func (g *generator) convertField(
 			field.Position, "undefined field %v", field.Alias)
 	}
 
+	if field.Name == "__typename" && strings.HasPrefix(field.Alias, deferredMarkerPrefix) {
+		// This is the marker for a deferred fragment; see addDeferredMarkers.
+		label := strings.TrimPrefix(field.Alias, deferredMarkerPrefix)
+		goRef, err := g.ref("github.com/Khan/genqlient/graphql.Received")
+		return &goStructField{
+			GoName:      upperFirst(label) + "Received",
+			GoType:      &goOpaqueType{GoRef: goRef, GraphQLName: field.Name},
+			JSONName:    field.Alias,
+			GraphQLName: field.Name,
+			Description: fmt.Sprintf(
+				"Whether the deferred fragment %v has been received.", label),
+		}, err
+	}
+
 	goName := upperFirst(field.Alias)
 	namePrefix = nextPrefix(namePrefix, field)
 
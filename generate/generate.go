@@ -6,9 +6,12 @@ package generate
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"go/format"
 	"io"
+	"regexp"
 	"sort"
 	"strings"
 	"text/template"
@@ -55,6 +58,13 @@ type operation struct {
 	Doc string `json:"-"`
 	// The body of the operation to send.
 	Body string `json:"query"`
+	// The hex-encoded SHA-256 hash of Body, e.g. for automatic persisted
+	// queries.
+	Hash string `json:"-"`
+	// The document ID under which the operation is registered with the
+	// server, if Config.DocumentIDs is set.  (It's the same as Hash; we
+	// duplicate it so it's only exported when requested.)
+	ID string `json:"id,omitempty"`
 	// The type of the argument to the operation, which we use both internally
 	// and to construct the arguments.  We do it this way so we can use the
 	// machinery we have for handling (and, specifically, json-marshaling)
@@ -72,6 +82,57 @@ type exportedOperations struct {
 	Operations []*operation `json:"operations"`
 }
 
+// apolloPersistedQueryManifest is the format of ExportOperations if
+// ExportOperationsFormat is "apollo", matching the persisted query list (PQL) manifest
+// used by Apollo's tooling.
+type apolloPersistedQueryManifest struct {
+	Format     string                          `json:"format"`
+	Version    int                             `json:"version"`
+	Operations []apolloPersistedQueryOperation `json:"operations"`
+}
+
+type apolloPersistedQueryOperation struct {
+	ID   string        `json:"id"`
+	Name string        `json:"name"`
+	Type ast.Operation `json:"type"`
+	Body string        `json:"body"`
+}
+
+// exportOperations returns the content of the ExportOperations file, in the
+// configured format.
+func (g *generator) exportOperations() ([]byte, error) {
+	var exported interface{}
+	switch g.Config.ExportOperationsFormat {
+	case "apollo":
+		manifest := apolloPersistedQueryManifest{
+			Format:     "apollo-persisted-query-manifest",
+			Version:    1,
+			Operations: make([]apolloPersistedQueryOperation, len(g.Operations)),
+		}
+		for i, op := range g.Operations {
+			manifest.Operations[i] = apolloPersistedQueryOperation{
+				ID: op.Hash, Name: op.Name, Type: op.Type, Body: op.Body,
+			}
+		}
+		exported = manifest
+	case "relay":
+		// Relay's format is simply a map from document ID to document.
+		documents := make(map[string]string, len(g.Operations))
+		for _, op := range g.Operations {
+			documents[op.Hash] = op.Body
+		}
+		exported = documents
+	default:
+		exported = exportedOperations{Operations: g.Operations}
+	}
+
+	// We use MarshalIndent so that the file is human-readable and slightly
+	// more likely to be git-mergeable (if you check it in).  In general it's
+	// never going to be used anywhere where space is an issue -- it doesn't
+	// go in your binary or anything.
+	return json.MarshalIndent(exported, "", "  ")
+}
+
 func newGenerator(
 	config *Config,
 	schema *ast.Schema,
@@ -154,10 +215,25 @@ func (g *generator) usedFragments(op *ast.OperationDefinition) ast.FragmentDefin
 
 // Preprocess each query to make any changes that genqlient needs.
 //
-// At present, the only change is that we add __typename, if not already
+// At present, there are two changes: we add __typename, if not already
 // requested, to each field of interface type, so we can use the right types
-// when unmarshaling.
-func (g *generator) preprocessQueryDocument(doc *ast.QueryDocument) {
+// when unmarshaling; and we add a marker field to each deferred fragment (see
+// addDeferredMarkers).  If Config.AddCacheKeys is set, we also add __typename
+// and id to each field of composite type (see addCacheKeys).
+func (g *generator) preprocessQueryDocument(doc *ast.QueryDocument) error {
+	for _, op := range doc.Operations {
+		err := g.addDeferredMarkers(op.SelectionSet)
+		if err != nil {
+			return err
+		}
+	}
+	for _, fragment := range doc.Fragments {
+		err := g.addDeferredMarkers(fragment.SelectionSet)
+		if err != nil {
+			return err
+		}
+	}
+
 	var observers validator.Events
 	// We want to ensure that everywhere you ask for some list of fields (a
 	// selection-set) from an interface (or union) type, you ask for its
@@ -202,26 +278,214 @@ func (g *generator) preprocessQueryDocument(doc *ast.QueryDocument) {
 		if !hasTypename {
 			// Ok, we need to add the field!
 			field.SelectionSet = append(ast.SelectionSet{
-				&ast.Field{
-					Alias: "__typename", Name: "__typename",
-					// Fake definition for the magic field __typename cribbed
-					// from gqlparser's validator/walk.go, equivalent to
-					//	__typename: String
-					// TODO(benkraft): This should in principle be
-					//	__typename: String!
-					// But genqlient doesn't care, so we just match gqlparser.
-					Definition: &ast.FieldDefinition{
-						Name: "__typename",
-						Type: ast.NamedType("String", nil /* pos */),
-					},
-					// Definition of the object that contains this field, i.e.
-					// FieldType.
-					ObjectDefinition: fieldType,
-				},
+				// The object that contains this field is FieldType.
+				typenameField("__typename", fieldType),
 			}, field.SelectionSet...)
 		}
 	})
+	if g.Config.AddCacheKeys {
+		observers.OnField(func(_ *validator.Walker, field *ast.Field) {
+			addCacheKeys(field, g.schema.Types[field.Definition.Type.Name()])
+		})
+	}
 	validator.Walk(g.schema, doc, &observers)
+	return nil
+}
+
+// addCacheKeys adds __typename, and id if the type has one, to the given
+// field's selection set, if they aren't already selected, so that a
+// normalized cache (see graphql.Cache) can key the objects in the response.
+func addCacheKeys(field *ast.Field, fieldType *ast.Definition) {
+	if fieldType == nil || !fieldType.IsCompositeType() {
+		return
+	}
+	var hasTypename, hasID bool
+	for _, selection := range field.SelectionSet {
+		subField, ok := selection.(*ast.Field)
+		if !ok {
+			continue
+		}
+		// If something else is aliased to __typename or id, we can't add
+		// them, but the cache will just treat the object as un-keyed.
+		hasTypename = hasTypename || subField.Alias == "__typename"
+		hasID = hasID || subField.Alias == "id"
+	}
+
+	var keys ast.SelectionSet
+	if !hasTypename {
+		keys = append(keys, typenameField("__typename", fieldType))
+	}
+	if idDef := fieldType.Fields.ForName("id"); idDef != nil && !hasID && len(idDef.Arguments) == 0 {
+		keys = append(keys, &ast.Field{
+			Alias: "id", Name: "id",
+			Definition:       idDef,
+			ObjectDefinition: fieldType,
+		})
+	}
+	field.SelectionSet = append(keys, field.SelectionSet...)
+}
+
+// typenameField returns a selection of the magic field __typename, with the
+// given alias, on the given type.
+func typenameField(alias string, objectDefinition *ast.Definition) *ast.Field {
+	return &ast.Field{
+		Alias: alias, Name: "__typename",
+		// Fake definition for the magic field __typename cribbed
+		// from gqlparser's validator/walk.go, equivalent to
+		//	__typename: String
+		// TODO(benkraft): This should in principle be
+		//	__typename: String!
+		// But genqlient doesn't care, so we just match gqlparser.
+		Definition: &ast.FieldDefinition{
+			Name: "__typename",
+			Type: ast.NamedType("String", nil /* pos */),
+		},
+		ObjectDefinition: objectDefinition,
+	}
+}
+
+// deferredMarkerPrefix is the prefix of the alias of the marker field which
+// addDeferredMarkers adds to each deferred fragment.
+const deferredMarkerPrefix = "genqlient_deferred_"
+
+var graphQLNameRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// addDeferredMarkers adds a marker field to each fragment in the given
+// selection-set (recursively) which has the @defer directive, so that the
+// generated types can say whether that fragment has arrived yet.
+//
+// The marker is __typename, aliased to deferredMarkerPrefix plus the
+// fragment's label.  Because the marker is part of the fragment, the server
+// sends it along with the rest of the fragment if it doesn't support
+// incremental delivery.  If it does, the client instead sets the marker when
+// it receives the payload with that label (see the graphql package's
+// readIncrementalResponse), so every deferred fragment must have a label.
+// In the generated code, we convert the marker to a field of type
+// graphql.Received (see convertField).
+//
+// Named fragment spreads are wrapped in an inline fragment, i.e.
+//
+//	...MyFragment @defer
+//
+// becomes
+//
+//	... on MyType @defer(label: "MyFragment") {
+//		...MyFragment
+//		genqlient_deferred_MyFragment: __typename
+//	}
+//
+// since the fragment may also be spread elsewhere.  This function may be
+// called several times on the same selection-set (since fragments may be
+// shared by several operations); it does nothing the second time.
+func (g *generator) addDeferredMarkers(selectionSet ast.SelectionSet) error {
+	for i, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			err := g.addDeferredMarkers(selection.SelectionSet)
+			if err != nil {
+				return err
+			}
+		case *ast.InlineFragment:
+			err := g.addDeferredMarkers(selection.SelectionSet)
+			if err != nil {
+				return err
+			}
+			directive := selection.Directives.ForName("defer")
+			if directive == nil {
+				continue
+			}
+			label, err := deferLabel(directive, "")
+			if err != nil {
+				return err
+			}
+			alias := deferredMarkerPrefix + label
+			if !hasAlias(selection.SelectionSet, alias) {
+				typ := selection.ObjectDefinition
+				if selection.TypeCondition != "" {
+					typ = g.schema.Types[selection.TypeCondition]
+				}
+				selection.SelectionSet = append(selection.SelectionSet, typenameField(alias, typ))
+			}
+		case *ast.FragmentSpread:
+			directive := selection.Directives.ForName("defer")
+			if directive == nil {
+				continue
+			}
+			label, err := deferLabel(directive, selection.Name)
+			if err != nil {
+				return err
+			}
+
+			// The wrapper gets all the directives (so @include and the like
+			// apply to the whole thing), with the label filled in.
+			labeledDirective := *directive
+			if directive.Arguments.ForName("label") == nil {
+				labeledDirective.Arguments = append(ast.ArgumentList{{
+					Name:  "label",
+					Value: &ast.Value{Kind: ast.StringValue, Raw: label},
+				}}, directive.Arguments...)
+			}
+			directives := make(ast.DirectiveList, len(selection.Directives))
+			for j, d := range selection.Directives {
+				if d == directive {
+					d = &labeledDirective
+				}
+				directives[j] = d
+			}
+
+			// Note selection.Definition may not be set (see usedFragments).
+			fragment := g.fragments[selection.Name]
+			if fragment == nil {
+				return errorf(selection.Position, "unknown fragment %s", selection.Name)
+			}
+			spread := *selection
+			spread.Directives = nil
+			typ := g.schema.Types[fragment.TypeCondition]
+			selectionSet[i] = &ast.InlineFragment{
+				TypeCondition: typ.Name,
+				Directives:    directives,
+				SelectionSet: ast.SelectionSet{
+					&spread,
+					typenameField(deferredMarkerPrefix+label, typ),
+				},
+				ObjectDefinition: selection.ObjectDefinition,
+			}
+		}
+	}
+	return nil
+}
+
+// hasAlias returns true if the given selection-set directly contains a field
+// with the given alias.
+func hasAlias(selectionSet ast.SelectionSet, alias string) bool {
+	for _, selection := range selectionSet {
+		if field, ok := selection.(*ast.Field); ok && field.Alias == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// deferLabel returns the label of the given @defer directive, or
+// defaultLabel if it has none.  It returns an error if there is no label
+// to use, or if the label isn't a valid GraphQL name.
+func deferLabel(directive *ast.Directive, defaultLabel string) (string, error) {
+	label := defaultLabel
+	if arg := directive.Arguments.ForName("label"); arg != nil {
+		if arg.Value.Kind != ast.StringValue {
+			return "", errorf(arg.Position, "genqlient requires that @defer labels be literal strings")
+		}
+		label = arg.Value.Raw
+	}
+	if label == "" {
+		return "", errorf(directive.Position,
+			"genqlient requires a label for @defer on an inline fragment, e.g. @defer(label: \"myLabel\")")
+	}
+	if !graphQLNameRegexp.MatchString(label) {
+		return "", errorf(directive.Position,
+			"genqlient requires that @defer labels be valid GraphQL names, got %q", label)
+	}
+	return label, nil
 }
 
 // validateOperation checks for a few classes of operations that gqlparser
@@ -256,7 +520,10 @@ func (g *generator) addOperation(op *ast.OperationDefinition) error {
 		Operations: ast.OperationList{op},
 		Fragments:  g.usedFragments(op),
 	}
-	g.preprocessQueryDocument(queryDoc)
+	err := g.preprocessQueryDocument(queryDoc)
+	if err != nil {
+		return err
+	}
 
 	var builder strings.Builder
 	f := formatter.NewFormatter(&builder)
@@ -295,14 +562,23 @@ func (g *generator) addOperation(op *ast.OperationDefinition) error {
 		sourceFilename = sourceFilename[:i]
 	}
 
+	// The newline just makes it format a little nicer.  We add it here
+	// rather than in the template so exported operations (and the hash)
+	// will match *exactly* what we send to the server.
+	body := "\n" + builder.String()
+	hash := sha256.Sum256([]byte(body))
+	var documentID string
+	if g.Config.DocumentIDs {
+		documentID = hex.EncodeToString(hash[:])
+	}
+
 	g.Operations = append(g.Operations, &operation{
-		Type: op.Operation,
-		Name: op.Name,
-		Doc:  docComment,
-		// The newline just makes it format a little nicer.  We add it here
-		// rather than in the template so exported operations will match
-		// *exactly* what we send to the server.
-		Body:           "\n" + builder.String(),
+		Type:           op.Operation,
+		Name:           op.Name,
+		Doc:            docComment,
+		Body:           body,
+		Hash:           hex.EncodeToString(hash[:]),
+		ID:             documentID,
 		Input:          inputType,
 		ResponseName:   responseType.Reference(),
 		SourceFilename: sourceFilename,
@@ -416,12 +692,7 @@ func Generate(config *Config) (map[string][]byte, error) {
 	}
 
 	if config.ExportOperations != "" {
-		// We use MarshalIndent so that the file is human-readable and
-		// slightly more likely to be git-mergeable (if you check it in).  In
-		// general it's never going to be used anywhere where space is an
-		// issue -- it doesn't go in your binary or anything.
-		retval[config.ExportOperations], err = json.MarshalIndent(
-			exportedOperations{Operations: g.Operations}, "", "  ")
+		retval[config.ExportOperations], err = g.exportOperations()
 		if err != nil {
 			return nil, errorf(nil, "unable to export queries: %v", err)
 		}
@@ -165,6 +165,18 @@ func TestGenerateWithConfig(t *testing.T) {
 		{"ExportOperations", "", nil, &Config{
 			ExportOperations: "operations.json",
 		}},
+		{"DocumentIDs", "", nil, &Config{
+			ExportOperations: "operations.json",
+			DocumentIDs:      true,
+		}},
+		{"ExportOperationsApollo", "", []string{"SimpleQuery.graphql", "SimpleMutation.graphql"}, &Config{
+			ExportOperations:       "operations.json",
+			ExportOperationsFormat: "apollo",
+		}},
+		{"ExportOperationsRelay", "", []string{"SimpleQuery.graphql", "SimpleMutation.graphql"}, &Config{
+			ExportOperations:       "operations.json",
+			ExportOperationsFormat: "relay",
+		}},
 		{"CustomContext", "", nil, &Config{
 			ContextType: "github.com/Khan/genqlient/internal/testutil.MyContext",
 		}},
@@ -214,6 +226,12 @@ func TestGenerateWithConfig(t *testing.T) {
 		{"Extensions", "", nil, &Config{
 			Extensions: true,
 		}},
+		{"FieldErrorMethods", "", nil, &Config{
+			FieldErrorMethods: true,
+		}},
+		{"AddCacheKeys", "", []string{"SimpleQuery.graphql", "SimpleNamedFragment.graphql"}, &Config{
+			AddCacheKeys: true,
+		}},
 		{"OptionalValue", "", []string{"ListInput.graphql", "QueryWithSlices.graphql"}, &Config{
 			Optional: "value",
 		}},
@@ -336,6 +354,8 @@ func TestGenerateErrors(t *testing.T) {
 				Package:     "test",
 				Generated:   os.DevNull,
 				ContextType: "context.Context",
+				// So that we check for conflicts with its methods.
+				FieldErrorMethods: true,
 				Bindings: map[string]*TypeBinding{
 					"ValidScalar":   {Type: "string"},
 					"InvalidScalar": {Type: "bogus"},
@@ -1429,6 +1429,9 @@ query ComplexInlineFragments {
 }
 `
 
+// The SHA-256 hash of ComplexInlineFragments_Operation, used for automatic persisted queries.
+const ComplexInlineFragments_OperationHash = "f09843b664f7b57ac9038c111235c36eed3d41ac6bce61f915d5e2999389cc38"
+
 // We test all the spread cases from docs/design.md, see there for more context
 // on each, as well as various other nonsense.  But for abstract-in-abstract
 // spreads, we can't test cases (4b) and (4c), where I implements J or vice
@@ -1438,8 +1441,9 @@ func ComplexInlineFragments(
 	client_ graphql.Client,
 ) (data_ *ComplexInlineFragmentsResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "ComplexInlineFragments",
-		Query:  ComplexInlineFragments_Operation,
+		OpName:    "ComplexInlineFragments",
+		Query:     ComplexInlineFragments_Operation,
+		QueryHash: ComplexInlineFragments_OperationHash,
 	}
 
 	data_ = &ComplexInlineFragmentsResponse{}
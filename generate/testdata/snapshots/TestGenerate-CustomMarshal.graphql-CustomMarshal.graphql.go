@@ -184,13 +184,17 @@ query CustomMarshal ($date: Date!) {
 }
 `
 
+// The SHA-256 hash of CustomMarshal_Operation, used for automatic persisted queries.
+const CustomMarshal_OperationHash = "1f3275dbf79603129b29d2f3f64d3c68bef1826ae5bd30fac8a002bddd8a70d3"
+
 func CustomMarshal(
 	client_ graphql.Client,
 	date time.Time,
 ) (data_ *CustomMarshalResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "CustomMarshal",
-		Query:  CustomMarshal_Operation,
+		OpName:    "CustomMarshal",
+		Query:     CustomMarshal_Operation,
+		QueryHash: CustomMarshal_OperationHash,
 		Variables: &__CustomMarshalInput{
 			Date: date,
 		},
@@ -211,14 +211,18 @@ query CustomMarshalSlice ($datesss: [[[Date!]!]!]!, $datesssp: [[[Date!]!]!]!) {
 }
 `
 
+// The SHA-256 hash of CustomMarshalSlice_Operation, used for automatic persisted queries.
+const CustomMarshalSlice_OperationHash = "d7203769079f341ac6cfc2984d993ef54bd781831bc86037fef55ccf4e216bc1"
+
 func CustomMarshalSlice(
 	client_ graphql.Client,
 	datesss [][][]time.Time,
 	datesssp [][][]*time.Time,
 ) (data_ *CustomMarshalSliceResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "CustomMarshalSlice",
-		Query:  CustomMarshalSlice_Operation,
+		OpName:    "CustomMarshalSlice",
+		Query:     CustomMarshalSlice_Operation,
+		QueryHash: CustomMarshalSlice_OperationHash,
 		Variables: &__CustomMarshalSliceInput{
 			Datesss:  datesss,
 			Datesssp: datesssp,
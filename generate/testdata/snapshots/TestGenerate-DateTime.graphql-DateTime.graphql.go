@@ -35,14 +35,18 @@ query convertTimezone ($dt: DateTime!, $tz: String) {
 }
 `
 
+// The SHA-256 hash of convertTimezone_Operation, used for automatic persisted queries.
+const convertTimezone_OperationHash = "a1245b310182fd1d1525790d7341925f9934ade569d30ebf2630d1445ad8caa9"
+
 func convertTimezone(
 	client_ graphql.Client,
 	dt time.Time,
 	tz string,
 ) (data_ *convertTimezoneResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "convertTimezone",
-		Query:  convertTimezone_Operation,
+		OpName:    "convertTimezone",
+		Query:     convertTimezone_Operation,
+		QueryHash: convertTimezone_OperationHash,
 		Variables: &__convertTimezoneInput{
 			Dt: dt,
 			Tz: tz,
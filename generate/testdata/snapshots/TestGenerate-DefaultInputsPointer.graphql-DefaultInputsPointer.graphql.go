@@ -40,6 +40,9 @@ query DefaultInputs ($input: InputWithDefaults!) {
 }
 `
 
+// The SHA-256 hash of DefaultInputs_Operation, used for automatic persisted queries.
+const DefaultInputs_OperationHash = "b1a8b9014a9b40ceec3938b0f2e515bba7da1165eb9b35e48553651c19bd3f31"
+
 // The `InputWithDefaults.field` cannot be `pointer: true`, together with implicit `omitempty: false`, as `null` is
 // not a valid value there. However, nullableField should still be ok
 // (this will send null, overwriting the server's default)
@@ -48,8 +51,9 @@ func DefaultInputs(
 	input InputWithDefaults,
 ) (data_ *DefaultInputsResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "DefaultInputs",
-		Query:  DefaultInputs_Operation,
+		OpName:    "DefaultInputs",
+		Query:     DefaultInputs_Operation,
+		QueryHash: DefaultInputs_OperationHash,
 		Variables: &__DefaultInputsInput{
 			Input: input,
 		},
@@ -40,14 +40,18 @@ query DefaultInputs ($input: InputWithDefaults!) {
 }
 `
 
+// The SHA-256 hash of DefaultInputs_Operation, used for automatic persisted queries.
+const DefaultInputs_OperationHash = "b1a8b9014a9b40ceec3938b0f2e515bba7da1165eb9b35e48553651c19bd3f31"
+
 // very similar to DefaultInputsWithForDirective.graphql - same expected behaviour, but takes a different code path(?)
 func DefaultInputs(
 	client_ graphql.Client,
 	input InputWithDefaults,
 ) (data_ *DefaultInputsResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "DefaultInputs",
-		Query:  DefaultInputs_Operation,
+		OpName:    "DefaultInputs",
+		Query:     DefaultInputs_Operation,
+		QueryHash: DefaultInputs_OperationHash,
 		Variables: &__DefaultInputsInput{
 			Input: input,
 		},
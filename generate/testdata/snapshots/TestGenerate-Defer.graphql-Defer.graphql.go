@@ -0,0 +1,258 @@
+// Code generated by github.com/Khan/genqlient, DO NOT EDIT.
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/Khan/genqlient/internal/testutil"
+)
+
+// DeferResponse is returned by Defer on success.
+type DeferResponse struct {
+	// user looks up a user by some stuff.
+	//
+	// See UserQueryInput for what stuff is supported.
+	// If query is null, returns the current user.
+	User DeferUser `json:"user"`
+}
+
+// GetUser returns DeferResponse.User, and is useful for accessing the field via an interface.
+func (v *DeferResponse) GetUser() DeferUser { return v.User }
+
+// DeferUser includes the requested fields of the GraphQL type User.
+// The GraphQL type's documentation follows.
+//
+// A User is a user!
+type DeferUser struct {
+	// id is the user's ID.
+	//
+	// It is stable, unique, and opaque, like all good IDs.
+	Id                 testutil.ID `json:"id"`
+	DeferredUserFields `json:"-"`
+	// Whether the deferred fragment DeferredUserFields has been received.
+	DeferredUserFieldsReceived graphql.Received `json:"genqlient_deferred_DeferredUserFields"`
+	Emails                     []string         `json:"emails"`
+	// Whether the deferred fragment emails has been received.
+	EmailsReceived graphql.Received `json:"genqlient_deferred_emails"`
+}
+
+// GetId returns DeferUser.Id, and is useful for accessing the field via an interface.
+func (v *DeferUser) GetId() testutil.ID { return v.Id }
+
+// GetDeferredUserFieldsReceived returns DeferUser.DeferredUserFieldsReceived, and is useful for accessing the field via an interface.
+func (v *DeferUser) GetDeferredUserFieldsReceived() graphql.Received {
+	return v.DeferredUserFieldsReceived
+}
+
+// GetEmails returns DeferUser.Emails, and is useful for accessing the field via an interface.
+func (v *DeferUser) GetEmails() []string { return v.Emails }
+
+// GetEmailsReceived returns DeferUser.EmailsReceived, and is useful for accessing the field via an interface.
+func (v *DeferUser) GetEmailsReceived() graphql.Received { return v.EmailsReceived }
+
+// GetName returns DeferUser.Name, and is useful for accessing the field via an interface.
+func (v *DeferUser) GetName() string { return v.DeferredUserFields.Name }
+
+// GetBirthdate returns DeferUser.Birthdate, and is useful for accessing the field via an interface.
+func (v *DeferUser) GetBirthdate() time.Time { return v.DeferredUserFields.Birthdate }
+
+func (v *DeferUser) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*DeferUser
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.DeferUser = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(
+		b, &v.DeferredUserFields)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type __premarshalDeferUser struct {
+	Id testutil.ID `json:"id"`
+
+	DeferredUserFieldsReceived graphql.Received `json:"genqlient_deferred_DeferredUserFields"`
+
+	Emails []string `json:"emails"`
+
+	EmailsReceived graphql.Received `json:"genqlient_deferred_emails"`
+
+	Name string `json:"name"`
+
+	Birthdate json.RawMessage `json:"birthdate"`
+}
+
+func (v *DeferUser) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *DeferUser) __premarshalJSON() (*__premarshalDeferUser, error) {
+	var retval __premarshalDeferUser
+
+	retval.Id = v.Id
+	retval.DeferredUserFieldsReceived = v.DeferredUserFieldsReceived
+	retval.Emails = v.Emails
+	retval.EmailsReceived = v.EmailsReceived
+	retval.Name = v.DeferredUserFields.Name
+	{
+
+		dst := &retval.Birthdate
+		src := v.DeferredUserFields.Birthdate
+		var err error
+		*dst, err = testutil.MarshalDate(
+			&src)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"unable to marshal DeferUser.DeferredUserFields.Birthdate: %w", err)
+		}
+	}
+	return &retval, nil
+}
+
+// DeferredUserFields includes the GraphQL fields of User requested by the fragment DeferredUserFields.
+// The GraphQL type's documentation follows.
+//
+// A User is a user!
+type DeferredUserFields struct {
+	Name      string    `json:"name"`
+	Birthdate time.Time `json:"-"`
+}
+
+// GetName returns DeferredUserFields.Name, and is useful for accessing the field via an interface.
+func (v *DeferredUserFields) GetName() string { return v.Name }
+
+// GetBirthdate returns DeferredUserFields.Birthdate, and is useful for accessing the field via an interface.
+func (v *DeferredUserFields) GetBirthdate() time.Time { return v.Birthdate }
+
+func (v *DeferredUserFields) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*DeferredUserFields
+		Birthdate json.RawMessage `json:"birthdate"`
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.DeferredUserFields = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	{
+		dst := &v.Birthdate
+		src := firstPass.Birthdate
+		if len(src) != 0 && string(src) != "null" {
+			err = testutil.UnmarshalDate(
+				src, dst)
+			if err != nil {
+				return fmt.Errorf(
+					"unable to unmarshal DeferredUserFields.Birthdate: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+type __premarshalDeferredUserFields struct {
+	Name string `json:"name"`
+
+	Birthdate json.RawMessage `json:"birthdate"`
+}
+
+func (v *DeferredUserFields) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *DeferredUserFields) __premarshalJSON() (*__premarshalDeferredUserFields, error) {
+	var retval __premarshalDeferredUserFields
+
+	retval.Name = v.Name
+	{
+
+		dst := &retval.Birthdate
+		src := v.Birthdate
+		var err error
+		*dst, err = testutil.MarshalDate(
+			&src)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"unable to marshal DeferredUserFields.Birthdate: %w", err)
+		}
+	}
+	return &retval, nil
+}
+
+// The query executed by Defer.
+const Defer_Operation = `
+query Defer {
+	user {
+		id
+		... on User @defer(label: "DeferredUserFields") {
+			... DeferredUserFields
+			genqlient_deferred_DeferredUserFields: __typename
+		}
+		... @defer(label: "emails") {
+			emails
+			genqlient_deferred_emails: __typename
+		}
+	}
+}
+fragment DeferredUserFields on User {
+	name
+	birthdate
+}
+`
+
+// The SHA-256 hash of Defer_Operation, used for automatic persisted queries.
+const Defer_OperationHash = "df3176d14d29e8432f4ee769e85ad637a49b6a22154678051ccc8ee8c5192ff0"
+
+func Defer(
+	client_ graphql.Client,
+) (data_ *DeferResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName:    "Defer",
+		Query:     Defer_Operation,
+		QueryHash: Defer_OperationHash,
+	}
+
+	data_ = &DeferResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		nil,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
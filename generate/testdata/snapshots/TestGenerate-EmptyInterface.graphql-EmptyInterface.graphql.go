@@ -28,12 +28,16 @@ query EmptyInterface {
 }
 `
 
+// The SHA-256 hash of EmptyInterface_Operation, used for automatic persisted queries.
+const EmptyInterface_OperationHash = "a908ad3ed5d267ab9c885d8bf7da43fde1ff94e96e0992bb640356974c1de8e4"
+
 func EmptyInterface(
 	client_ graphql.Client,
 ) (data_ *EmptyInterfaceResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "EmptyInterface",
-		Query:  EmptyInterface_Operation,
+		OpName:    "EmptyInterface",
+		Query:     EmptyInterface_Operation,
+		QueryHash: EmptyInterface_OperationHash,
 	}
 
 	data_ = &EmptyInterfaceResponse{}
@@ -304,12 +304,16 @@ fragment ChildVideoFields on Video {
 }
 `
 
+// The SHA-256 hash of ComplexNamedFragments_Operation, used for automatic persisted queries.
+const ComplexNamedFragments_OperationHash = "cb1f1eadfc828200f17fb9d21d4f98d5d2cc1f5873d31abfeb55cdad7ebb5327"
+
 func ComplexNamedFragments(
 	client_ graphql.Client,
 ) (data_ *InnerQueryFragment, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "ComplexNamedFragments",
-		Query:  ComplexNamedFragments_Operation,
+		OpName:    "ComplexNamedFragments",
+		Query:     ComplexNamedFragments_Operation,
+		QueryHash: ComplexNamedFragments_OperationHash,
 	}
 
 	data_ = &InnerQueryFragment{}
@@ -91,13 +91,17 @@ query GetPokemon ($where: getPokemonBoolExp!) {
 }
 `
 
+// The SHA-256 hash of GetPokemon_Operation, used for automatic persisted queries.
+const GetPokemon_OperationHash = "8ade09214988e27fccf6e751a6ec1406bae57017ad9b6fa327f520bd22edc8fd"
+
 func GetPokemon(
 	client_ graphql.Client,
 	where *GetPokemonBoolExp,
 ) (data_ *GetPokemonResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "GetPokemon",
-		Query:  GetPokemon_Operation,
+		OpName:    "GetPokemon",
+		Query:     GetPokemon_Operation,
+		QueryHash: GetPokemon_OperationHash,
 		Variables: &__GetPokemonInput{
 			Where: where,
 		},
@@ -68,13 +68,17 @@ query InputEnumQuery ($role: Role!) {
 }
 `
 
+// The SHA-256 hash of InputEnumQuery_Operation, used for automatic persisted queries.
+const InputEnumQuery_OperationHash = "2c1ddbd6235b8d379ecf482d08ce9c2008e78284fdac86efaff30895d3c4b297"
+
 func InputEnumQuery(
 	client_ graphql.Client,
 	role Role,
 ) (data_ *InputEnumQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "InputEnumQuery",
-		Query:  InputEnumQuery_Operation,
+		OpName:    "InputEnumQuery",
+		Query:     InputEnumQuery_Operation,
+		QueryHash: InputEnumQuery_OperationHash,
 		Variables: &__InputEnumQueryInput{
 			Role: role,
 		},
@@ -191,13 +191,17 @@ query InputObjectQuery ($query: UserQueryInput) {
 }
 `
 
+// The SHA-256 hash of InputObjectQuery_Operation, used for automatic persisted queries.
+const InputObjectQuery_OperationHash = "e014b248f8fbb4c1c1af234caddac3ccac15e406513056c9f2461089c87d9716"
+
 func InputObjectQuery(
 	client_ graphql.Client,
 	query UserQueryInput,
 ) (data_ *InputObjectQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "InputObjectQuery",
-		Query:  InputObjectQuery_Operation,
+		OpName:    "InputObjectQuery",
+		Query:     InputObjectQuery_Operation,
+		QueryHash: InputObjectQuery_OperationHash,
 		Variables: &__InputObjectQueryInput{
 			Query: query,
 		},
@@ -544,12 +544,16 @@ query InterfaceListField {
 }
 `
 
+// The SHA-256 hash of InterfaceListField_Operation, used for automatic persisted queries.
+const InterfaceListField_OperationHash = "0b32d3d26550ecd09143c2228175edd7707032d62a45b8b509852943499ddc62"
+
 func InterfaceListField(
 	client_ graphql.Client,
 ) (data_ *InterfaceListFieldResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "InterfaceListField",
-		Query:  InterfaceListField_Operation,
+		OpName:    "InterfaceListField",
+		Query:     InterfaceListField_Operation,
+		QueryHash: InterfaceListField_OperationHash,
 	}
 
 	data_ = &InterfaceListFieldResponse{}
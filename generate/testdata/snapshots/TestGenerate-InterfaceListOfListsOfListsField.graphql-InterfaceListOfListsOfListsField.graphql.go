@@ -522,12 +522,16 @@ query InterfaceListOfListOfListsField {
 }
 `
 
+// The SHA-256 hash of InterfaceListOfListOfListsField_Operation, used for automatic persisted queries.
+const InterfaceListOfListOfListsField_OperationHash = "a56baea97fa9125de4ea4438c2fe30c1d71c6f940577d7d14af6100b9f13c655"
+
 func InterfaceListOfListOfListsField(
 	client_ graphql.Client,
 ) (data_ *InterfaceListOfListOfListsFieldResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "InterfaceListOfListOfListsField",
-		Query:  InterfaceListOfListOfListsField_Operation,
+		OpName:    "InterfaceListOfListOfListsField",
+		Query:     InterfaceListOfListOfListsField_Operation,
+		QueryHash: InterfaceListOfListOfListsField_OperationHash,
 	}
 
 	data_ = &InterfaceListOfListOfListsFieldResponse{}
@@ -50,13 +50,17 @@ query ListInputQuery ($names: [String]) {
 }
 `
 
+// The SHA-256 hash of ListInputQuery_Operation, used for automatic persisted queries.
+const ListInputQuery_OperationHash = "426daf556301f116c01dc169d6a3f11479f2a4bd6c1b3a08002d8f06d3642746"
+
 func ListInputQuery(
 	client_ graphql.Client,
 	names []string,
 ) (data_ *ListInputQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "ListInputQuery",
-		Query:  ListInputQuery_Operation,
+		OpName:    "ListInputQuery",
+		Query:     ListInputQuery_Operation,
+		QueryHash: ListInputQuery_OperationHash,
 		Variables: &__ListInputQueryInput{
 			Names: names,
 		},
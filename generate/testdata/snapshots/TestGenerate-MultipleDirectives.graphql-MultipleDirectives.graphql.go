@@ -342,14 +342,18 @@ query MultipleDirectives ($query: UserQueryInput, $queries: [UserQueryInput]) {
 }
 `
 
+// The SHA-256 hash of MultipleDirectives_Operation, used for automatic persisted queries.
+const MultipleDirectives_OperationHash = "ff4be913bde52e0f5a7646752695f82f0bd5c5418e16e6447eac3aec08529995"
+
 func MultipleDirectives(
 	client_ graphql.Client,
 	query MyInput,
 	queries []*UserQueryInput,
 ) (data_ *MyMultipleDirectivesResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "MultipleDirectives",
-		Query:  MultipleDirectives_Operation,
+		OpName:    "MultipleDirectives",
+		Query:     MultipleDirectives_Operation,
+		QueryHash: MultipleDirectives_OperationHash,
 		Variables: &__MultipleDirectivesInput{
 			Query:   query,
 			Queries: queries,
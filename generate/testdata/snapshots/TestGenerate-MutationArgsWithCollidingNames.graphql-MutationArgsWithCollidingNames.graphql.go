@@ -60,6 +60,9 @@ mutation MutationArgsWithCollidingNames ($data: String!, $req: Int, $resp: Int,
 }
 `
 
+// The SHA-256 hash of MutationArgsWithCollidingNames_Operation, used for automatic persisted queries.
+const MutationArgsWithCollidingNames_OperationHash = "708640df257e9ce47660f48fe4f62257eef21c24042866e6a41f575dd3486104"
+
 func MutationArgsWithCollidingNames(
 	client_ graphql.Client,
 	data string,
@@ -68,8 +71,9 @@ func MutationArgsWithCollidingNames(
 	client string,
 ) (data_ *MutationArgsWithCollidingNamesResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "MutationArgsWithCollidingNames",
-		Query:  MutationArgsWithCollidingNames_Operation,
+		OpName:    "MutationArgsWithCollidingNames",
+		Query:     MutationArgsWithCollidingNames_Operation,
+		QueryHash: MutationArgsWithCollidingNames_OperationHash,
 		Variables: &__MutationArgsWithCollidingNamesInput{
 			Data:   data,
 			Req:    req,
@@ -238,6 +238,9 @@ query OmitEmptyQuery ($query: UserQueryInput, $queries: [UserQueryInput], $dt: D
 }
 `
 
+// The SHA-256 hash of OmitEmptyQuery_Operation, used for automatic persisted queries.
+const OmitEmptyQuery_OperationHash = "0b1d1ed1d5cac96c5c5ae37cf81e32daf6b41b81b292efd8cc334c3c22d43e38"
+
 func OmitEmptyQuery(
 	client_ graphql.Client,
 	query UserQueryInput,
@@ -247,8 +250,9 @@ func OmitEmptyQuery(
 	tzNoOmitEmpty string,
 ) (data_ *OmitEmptyQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "OmitEmptyQuery",
-		Query:  OmitEmptyQuery_Operation,
+		OpName:    "OmitEmptyQuery",
+		Query:     OmitEmptyQuery_Operation,
+		QueryHash: OmitEmptyQuery_OperationHash,
 		Variables: &__OmitEmptyQueryInput{
 			Query:         query,
 			Queries:       queries,
@@ -40,13 +40,17 @@ query OmitemptyFalse ($input: OmitemptyInput) {
 }
 `
 
+// The SHA-256 hash of OmitemptyFalse_Operation, used for automatic persisted queries.
+const OmitemptyFalse_OperationHash = "1dd9a958c616857c32e74315ba15ad044cab616a5d80e23356c0853101c4d302"
+
 func OmitemptyFalse(
 	client_ graphql.Client,
 	input OmitemptyInput,
 ) (data_ *OmitemptyFalseResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "OmitemptyFalse",
-		Query:  OmitemptyFalse_Operation,
+		OpName:    "OmitemptyFalse",
+		Query:     OmitemptyFalse_Operation,
+		QueryHash: OmitemptyFalse_OperationHash,
 		Variables: &__OmitemptyFalseInput{
 			Input: input,
 		},
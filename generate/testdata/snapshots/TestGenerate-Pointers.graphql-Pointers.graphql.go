@@ -252,6 +252,9 @@ query PointersQuery ($query: UserQueryInput, $dt: DateTime, $tz: String) {
 }
 `
 
+// The SHA-256 hash of PointersQuery_Operation, used for automatic persisted queries.
+const PointersQuery_OperationHash = "dcc908c6d389c40f88997f15b05db6fbf79cc0f75c3858b1f22cf4e0ad2c17f1"
+
 func PointersQuery(
 	client_ graphql.Client,
 	query *UserQueryInput,
@@ -259,8 +262,9 @@ func PointersQuery(
 	tz *string,
 ) (data_ *PointersQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "PointersQuery",
-		Query:  PointersQuery_Operation,
+		OpName:    "PointersQuery",
+		Query:     PointersQuery_Operation,
+		QueryHash: PointersQuery_OperationHash,
 		Variables: &__PointersQueryInput{
 			Query: query,
 			Dt:    dt,
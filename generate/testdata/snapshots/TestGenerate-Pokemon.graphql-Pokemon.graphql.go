@@ -90,13 +90,17 @@ query GetPokemonSiblings ($input: PokemonInput!) {
 }
 `
 
+// The SHA-256 hash of GetPokemonSiblings_Operation, used for automatic persisted queries.
+const GetPokemonSiblings_OperationHash = "ddca1f33a7e7152077694fd5cc75eeedff31c977c4961b80c1591d4db74696bc"
+
 func GetPokemonSiblings(
 	client_ graphql.Client,
 	input testutil.Pokemon,
 ) (data_ *GetPokemonSiblingsResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "GetPokemonSiblings",
-		Query:  GetPokemonSiblings_Operation,
+		OpName:    "GetPokemonSiblings",
+		Query:     GetPokemonSiblings_Operation,
+		QueryHash: GetPokemonSiblings_OperationHash,
 		Variables: &__GetPokemonSiblingsInput{
 			Input: input,
 		},
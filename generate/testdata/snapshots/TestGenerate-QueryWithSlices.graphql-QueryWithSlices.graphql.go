@@ -53,12 +53,16 @@ query QueryWithSlices {
 }
 `
 
+// The SHA-256 hash of QueryWithSlices_Operation, used for automatic persisted queries.
+const QueryWithSlices_OperationHash = "5d4142d36f8dc723dec90c83e7140d26bbc4b8c6bdb4ab24bad9282b1ce71de6"
+
 func QueryWithSlices(
 	client_ graphql.Client,
 ) (data_ *QueryWithSlicesResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "QueryWithSlices",
-		Query:  QueryWithSlices_Operation,
+		OpName:    "QueryWithSlices",
+		Query:     QueryWithSlices_Operation,
+		QueryHash: QueryWithSlices_OperationHash,
 	}
 
 	data_ = &QueryWithSlicesResponse{}
@@ -55,12 +55,16 @@ query QueryWithStructs {
 }
 `
 
+// The SHA-256 hash of QueryWithStructs_Operation, used for automatic persisted queries.
+const QueryWithStructs_OperationHash = "a9f9b2a3048c540188752153837017ebd5f9cfd71ceafa610990bc5d78a5ddc2"
+
 func QueryWithStructs(
 	client_ graphql.Client,
 ) (data_ *QueryWithStructsResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "QueryWithStructs",
-		Query:  QueryWithStructs_Operation,
+		OpName:    "QueryWithStructs",
+		Query:     QueryWithStructs_Operation,
+		QueryHash: QueryWithStructs_OperationHash,
 	}
 
 	data_ = &QueryWithStructsResponse{}
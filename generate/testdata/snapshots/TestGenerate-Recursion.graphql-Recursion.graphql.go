@@ -83,13 +83,17 @@ query Recursion ($input: RecursiveInput!) {
 }
 `
 
+// The SHA-256 hash of Recursion_Operation, used for automatic persisted queries.
+const Recursion_OperationHash = "df909aae5eb8934ed58a5f8a61ecfaddadaf22f4951bc4533980553a1f9e3580"
+
 func Recursion(
 	client_ graphql.Client,
 	input RecursiveInput,
 ) (data_ *RecursionResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "Recursion",
-		Query:  Recursion_Operation,
+		OpName:    "Recursion",
+		Query:     Recursion_Operation,
+		QueryHash: Recursion_OperationHash,
 		Variables: &__RecursionInput{
 			Input: input,
 		},
@@ -50,13 +50,17 @@ query SimpleInputQuery ($name: String!) {
 }
 `
 
+// The SHA-256 hash of SimpleInputQuery_Operation, used for automatic persisted queries.
+const SimpleInputQuery_OperationHash = "84ce0c464ccde445c4d1597dd7517a3fe6d4b520f7ddfbf3909ea28bef900cde"
+
 func SimpleInputQuery(
 	client_ graphql.Client,
 	name string,
 ) (data_ *SimpleInputQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "SimpleInputQuery",
-		Query:  SimpleInputQuery_Operation,
+		OpName:    "SimpleInputQuery",
+		Query:     SimpleInputQuery_Operation,
+		QueryHash: SimpleInputQuery_OperationHash,
 		Variables: &__SimpleInputQueryInput{
 			Name: name,
 		},
@@ -51,6 +51,9 @@ mutation SimpleMutation ($name: String!) {
 }
 `
 
+// The SHA-256 hash of SimpleMutation_Operation, used for automatic persisted queries.
+const SimpleMutation_OperationHash = "560dcb2261471cee26fc98a42a3e1e3547d87470bf2979b200b178803b0fdc09"
+
 // SimpleMutation creates a user.
 //
 // It has a long doc-comment, to test that we handle that correctly.
@@ -60,8 +63,9 @@ func SimpleMutation(
 	name string,
 ) (data_ *SimpleMutationResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "SimpleMutation",
-		Query:  SimpleMutation_Operation,
+		OpName:    "SimpleMutation",
+		Query:     SimpleMutation_Operation,
+		QueryHash: SimpleMutation_OperationHash,
 		Variables: &__SimpleMutationInput{
 			Name: name,
 		},
@@ -571,12 +571,16 @@ fragment VideoFields on Video {
 }
 `
 
+// The SHA-256 hash of SimpleNamedFragment_Operation, used for automatic persisted queries.
+const SimpleNamedFragment_OperationHash = "e032b6f75826bba2f7581d052d14841bb283d128de1d3a93871299b749c82b64"
+
 func SimpleNamedFragment(
 	client_ graphql.Client,
 ) (data_ *SimpleNamedFragmentResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "SimpleNamedFragment",
-		Query:  SimpleNamedFragment_Operation,
+		OpName:    "SimpleNamedFragment",
+		Query:     SimpleNamedFragment_Operation,
+		QueryHash: SimpleNamedFragment_OperationHash,
 	}
 
 	data_ = &SimpleNamedFragmentResponse{}
@@ -47,12 +47,16 @@ query SimpleQueryNoOverride {
 }
 `
 
+// The SHA-256 hash of SimpleQueryNoOverride_Operation, used for automatic persisted queries.
+const SimpleQueryNoOverride_OperationHash = "1726a0b9a4fdd20dff8564ac1cd39f383b3688f2ff9d30dda31e501a0189fd4c"
+
 func SimpleQueryNoOverride(
 	client_ graphql.Client,
 ) (data_ *SimpleQueryNoOverrideResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "SimpleQueryNoOverride",
-		Query:  SimpleQueryNoOverride_Operation,
+		OpName:    "SimpleQueryNoOverride",
+		Query:     SimpleQueryNoOverride_Operation,
+		QueryHash: SimpleQueryNoOverride_OperationHash,
 	}
 
 	data_ = &SimpleQueryNoOverrideResponse{}
@@ -24,13 +24,17 @@ subscription SimpleSubscription {
 }
 `
 
+// The SHA-256 hash of SimpleSubscription_Operation, used for automatic persisted queries.
+const SimpleSubscription_OperationHash = "f1eaa4d1b1c2eded77f800897dcdff0063d51c85e7edce8e93cff86c25e7649c"
+
 // To unsubscribe, use [graphql.WebSocketClient.Unsubscribe]
 func SimpleSubscription(
 	client_ graphql.WebSocketClient,
 ) (dataChan_ chan SimpleSubscriptionWsResponse, subscriptionID_ string, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "SimpleSubscription",
-		Query:  SimpleSubscription_Operation,
+		OpName:    "SimpleSubscription",
+		Query:     SimpleSubscription_Operation,
+		QueryHash: SimpleSubscription_OperationHash,
 	}
 
 	dataChan_ = make(chan SimpleSubscriptionWsResponse)
@@ -460,12 +460,16 @@ fragment VideoFields on Video {
 }
 `
 
+// The SHA-256 hash of StructOption_Operation, used for automatic persisted queries.
+const StructOption_OperationHash = "5ab7399fda700c85de8d829193852aa565b8652fbbcd04754ab3ed487aaf302f"
+
 func StructOption(
 	client_ graphql.Client,
 ) (data_ *StructOptionResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "StructOption",
-		Query:  StructOption_Operation,
+		OpName:    "StructOption",
+		Query:     StructOption_Operation,
+		QueryHash: StructOption_OperationHash,
 	}
 
 	data_ = &StructOptionResponse{}
@@ -47,12 +47,16 @@ query TypeNameQuery {
 }
 `
 
+// The SHA-256 hash of TypeNameQuery_Operation, used for automatic persisted queries.
+const TypeNameQuery_OperationHash = "09a8305acea8d6c2773e088d6249ac26a6a3ab7b8b413f320edd7d8d765bba15"
+
 func TypeNameQuery(
 	client_ graphql.Client,
 ) (data_ *TypeNameQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "TypeNameQuery",
-		Query:  TypeNameQuery_Operation,
+		OpName:    "TypeNameQuery",
+		Query:     TypeNameQuery_Operation,
+		QueryHash: TypeNameQuery_OperationHash,
 	}
 
 	data_ = &TypeNameQueryResponse{}
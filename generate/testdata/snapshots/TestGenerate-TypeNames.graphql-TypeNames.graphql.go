@@ -283,12 +283,16 @@ query TypeNames {
 }
 `
 
+// The SHA-256 hash of TypeNames_Operation, used for automatic persisted queries.
+const TypeNames_OperationHash = "ec9a4dcc2da9c4b8fa4960a1a40cd1ab9dab77fce17a6a02b3d01f3bc54d6deb"
+
 func TypeNames(
 	client_ graphql.Client,
 ) (data_ *Resp, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "TypeNames",
-		Query:  TypeNames_Operation,
+		OpName:    "TypeNames",
+		Query:     TypeNames_Operation,
+		QueryHash: TypeNames_OperationHash,
 	}
 
 	data_ = &Resp{}
@@ -183,12 +183,16 @@ query UnionNoFragmentsQuery {
 }
 `
 
+// The SHA-256 hash of UnionNoFragmentsQuery_Operation, used for automatic persisted queries.
+const UnionNoFragmentsQuery_OperationHash = "a623ac1b42f347c98c41daae7d660a060edfd6efcc6854362a0f53bd708f6cf5"
+
 func UnionNoFragmentsQuery(
 	client_ graphql.Client,
 ) (data_ *UnionNoFragmentsQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "UnionNoFragmentsQuery",
-		Query:  UnionNoFragmentsQuery_Operation,
+		OpName:    "UnionNoFragmentsQuery",
+		Query:     UnionNoFragmentsQuery_Operation,
+		QueryHash: UnionNoFragmentsQuery_OperationHash,
 	}
 
 	data_ = &UnionNoFragmentsQueryResponse{}
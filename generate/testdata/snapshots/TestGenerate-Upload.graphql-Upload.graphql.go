@@ -0,0 +1,88 @@
+// Code generated by github.com/Khan/genqlient, DO NOT EDIT.
+
+package test
+
+import (
+	"github.com/Khan/genqlient/graphql"
+	"github.com/Khan/genqlient/internal/testutil"
+)
+
+// UploadAvatarResponse is returned by UploadAvatar on success.
+type UploadAvatarResponse struct {
+	UploadAvatar UploadAvatarUploadAvatarUser `json:"uploadAvatar"`
+}
+
+// GetUploadAvatar returns UploadAvatarResponse.UploadAvatar, and is useful for accessing the field via an interface.
+func (v *UploadAvatarResponse) GetUploadAvatar() UploadAvatarUploadAvatarUser { return v.UploadAvatar }
+
+// UploadAvatarUploadAvatarUser includes the requested fields of the GraphQL type User.
+// The GraphQL type's documentation follows.
+//
+// A User is a user!
+type UploadAvatarUploadAvatarUser struct {
+	// id is the user's ID.
+	//
+	// It is stable, unique, and opaque, like all good IDs.
+	Id testutil.ID `json:"id"`
+}
+
+// GetId returns UploadAvatarUploadAvatarUser.Id, and is useful for accessing the field via an interface.
+func (v *UploadAvatarUploadAvatarUser) GetId() testutil.ID { return v.Id }
+
+// __UploadAvatarInput is used internally by genqlient
+type __UploadAvatarInput struct {
+	UserId     testutil.ID      `json:"userId"`
+	File       graphql.Upload   `json:"file"`
+	Thumbnails []graphql.Upload `json:"thumbnails"`
+}
+
+// GetUserId returns __UploadAvatarInput.UserId, and is useful for accessing the field via an interface.
+func (v *__UploadAvatarInput) GetUserId() testutil.ID { return v.UserId }
+
+// GetFile returns __UploadAvatarInput.File, and is useful for accessing the field via an interface.
+func (v *__UploadAvatarInput) GetFile() graphql.Upload { return v.File }
+
+// GetThumbnails returns __UploadAvatarInput.Thumbnails, and is useful for accessing the field via an interface.
+func (v *__UploadAvatarInput) GetThumbnails() []graphql.Upload { return v.Thumbnails }
+
+// The mutation executed by UploadAvatar.
+const UploadAvatar_Operation = `
+mutation UploadAvatar ($userId: ID!, $file: Upload!, $thumbnails: [Upload!]) {
+	uploadAvatar(userId: $userId, file: $file, thumbnails: $thumbnails) {
+		id
+	}
+}
+`
+
+// The SHA-256 hash of UploadAvatar_Operation, used for automatic persisted queries.
+const UploadAvatar_OperationHash = "85c50f07aa42d88b8bd4929e2cee22795dbdeb6eda2b9b3bbf50f42c7e45822a"
+
+func UploadAvatar(
+	client_ graphql.Client,
+	userId testutil.ID,
+	file graphql.Upload,
+	thumbnails []graphql.Upload,
+) (data_ *UploadAvatarResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName:    "UploadAvatar",
+		Query:     UploadAvatar_Operation,
+		QueryHash: UploadAvatar_OperationHash,
+		Variables: &__UploadAvatarInput{
+			UserId:     userId,
+			File:       file,
+			Thumbnails: thumbnails,
+		},
+	}
+
+	data_ = &UploadAvatarResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		nil,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
@@ -79,12 +79,16 @@ query UsesEnumTwiceQuery {
 }
 `
 
+// The SHA-256 hash of UsesEnumTwiceQuery_Operation, used for automatic persisted queries.
+const UsesEnumTwiceQuery_OperationHash = "6023f30cb636680d47ab152895913ca024d5b09d136d198f99559efe4d7257d1"
+
 func UsesEnumTwiceQuery(
 	client_ graphql.Client,
 ) (data_ *UsesEnumTwiceQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "UsesEnumTwiceQuery",
-		Query:  UsesEnumTwiceQuery_Operation,
+		OpName:    "UsesEnumTwiceQuery",
+		Query:     UsesEnumTwiceQuery_Operation,
+		QueryHash: UsesEnumTwiceQuery_OperationHash,
 	}
 
 	data_ = &UsesEnumTwiceQueryResponse{}
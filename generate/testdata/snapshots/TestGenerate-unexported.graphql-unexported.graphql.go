@@ -191,13 +191,17 @@ query unexported ($query: UserQueryInput) {
 }
 `
 
+// The SHA-256 hash of unexported_Operation, used for automatic persisted queries.
+const unexported_OperationHash = "d1f8824f45f036da72f40408147242da933fffecc3c3d0ef3f7c5a5aabfe04aa"
+
 func unexported(
 	client_ graphql.Client,
 	query UserQueryInput,
 ) (data_ *unexportedResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "unexported",
-		Query:  unexported_Operation,
+		OpName:    "unexported",
+		Query:     unexported_Operation,
+		QueryHash: unexported_OperationHash,
 		Variables: &__unexportedInput{
 			Query: query,
 		},
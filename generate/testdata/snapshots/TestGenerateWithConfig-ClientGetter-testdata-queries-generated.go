@@ -44,12 +44,16 @@ query SimpleQuery {
 }
 `
 
+// The SHA-256 hash of SimpleQuery_Operation, used for automatic persisted queries.
+const SimpleQuery_OperationHash = "a37e1b1047bf42cf2c9464e0ee6b63c2d382709b63003df64e2d410cb6d043a2"
+
 func SimpleQuery(
 	ctx_ context.Context,
 ) (data_ *SimpleQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "SimpleQuery",
-		Query:  SimpleQuery_Operation,
+		OpName:    "SimpleQuery",
+		Query:     SimpleQuery_Operation,
+		QueryHash: SimpleQuery_OperationHash,
 	}
 	var client_ graphql.Client
 
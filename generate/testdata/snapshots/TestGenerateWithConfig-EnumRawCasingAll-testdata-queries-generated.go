@@ -81,13 +81,17 @@ query QueryWithEnums {
 }
 `
 
+// The SHA-256 hash of QueryWithEnums_Operation, used for automatic persisted queries.
+const QueryWithEnums_OperationHash = "bc2cd19197ad2eeebd4b5e298f054a1d2905bf350102092b8ab331edd376af54"
+
 func QueryWithEnums(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *QueryWithEnumsResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "QueryWithEnums",
-		Query:  QueryWithEnums_Operation,
+		OpName:    "QueryWithEnums",
+		Query:     QueryWithEnums_Operation,
+		QueryHash: QueryWithEnums_OperationHash,
 	}
 
 	data_ = &QueryWithEnumsResponse{}
@@ -0,0 +1,147 @@
+// Code generated by github.com/Khan/genqlient, DO NOT EDIT.
+
+package queries
+
+import (
+	"context"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// SimpleMutationCreateUser includes the requested fields of the GraphQL type User.
+// The GraphQL type's documentation follows.
+//
+// A User is a user!
+type SimpleMutationCreateUser struct {
+	// id is the user's ID.
+	//
+	// It is stable, unique, and opaque, like all good IDs.
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetId returns SimpleMutationCreateUser.Id, and is useful for accessing the field via an interface.
+func (v *SimpleMutationCreateUser) GetId() string { return v.Id }
+
+// GetName returns SimpleMutationCreateUser.Name, and is useful for accessing the field via an interface.
+func (v *SimpleMutationCreateUser) GetName() string { return v.Name }
+
+// SimpleMutationResponse is returned by SimpleMutation on success.
+type SimpleMutationResponse struct {
+	CreateUser SimpleMutationCreateUser `json:"createUser"`
+}
+
+// GetCreateUser returns SimpleMutationResponse.CreateUser, and is useful for accessing the field via an interface.
+func (v *SimpleMutationResponse) GetCreateUser() SimpleMutationCreateUser { return v.CreateUser }
+
+// SimpleQueryResponse is returned by SimpleQuery on success.
+type SimpleQueryResponse struct {
+	// user looks up a user by some stuff.
+	//
+	// See UserQueryInput for what stuff is supported.
+	// If query is null, returns the current user.
+	User SimpleQueryUser `json:"user"`
+}
+
+// GetUser returns SimpleQueryResponse.User, and is useful for accessing the field via an interface.
+func (v *SimpleQueryResponse) GetUser() SimpleQueryUser { return v.User }
+
+// SimpleQueryUser includes the requested fields of the GraphQL type User.
+// The GraphQL type's documentation follows.
+//
+// A User is a user!
+type SimpleQueryUser struct {
+	// id is the user's ID.
+	//
+	// It is stable, unique, and opaque, like all good IDs.
+	Id string `json:"id"`
+}
+
+// GetId returns SimpleQueryUser.Id, and is useful for accessing the field via an interface.
+func (v *SimpleQueryUser) GetId() string { return v.Id }
+
+// __SimpleMutationInput is used internally by genqlient
+type __SimpleMutationInput struct {
+	Name string `json:"name"`
+}
+
+// GetName returns __SimpleMutationInput.Name, and is useful for accessing the field via an interface.
+func (v *__SimpleMutationInput) GetName() string { return v.Name }
+
+// The mutation executed by SimpleMutation.
+const SimpleMutation_Operation = `
+mutation SimpleMutation ($name: String!) {
+	createUser(name: $name) {
+		id
+		name
+	}
+}
+`
+
+// The SHA-256 hash of SimpleMutation_Operation, used for automatic persisted queries.
+const SimpleMutation_OperationHash = "560dcb2261471cee26fc98a42a3e1e3547d87470bf2979b200b178803b0fdc09"
+
+// SimpleMutation creates a user.
+//
+// It has a long doc-comment, to test that we handle that correctly.
+// What a long comment indeed.
+func SimpleMutation(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	name string,
+) (data_ *SimpleMutationResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName:    "SimpleMutation",
+		Query:     SimpleMutation_Operation,
+		QueryHash: SimpleMutation_OperationHash,
+		Variables: &__SimpleMutationInput{
+			Name: name,
+		},
+	}
+
+	data_ = &SimpleMutationResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
+// The query executed by SimpleQuery.
+const SimpleQuery_Operation = `
+query SimpleQuery {
+	user {
+		id
+	}
+}
+`
+
+// The SHA-256 hash of SimpleQuery_Operation, used for automatic persisted queries.
+const SimpleQuery_OperationHash = "a37e1b1047bf42cf2c9464e0ee6b63c2d382709b63003df64e2d410cb6d043a2"
+
+func SimpleQuery(
+	ctx_ context.Context,
+	client_ graphql.Client,
+) (data_ *SimpleQueryResponse, err_ error) {
+	req_ := &graphql.Request{
+		OpName:    "SimpleQuery",
+		Query:     SimpleQuery_Operation,
+		QueryHash: SimpleQuery_OperationHash,
+	}
+
+	data_ = &SimpleQueryResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, err_
+}
+
@@ -148,14 +148,18 @@ query ListInputQuery ($names: [String]) {
 }
 `
 
+// The SHA-256 hash of ListInputQuery_Operation, used for automatic persisted queries.
+const ListInputQuery_OperationHash = "426daf556301f116c01dc169d6a3f11479f2a4bd6c1b3a08002d8f06d3642746"
+
 func ListInputQuery(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	names []*string,
 ) (data_ *ListInputQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "ListInputQuery",
-		Query:  ListInputQuery_Operation,
+		OpName:    "ListInputQuery",
+		Query:     ListInputQuery_Operation,
+		QueryHash: ListInputQuery_OperationHash,
 		Variables: &__ListInputQueryInput{
 			Names: names,
 		},
@@ -185,13 +189,17 @@ query QueryWithSlices {
 }
 `
 
+// The SHA-256 hash of QueryWithSlices_Operation, used for automatic persisted queries.
+const QueryWithSlices_OperationHash = "5d4142d36f8dc723dec90c83e7140d26bbc4b8c6bdb4ab24bad9282b1ce71de6"
+
 func QueryWithSlices(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *QueryWithSlicesResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "QueryWithSlices",
-		Query:  QueryWithSlices_Operation,
+		OpName:    "QueryWithSlices",
+		Query:     QueryWithSlices_Operation,
+		QueryHash: QueryWithSlices_OperationHash,
 	}
 
 	data_ = &QueryWithSlicesResponse{}
@@ -216,13 +224,17 @@ query SimpleQueryNoOverride {
 }
 `
 
+// The SHA-256 hash of SimpleQueryNoOverride_Operation, used for automatic persisted queries.
+const SimpleQueryNoOverride_OperationHash = "1726a0b9a4fdd20dff8564ac1cd39f383b3688f2ff9d30dda31e501a0189fd4c"
+
 func SimpleQueryNoOverride(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *SimpleQueryNoOverrideResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "SimpleQueryNoOverride",
-		Query:  SimpleQueryNoOverride_Operation,
+		OpName:    "SimpleQueryNoOverride",
+		Query:     SimpleQueryNoOverride_Operation,
+		QueryHash: SimpleQueryNoOverride_OperationHash,
 	}
 
 	data_ = &SimpleQueryNoOverrideResponse{}
@@ -247,13 +259,17 @@ query SimpleQueryWithPointerFalseOverride {
 }
 `
 
+// The SHA-256 hash of SimpleQueryWithPointerFalseOverride_Operation, used for automatic persisted queries.
+const SimpleQueryWithPointerFalseOverride_OperationHash = "29e87ff9bf7fe302e5d143ede9b7be1eb9a3770ce2fd2bb3edd76593de2b981a"
+
 func SimpleQueryWithPointerFalseOverride(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *SimpleQueryWithPointerFalseOverrideResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "SimpleQueryWithPointerFalseOverride",
-		Query:  SimpleQueryWithPointerFalseOverride_Operation,
+		OpName:    "SimpleQueryWithPointerFalseOverride",
+		Query:     SimpleQueryWithPointerFalseOverride_Operation,
+		QueryHash: SimpleQueryWithPointerFalseOverride_OperationHash,
 	}
 
 	data_ = &SimpleQueryWithPointerFalseOverrideResponse{}
@@ -86,14 +86,18 @@ query ListInputQuery ($names: [String]) {
 }
 `
 
+// The SHA-256 hash of ListInputQuery_Operation, used for automatic persisted queries.
+const ListInputQuery_OperationHash = "426daf556301f116c01dc169d6a3f11479f2a4bd6c1b3a08002d8f06d3642746"
+
 func ListInputQuery(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	names []string,
 ) (data_ *ListInputQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "ListInputQuery",
-		Query:  ListInputQuery_Operation,
+		OpName:    "ListInputQuery",
+		Query:     ListInputQuery_Operation,
+		QueryHash: ListInputQuery_OperationHash,
 		Variables: &__ListInputQueryInput{
 			Names: names,
 		},
@@ -123,13 +127,17 @@ query QueryWithSlices {
 }
 `
 
+// The SHA-256 hash of QueryWithSlices_Operation, used for automatic persisted queries.
+const QueryWithSlices_OperationHash = "5d4142d36f8dc723dec90c83e7140d26bbc4b8c6bdb4ab24bad9282b1ce71de6"
+
 func QueryWithSlices(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *QueryWithSlicesResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "QueryWithSlices",
-		Query:  QueryWithSlices_Operation,
+		OpName:    "QueryWithSlices",
+		Query:     QueryWithSlices_Operation,
+		QueryHash: QueryWithSlices_OperationHash,
 	}
 
 	data_ = &QueryWithSlicesResponse{}
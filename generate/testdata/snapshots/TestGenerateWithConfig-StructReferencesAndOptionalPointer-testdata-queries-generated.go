@@ -243,14 +243,18 @@ query InputObjectQuery ($query: UserQueryInput) {
 }
 `
 
+// The SHA-256 hash of InputObjectQuery_Operation, used for automatic persisted queries.
+const InputObjectQuery_OperationHash = "e014b248f8fbb4c1c1af234caddac3ccac15e406513056c9f2461089c87d9716"
+
 func InputObjectQuery(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	query *UserQueryInput,
 ) (data_ *InputObjectQueryResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "InputObjectQuery",
-		Query:  InputObjectQuery_Operation,
+		OpName:    "InputObjectQuery",
+		Query:     InputObjectQuery_Operation,
+		QueryHash: InputObjectQuery_OperationHash,
 		Variables: &__InputObjectQueryInput{
 			Query: query,
 		},
@@ -280,13 +284,17 @@ query QueryWithStructs {
 }
 `
 
+// The SHA-256 hash of QueryWithStructs_Operation, used for automatic persisted queries.
+const QueryWithStructs_OperationHash = "a9f9b2a3048c540188752153837017ebd5f9cfd71ceafa610990bc5d78a5ddc2"
+
 func QueryWithStructs(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *QueryWithStructsResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "QueryWithStructs",
-		Query:  QueryWithStructs_Operation,
+		OpName:    "QueryWithStructs",
+		Query:     QueryWithStructs_Operation,
+		QueryHash: QueryWithStructs_OperationHash,
 	}
 
 	data_ = &QueryWithStructsResponse{}
@@ -63,6 +63,9 @@ query UseStructReference ($input: UseStructReferencesInput!) {
 }
 `
 
+// The SHA-256 hash of UseStructReference_Operation, used for automatic persisted queries.
+const UseStructReference_OperationHash = "076215daf73c6ccdfdc186ad6637dd56485674ecf64458b5717f3e4f8df5b524"
+
 // https://github.com/Khan/genqlient/issues/342
 func UseStructReference(
 	ctx_ context.Context,
@@ -70,8 +73,9 @@ func UseStructReference(
 	input *UseStructReferencesInput,
 ) (data_ *UseStructReferenceResponse, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "UseStructReference",
-		Query:  UseStructReference_Operation,
+		OpName:    "UseStructReference",
+		Query:     UseStructReference_Operation,
+		QueryHash: UseStructReference_OperationHash,
 		Variables: &__UseStructReferenceInput{
 			Input: input,
 		},
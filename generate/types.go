@@ -164,10 +164,13 @@ func (typ *goEnumType) GraphQLTypeName() string        { return typ.GraphQLName
 // goStructType represents a Go struct type used to represent a GraphQL object
 // or input-object type.
 type goStructType struct {
-	GoName    string
-	Fields    []*goStructField
-	IsInput   bool
-	Selection ast.SelectionSet
+	GoName  string
+	Fields  []*goStructField
+	IsInput bool
+	// If set, write a <Field>Failed method for each field; see
+	// Config.FieldErrorMethods.  (Only set for operation response types.)
+	FieldErrorMethods bool
+	Selection         ast.SelectionSet
 	descriptionInfo
 	Generator *generator // for the convenience of the template
 }
@@ -429,6 +432,27 @@ func (typ *goStructType) WriteDefinition(w io.Writer, g *generator) error {
 			typ.GoName, field.GoName, field.GoType.Reference(), field.Selector)
 	}
 
+	// For response types, if requested, write methods which check whether
+	// each field has errors.  (Only at the top level: deeper types don't
+	// know their path, and may be shared between several.)
+	if typ.FieldErrorMethods {
+		errorsAt, err := g.ref("github.com/Khan/genqlient/graphql.ErrorsAt")
+		if err != nil {
+			return err
+		}
+		for _, field := range flattened {
+			description := fmt.Sprintf(
+				"%sFailed returns true if %s.%s was nulled, in whole or in part, "+
+					"by an error in err, which should be the error returned "+
+					"with the response, or if v is nil, since then there's no "+
+					"data at all.",
+				field.GoName, typ.GoName, field.GoName)
+			writeDescription(w, description)
+			fmt.Fprintf(w, "func (v *%s) %sFailed(err error) bool { return v == nil || len(%s(err, %q)) > 0 }\n",
+				typ.GoName, field.GoName, errorsAt, field.JSONName)
+		}
+	}
+
 	// Now, if needed, write the marshaler/unmarshaler.  We need one if we have
 	// any interface-typed fields, or any embedded fields.
 	//
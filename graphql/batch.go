@@ -0,0 +1,204 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithBatching configures the client to combine concurrent requests into
+// batches, which are sent to the server as a single POST whose body is a JSON
+// array of requests.  The server must support this form of batching (e.g.
+// Apollo Server with allowBatchedHttpRequests) and respond with an array of
+// responses in the same order.
+//
+// A request is held for up to window after the first request of its batch,
+// or until maxSize requests are waiting, whichever comes first (a maxSize of
+// 0 means no limit; a window of 0 disables batching).  A batch of just one
+// request is sent as an ordinary, unbatched request.  Each caller receives
+// its own operation's response and errors; only errors affecting the whole
+// batch, such as network errors or non-200 statuses, are returned to every
+// caller in the batch.
+//
+// The batch is sent with a context which is canceled only once the contexts
+// of all of its requests are done; a caller whose context is done returns
+// immediately without waiting for the batch.
+//
+// Batching only applies to clients from [NewClient]; it has no effect on
+// [NewClientUsingGet].
+func WithBatching(window time.Duration, maxSize int) ClientOption {
+	return func(c *client) {
+		c.batchWindow = window
+		c.batchMaxSize = maxSize
+	}
+}
+
+// batcher collects a client's requests into batches.
+type batcher struct {
+	client  *client
+	timer   *time.Timer
+	pending []*batchedRequest
+	window  time.Duration
+	maxSize int
+	mu      sync.Mutex
+}
+
+type batchedRequest struct {
+	ctx    context.Context
+	result chan batchResult // buffered, so the sender never blocks
+	// The request, already marshaled, so that a request which can't be
+	// fails on its own, rather than failing its whole batch.
+	body json.RawMessage
+}
+
+type batchResult struct {
+	err      error
+	data     json.RawMessage
+	metadata ResponseMetadata
+}
+
+func (b *batcher) makeRequest(ctx context.Context, req *Request, resp *Response) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	body, err := json.Marshal(b.client.postPayload(req))
+	if err != nil {
+		return err
+	}
+	r := &batchedRequest{ctx: ctx, body: body, result: make(chan batchResult, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, r)
+	var batch []*batchedRequest
+	if b.maxSize > 0 && len(b.pending) >= b.maxSize {
+		batch = b.take()
+	} else if len(b.pending) == 1 {
+		b.timer = time.AfterFunc(b.window, b.flush)
+	}
+	b.mu.Unlock()
+
+	if batch != nil {
+		go b.send(batch)
+	}
+
+	select {
+	case result := <-r.result:
+		if md := responseMetadata(ctx); md != nil {
+			*md = result.metadata
+		}
+		if m := requestMetrics(ctx); m != nil {
+			m.recordBatchedRequest(body, result.data)
+		}
+		if result.err != nil {
+			decodeHTTPError(result.err, resp)
+			return result.err
+		}
+		err = json.Unmarshal(result.data, resp)
+		if err != nil {
+			return err
+		}
+		if len(resp.Errors) > 0 {
+			return resp.Errors
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take removes and returns the pending requests.  b.mu must be held.
+func (b *batcher) take() []*batchedRequest {
+	batch := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch
+}
+
+// flush sends the pending requests, if any.
+func (b *batcher) flush() {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+	if len(batch) > 0 {
+		b.send(batch)
+	}
+}
+
+// send sends the given batch, and delivers each request its result.
+func (b *batcher) send(batch []*batchedRequest) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for _, r := range batch {
+			select {
+			case <-r.ctx.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+
+	// Each request gets its own copy of the metadata, which it fills in
+	// itself, in case its caller is no longer waiting.
+	var md ResponseMetadata
+	results, err := b.roundTrip(WithResponseMetadata(ctx, &md), batch)
+	for i, r := range batch {
+		if err != nil {
+			r.result <- batchResult{err: err, metadata: md}
+		} else {
+			r.result <- batchResult{data: results[i], metadata: md}
+		}
+	}
+}
+
+// roundTrip makes the HTTP request for the given batch, and returns the raw
+// response to each of its requests.
+func (b *batcher) roundTrip(ctx context.Context, batch []*batchedRequest) ([]json.RawMessage, error) {
+	var body []byte
+	if len(batch) == 1 {
+		body = batch[0].body
+	} else {
+		body = append(body, '[')
+		for i, r := range batch {
+			if i > 0 {
+				body = append(body, ',')
+			}
+			body = append(body, r.body...)
+		}
+		body = append(body, ']')
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, b.client.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := b.client.do(ctx, httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var results []json.RawMessage
+	if len(batch) == 1 {
+		results = make([]json.RawMessage, 1)
+		err = json.NewDecoder(httpResp.Body).Decode(&results[0])
+	} else {
+		err = json.NewDecoder(httpResp.Body).Decode(&results)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(batch) {
+		return nil, fmt.Errorf(
+			"batch of %d requests returned %d responses", len(batch), len(results))
+	}
+	return results, nil
+}
@@ -0,0 +1,702 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// CachePolicy says how a client returned by [WithCache] uses its cache.
+type CachePolicy int
+
+const (
+	// CacheFirst returns the response from the cache if it has all the data
+	// the query needs, and otherwise makes the request, and caches the
+	// response.
+	CacheFirst CachePolicy = iota
+	// NetworkOnly always makes the request, and caches the response.
+	NetworkOnly
+	// CacheAndNetwork returns the response from the cache if it has all the
+	// data the query needs, but also makes the request in the background to
+	// update the cache, so that later queries get fresh data.  If the cache
+	// doesn't have the data, it makes the request just like CacheFirst.
+	CacheAndNetwork
+)
+
+type cachePolicyKey struct{}
+
+// WithCachePolicy returns a context which, when passed to a genqlient
+// function (or [Client.MakeRequest]) using a client returned by
+// [WithCache], overrides the client's cache policy for that request.
+func WithCachePolicy(ctx context.Context, policy CachePolicy) context.Context {
+	return context.WithValue(ctx, cachePolicyKey{}, policy)
+}
+
+// Cache is a normalized cache of GraphQL responses, for use with
+// [WithCache].
+//
+// Rather than caching each response as a whole, Cache splits it into
+// entities: objects with a __typename and an id, keyed by the two, such
+// that different queries (and mutations) which return the same entity
+// share, and update, its fields.  Other objects are cached as part of the
+// entity (or query) which contains them.  For the cache to key entities
+// reliably, the query must select __typename and id on each object which
+// has them; set add_cache_keys in genqlient.yaml to have genqlient do so.
+//
+// A Cache is safe for concurrent use, and may be shared by several clients
+// (for the same server).
+type Cache struct {
+	// The cached entities, keyed by entityKey (or rootQueryKey, for the
+	// fields of the query type).  Each entity is a map from fieldKey to a
+	// cached value, which is either:
+	//   - json.RawMessage, for scalars, or for null,
+	//   - entityRef, for entities,
+	//   - map[string]interface{}, for other objects, in the same format, or
+	//   - []interface{}, for lists of the above.
+	entities map[string]map[string]interface{}
+	// We have no schema, so we learn from the responses we cache whether a
+	// fragment applies to a given object.  objectTypes is the set of
+	// typenames we've seen, all of which are object types, so a fragment on
+	// one applies only to objects of that type.  For other type-conditions
+	// (interfaces and unions), fragmentMatches records, for each typename,
+	// whether the fragments on each type-condition applied.
+	objectTypes     map[string]bool
+	fragmentMatches map[string]map[string]bool
+	// Parsed queries, keyed by their text.
+	documents map[string]*ast.QueryDocument
+	mu        sync.Mutex
+}
+
+// entityRef is the cached value of a field whose value is an entity, and is
+// the entity's key.
+type entityRef string
+
+// The key under which we cache the fields of the query type.
+const rootQueryKey = "ROOT_QUERY"
+
+// NewCache returns a new, empty, [Cache].
+func NewCache() *Cache {
+	return &Cache{
+		entities:        map[string]map[string]interface{}{},
+		objectTypes:     map[string]bool{},
+		fragmentMatches: map[string]map[string]bool{},
+		documents:       map[string]*ast.QueryDocument{},
+	}
+}
+
+// Evict removes the entity with the given typename and ID from the cache,
+// for example because it was deleted.  Queries which need it will then be
+// cache misses.
+func (c *Cache) Evict(typename, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entities, entityKey(typename, id))
+}
+
+// Reset removes everything from the cache.
+func (c *Cache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entities = map[string]map[string]interface{}{}
+	c.objectTypes = map[string]bool{}
+	c.fragmentMatches = map[string]map[string]bool{}
+}
+
+func entityKey(typename, id string) string {
+	// GraphQL names can't contain ":", so this is unambiguous.
+	return typename + ":" + id
+}
+
+// WithCache wraps client to cache responses in the given normalized cache,
+// and use the cached data to answer queries, according to the given policy
+// (which may be overridden per-request with [WithCachePolicy]).  Responses
+// with errors are not cached.  Mutations always make the request, but
+// update the cache with any entities they return.
+//
+// Requests which use @defer or @stream bypass the cache.  Since the cache
+// must see the raw response, WithCache should wrap the other clients and
+// middleware, rather than the reverse; it's typically used as:
+//
+//	cache := graphql.NewCache()
+//	client := graphql.WithCache(
+//		graphql.NewClient(endpoint, httpClient), cache, graphql.CacheFirst)
+func WithCache(client Client, cache *Cache, policy CachePolicy) Client {
+	return &cacheClient{client: client, cache: cache, policy: policy}
+}
+
+type cacheClient struct {
+	client Client
+	cache  *Cache
+	policy CachePolicy
+}
+
+func (c *cacheClient) MakeRequest(ctx context.Context, req *Request, resp *Response) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	op, err := c.cache.prepare(req)
+	if err != nil || op == nil || isIncremental(req) {
+		// We can't cache this request, so we just pass it through.  (The
+		// server will report any problem with the query.)
+		return c.client.MakeRequest(ctx, req, resp)
+	}
+
+	policy := c.policy
+	if ctxPolicy, ok := ctx.Value(cachePolicyKey{}).(CachePolicy); ok {
+		policy = ctxPolicy
+	}
+	if op.operation.Operation == ast.Query && policy != NetworkOnly {
+		data, ok := c.cache.read(op)
+		if ok {
+			if policy == CacheAndNetwork {
+				go func() {
+					_ = c.fetch(detachedContext{ctx}, op, &Response{})
+				}()
+			}
+			return json.Unmarshal(data, resp.Data)
+		}
+	}
+	return c.fetch(ctx, op, resp)
+}
+
+// fetch makes the request, caches the response (if possible), and decodes
+// it into resp.
+func (c *cacheClient) fetch(ctx context.Context, op *cacheOperation, resp *Response) error {
+	var data json.RawMessage
+	rawResp := &Response{Data: &data}
+	err := c.client.MakeRequest(ctx, op.req, rawResp)
+	resp.Errors = rawResp.Errors
+	resp.Extensions = rawResp.Extensions
+	if len(data) > 0 && resp.Data != nil {
+		decodeErr := json.Unmarshal(data, resp.Data)
+		if err == nil {
+			err = decodeErr
+		}
+	}
+	if err == nil {
+		c.cache.write(op, data)
+	}
+	return err
+}
+
+// detachedContext is a context with the values of its parent, but which is
+// never done, so we can use it after the parent is.  (It omits any response
+// metadata, which the parent's user may no longer expect to be written.)
+type detachedContext struct{ parent context.Context }
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	if key == (responseMetadataKey{}) {
+		return nil
+	}
+	return c.parent.Value(key)
+}
+
+// cacheOperation is a request along with the information the cache needs
+// about it.
+type cacheOperation struct {
+	req       *Request
+	doc       *ast.QueryDocument
+	operation *ast.OperationDefinition
+	variables map[string]interface{}
+}
+
+// prepare parses the request's query and variables, or returns nil if it's
+// not one the cache can handle.
+func (c *Cache) prepare(req *Request) (*cacheOperation, error) {
+	if req.Query == "" {
+		return nil, nil
+	}
+	c.mu.Lock()
+	doc, ok := c.documents[req.Query]
+	c.mu.Unlock()
+	if !ok {
+		var err error
+		doc, err = parser.ParseQuery(&ast.Source{Input: req.Query})
+		if err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		c.documents[req.Query] = doc
+		c.mu.Unlock()
+	}
+
+	var operation *ast.OperationDefinition
+	if req.OpName != "" {
+		operation = doc.Operations.ForName(req.OpName)
+	} else if len(doc.Operations) == 1 {
+		operation = doc.Operations[0]
+	}
+	if operation == nil || operation.Operation == ast.Subscription {
+		return nil, nil
+	}
+
+	variables := map[string]interface{}{}
+	if req.Variables != nil {
+		b, err := json.Marshal(req.Variables)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		err = dec.Decode(&variables)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, def := range operation.VariableDefinitions {
+		if _, ok := variables[def.Variable]; !ok && def.DefaultValue != nil {
+			value, err := def.DefaultValue.Value(nil)
+			if err != nil {
+				return nil, err
+			}
+			variables[def.Variable] = value
+		}
+	}
+
+	return &cacheOperation{req: req, doc: doc, operation: operation, variables: variables}, nil
+}
+
+// fieldKey returns the key under which we cache the given field, which
+// includes its arguments (but not its alias).
+func (op *cacheOperation) fieldKey(field *ast.Field) (string, error) {
+	if len(field.Arguments) == 0 {
+		return field.Name, nil
+	}
+	args := make(map[string]interface{}, len(field.Arguments))
+	for _, arg := range field.Arguments {
+		value, err := arg.Value.Value(op.variables)
+		if err != nil {
+			return "", err
+		}
+		args[arg.Name] = value
+	}
+	// json.Marshal sorts the keys, so equal arguments have equal keys.
+	b, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	return field.Name + "(" + string(b) + ")", nil
+}
+
+// included returns false if the given directives say to skip the selection
+// (via @skip or @include).
+func (op *cacheOperation) included(directives ast.DirectiveList) bool {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			continue
+		}
+		arg := directive.Arguments.ForName("if")
+		if arg == nil {
+			continue
+		}
+		value, _ := arg.Value.Value(op.variables)
+		if value == (directive.Name == "skip") {
+			return false
+		}
+	}
+	return true
+}
+
+// fragment returns the type-condition and selection-set of the given
+// fragment selection, or ok=false if it's not a fragment.
+func (op *cacheOperation) fragment(selection ast.Selection) (typeCondition string, selectionSet ast.SelectionSet, ok bool) {
+	switch selection := selection.(type) {
+	case *ast.InlineFragment:
+		if !op.included(selection.Directives) {
+			return "", nil, false
+		}
+		return selection.TypeCondition, selection.SelectionSet, true
+	case *ast.FragmentSpread:
+		def := op.doc.Fragments.ForName(selection.Name)
+		if def == nil || !op.included(selection.Directives) {
+			return "", nil, false
+		}
+		return def.TypeCondition, def.SelectionSet, true
+	}
+	return "", nil, false
+}
+
+var errCacheMiss = errors.New("cache miss")
+
+// write caches the given response data for the given operation.
+//
+// We write to copies of the cached entities, and replace the originals only
+// if the whole write succeeds, so that a response we can't cache doesn't
+// leave the cache half-updated.  (What we learn about the types, in
+// objectTypes and fragmentMatches, is true either way, so we keep it.)
+func (c *Cache) write(op *cacheOperation, data json.RawMessage) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil || fields == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	staged := map[string]map[string]interface{}{}
+	var root map[string]interface{}
+	if op.operation.Operation == ast.Query {
+		root = c.stage(staged, rootQueryKey)
+	} else {
+		// For mutations, we still write to a root object, so we cache any
+		// entities they return, but we then discard it.
+		root = map[string]interface{}{}
+	}
+	if c.writeSelectionSet(op, staged, root, op.operation.SelectionSet, fields, "") != nil {
+		return
+	}
+	for key, entity := range staged {
+		c.entities[key] = entity
+	}
+}
+
+// stage returns the copy, in staged, of the cached entity with the given
+// key, making it (or a new entity) if needed.  c.mu must be held.
+func (c *Cache) stage(staged map[string]map[string]interface{}, key string) map[string]interface{} {
+	entity, ok := staged[key]
+	if !ok {
+		entity = copyCachedObject(c.entities[key])
+		staged[key] = entity
+	}
+	return entity
+}
+
+// copyCachedObject returns a deep copy of the given cached object, which
+// may be nil, in which case it returns a new object.  (Only objects and
+// lists need copying; we never modify the other cached values.)
+func copyCachedObject(object map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(object))
+	for k, v := range object {
+		copied[k] = copyCachedValue(v)
+	}
+	return copied
+}
+
+func copyCachedValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return copyCachedObject(value)
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, elem := range value {
+			copied[i] = copyCachedValue(elem)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// writeSelectionSet caches the given fields, the JSON response to the given
+// selection set, in the cached object cached (of the given typename, if
+// known), writing entities to staged (see write).  c.mu must be held.
+func (c *Cache) writeSelectionSet(
+	op *cacheOperation,
+	staged map[string]map[string]interface{},
+	cached map[string]interface{},
+	selectionSet ast.SelectionSet,
+	fields map[string]json.RawMessage,
+	typename string,
+) error {
+	for _, selection := range selectionSet {
+		if field, ok := selection.(*ast.Field); ok {
+			if !op.included(field.Directives) {
+				continue
+			}
+			raw, ok := fields[field.Alias]
+			if !ok {
+				continue
+			}
+			key, err := op.fieldKey(field)
+			if err != nil {
+				return err
+			}
+			if len(field.SelectionSet) == 0 {
+				cached[key] = raw
+				continue
+			}
+			value, err := c.writeValue(op, staged, cached[key], field.SelectionSet, raw)
+			if err != nil {
+				return err
+			}
+			cached[key] = value
+			continue
+		}
+
+		typeCondition, fragmentSelectionSet, ok := op.fragment(selection)
+		if !ok {
+			continue
+		}
+		switch matches, known := c.fragmentApplies(op, selectionSet, typeCondition, fragmentSelectionSet, typename); {
+		case known && !matches:
+			continue
+		case !known:
+			// The fragment applies if and only if the response has its
+			// fields; we remember which, for reading.
+			matches = false
+			for _, alias := range distinctAliases(op, selectionSet, fragmentSelectionSet) {
+				if _, ok := fields[alias]; ok {
+					matches = true
+					break
+				}
+			}
+			if c.fragmentMatches[typename] == nil {
+				c.fragmentMatches[typename] = map[string]bool{}
+			}
+			c.fragmentMatches[typename][typeCondition] = matches
+			if !matches {
+				continue
+			}
+		}
+		err := c.writeSelectionSet(op, staged, cached, fragmentSelectionSet, fields, typename)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fragmentApplies returns whether the given fragment, in the given
+// selection set, applies to an object of the given typename (if known), or
+// known=false if we don't know.  c.mu must be held.
+func (c *Cache) fragmentApplies(
+	op *cacheOperation,
+	selectionSet ast.SelectionSet,
+	typeCondition string,
+	fragmentSelectionSet ast.SelectionSet,
+	typename string,
+) (matches, known bool) {
+	switch {
+	case typename == "":
+		// We only omit __typename for objects of concrete type (or the
+		// root), and for those, GraphQL validation ensures that every
+		// fragment applies.
+		return true, true
+	case typeCondition == "" || typeCondition == typename:
+		return true, true
+	case c.objectTypes[typeCondition]:
+		return false, true
+	case len(distinctAliases(op, selectionSet, fragmentSelectionSet)) == 0:
+		// We can't tell, but it also doesn't matter: its fields are
+		// selected anyway.
+		return true, true
+	}
+	matches, known = c.fragmentMatches[typename][typeCondition]
+	return matches, known
+}
+
+// distinctAliases returns the response keys of the fields in the given
+// fragment (including in its own fragments) which are not also selected
+// directly in the enclosing selection set.  The response has those fields
+// if and only if the fragment applies.
+func distinctAliases(op *cacheOperation, selectionSet, fragmentSelectionSet ast.SelectionSet) []string {
+	var aliases []string
+	for _, selection := range fragmentSelectionSet {
+		if field, ok := selection.(*ast.Field); ok {
+			if !selectsAlias(selectionSet, field.Alias) {
+				aliases = append(aliases, field.Alias)
+			}
+		} else if _, nested, ok := op.fragment(selection); ok {
+			aliases = append(aliases, distinctAliases(op, selectionSet, nested)...)
+		}
+	}
+	return aliases
+}
+
+// selectsAlias returns true if the given selection set directly selects a
+// field with the given alias (i.e. response key).
+func selectsAlias(selectionSet ast.SelectionSet, alias string) bool {
+	for _, selection := range selectionSet {
+		if field, ok := selection.(*ast.Field); ok && field.Alias == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// writeValue caches raw, the JSON value of a field with the given selection
+// set, and returns the value to cache for the field itself.  existing is
+// the value previously cached for the field, if any, and entities are
+// written to staged (see write).  c.mu must be held.
+func (c *Cache) writeValue(
+	op *cacheOperation,
+	staged map[string]map[string]interface{},
+	existing interface{},
+	selectionSet ast.SelectionSet,
+	raw json.RawMessage,
+) (interface{}, error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0 || raw[0] == 'n':
+		return json.RawMessage("null"), nil
+	case raw[0] == '[':
+		var elems []json.RawMessage
+		err := json.Unmarshal(raw, &elems)
+		if err != nil {
+			return nil, err
+		}
+		existingElems, _ := existing.([]interface{})
+		values := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			var existingElem interface{}
+			if i < len(existingElems) {
+				existingElem = existingElems[i]
+			}
+			values[i], err = c.writeValue(op, staged, existingElem, selectionSet, elem)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(raw, &fields)
+	if err != nil {
+		return nil, err
+	}
+	var typename, id string
+	_ = json.Unmarshal(fields["__typename"], &typename)
+	if rawID := bytes.TrimSpace(fields["id"]); len(rawID) > 0 && rawID[0] != 'n' {
+		if json.Unmarshal(rawID, &id) != nil {
+			id = string(rawID) // a numeric ID
+		}
+	}
+
+	if typename != "" {
+		c.objectTypes[typename] = true
+	}
+	if typename != "" && id != "" {
+		key := entityKey(typename, id)
+		err = c.writeSelectionSet(op, staged, c.stage(staged, key), selectionSet, fields, typename)
+		return entityRef(key), err
+	}
+
+	object, ok := existing.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	err = c.writeSelectionSet(op, staged, object, selectionSet, fields, typename)
+	return object, err
+}
+
+// read returns the response data for the given query from the cache, or
+// ok=false if the cache doesn't have all of it.
+func (c *Cache) read(op *cacheOperation) (data json.RawMessage, ok bool) {
+	c.mu.Lock()
+	root := c.entities[rootQueryKey]
+	if root == nil {
+		c.mu.Unlock()
+		return nil, false
+	}
+	out := map[string]interface{}{}
+	err := c.readSelectionSet(op, root, op.operation.SelectionSet, "", out)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, false
+	}
+	data, err = json.Marshal(out)
+	return data, err == nil
+}
+
+// readSelectionSet reads the response to the given selection set from the
+// cached object cached (of the given typename, if known) into out, or
+// returns errCacheMiss if the cache doesn't have it all.  c.mu must be held.
+func (c *Cache) readSelectionSet(
+	op *cacheOperation,
+	cached map[string]interface{},
+	selectionSet ast.SelectionSet,
+	typename string,
+	out map[string]interface{},
+) error {
+	for _, selection := range selectionSet {
+		if field, ok := selection.(*ast.Field); ok {
+			if !op.included(field.Directives) {
+				continue
+			}
+			key, err := op.fieldKey(field)
+			if err != nil {
+				return err
+			}
+			value, ok := cached[key]
+			if !ok {
+				if field.Name == "__typename" && typename != "" {
+					out[field.Alias] = typename
+					continue
+				}
+				return errCacheMiss
+			}
+			if len(field.SelectionSet) == 0 {
+				out[field.Alias] = value
+				continue
+			}
+			out[field.Alias], err = c.readValue(op, value, field.SelectionSet)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		typeCondition, fragmentSelectionSet, ok := op.fragment(selection)
+		if !ok {
+			continue
+		}
+		matches, known := c.fragmentApplies(op, selectionSet, typeCondition, fragmentSelectionSet, typename)
+		if !known {
+			return errCacheMiss
+		} else if !matches {
+			continue
+		}
+		err := c.readSelectionSet(op, cached, fragmentSelectionSet, typename, out)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readValue returns the response to a field with the given selection set,
+// whose cached value is value.  c.mu must be held.
+func (c *Cache) readValue(op *cacheOperation, value interface{}, selectionSet ast.SelectionSet) (interface{}, error) {
+	switch value := value.(type) {
+	case json.RawMessage:
+		return value, nil // null
+	case []interface{}:
+		elems := make([]interface{}, len(value))
+		for i, elem := range value {
+			var err error
+			elems[i], err = c.readValue(op, elem, selectionSet)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	case entityRef:
+		entity := c.entities[string(value)]
+		if entity == nil {
+			return nil, errCacheMiss
+		}
+		typename, _, _ := strings.Cut(string(value), ":")
+		out := map[string]interface{}{}
+		err := c.readSelectionSet(op, entity, selectionSet, typename, out)
+		return out, err
+	case map[string]interface{}:
+		var typename string
+		if raw, ok := value["__typename"].(json.RawMessage); ok {
+			_ = json.Unmarshal(raw, &typename)
+		}
+		out := map[string]interface{}{}
+		err := c.readSelectionSet(op, value, selectionSet, typename, out)
+		return out, err
+	}
+	return nil, errCacheMiss
+}
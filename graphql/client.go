@@ -3,15 +3,22 @@ package graphql
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
 )
 
 // Client is the interface that the generated code calls into to actually make
@@ -45,7 +52,9 @@ type WebSocketClient interface {
 	// of the client's GraphQL API.
 	//
 	// errChan is a channel on which are sent the errors of webSocket
-	// communication. It will be closed when calling the `Close()` method.
+	// communication, including those from a subscription's
+	// forwardDataFunc (after which the subscription continues). It will be
+	// closed when calling the `Close()` method.
 	//
 	// err is any error that occurs when setting up the webSocket connection.
 	Start(ctx context.Context) (errChan chan error, err error)
@@ -82,8 +91,58 @@ type ForwardDataFunction func(interfaceChan interface{}, jsonRawMsg json.RawMess
 
 type client struct {
 	httpClient Doer
-	endpoint   string
-	method     string
+	// If set, requests are sent in batches.  See [WithBatching].
+	batcher *batcher
+	// If set, GET responses are cached here.  See [WithHTTPCache].
+	httpCacheStore HTTPCacheStore
+	// If set, metrics are recorded here.  See [WithMetrics].
+	metrics  MetricsRecorder
+	endpoint string
+	method   string
+	// The arguments to WithBatching, applied in newClient.
+	batchWindow  time.Duration
+	batchMaxSize int
+	// If set, send only the query's hash, and fall back to the full query if
+	// the server doesn't have it.  See [WithAutomaticPersistedQueries].
+	persistedQueries bool
+	// If set, send only the document ID, never the query.  See
+	// [WithTrustedDocuments].
+	trustedDocuments bool
+}
+
+// ClientOption configures optional behavior of the [Client] returned by
+// [NewClient] or [NewClientUsingGet].
+type ClientOption func(*client)
+
+// WithAutomaticPersistedQueries configures the client to use [automatic
+// persisted queries].
+//
+// Instead of the full query, the client first sends only its SHA-256 hash, in
+// the "persistedQuery" request extension.  If the server doesn't recognize the
+// hash (it responds with a PersistedQueryNotFound error), the client retries
+// once with the full query, which the server will then cache.  genqlient's
+// generated code precomputes the hash (see [Request.QueryHash]) so nothing is
+// hashed at runtime.
+//
+// [automatic persisted queries]: https://www.apollographql.com/docs/apollo-server/performance/apq/
+func WithAutomaticPersistedQueries() ClientOption {
+	return func(c *client) { c.persistedQueries = true }
+}
+
+// WithTrustedDocuments configures the client to send only a document ID in
+// place of the query, for servers which accept only [trusted documents]
+// registered ahead of time.
+//
+// The document ID is sent as "documentId", in the JSON body (for POST) or the
+// URL parameters (for GET), and is taken from [Request.DocumentID].  To
+// populate it, set use_document_ids in genqlient.yaml, and register the
+// documents from the export_operations file with your server.  The client
+// will return an error for requests with no DocumentID.  This option takes
+// precedence over [WithAutomaticPersistedQueries].
+//
+// [trusted documents]: https://benjie.dev/graphql/trusted-documents
+func WithTrustedDocuments() ClientOption {
+	return func(c *client) { c.trustedDocuments = true }
 }
 
 // NewClient returns a [Client] which makes requests to the given endpoint,
@@ -100,9 +159,12 @@ type client struct {
 // [http.Transport] to add those headers.  See [example/main.go] for an
 // example.
 //
+// Additional behavior, such as automatic persisted queries, may be enabled
+// by passing one or more [ClientOption] values.
+//
 // [example/main.go]: https://github.com/Khan/genqlient/blob/main/example/main.go#L12-L20
-func NewClient(endpoint string, httpClient Doer) Client {
-	return newClient(endpoint, httpClient, http.MethodPost)
+func NewClient(endpoint string, httpClient Doer, opts ...ClientOption) Client {
+	return newClient(endpoint, httpClient, http.MethodPost, opts)
 }
 
 // NewClientUsingGet returns a [Client] which makes GET requests to the given
@@ -121,18 +183,31 @@ func NewClient(endpoint string, httpClient Doer) Client {
 // [http.Transport] to add those headers.  See [example/main.go] for an
 // example.
 //
+// Like [NewClient], it accepts [ClientOption] values to enable additional
+// behavior.
+//
 // [example/main.go]: https://github.com/Khan/genqlient/blob/main/example/main.go#L12-L20
-func NewClientUsingGet(endpoint string, httpClient Doer) Client {
-	return newClient(endpoint, httpClient, http.MethodGet)
+func NewClientUsingGet(endpoint string, httpClient Doer, opts ...ClientOption) Client {
+	return newClient(endpoint, httpClient, http.MethodGet, opts)
 }
 
 // NewClientUsingWebSocket returns a [WebSocketClient] which makes subscription requests
 // to the given endpoint using webSocket.
 //
-// The client does not support queries nor mutations, and will return an error
-// if passed a request that attempts one.
-func NewClientUsingWebSocket(endpoint string, wsDialer Dialer, headers http.Header) WebSocketClient {
-	return NewClientUsingWebSocketWithConnectionParams(endpoint, wsDialer, headers, nil)
+// Its Subscribe method does not support queries nor mutations, and will
+// return an error if passed a request that attempts one.  But the returned
+// client also implements [Client], whose MakeRequest makes queries and
+// mutations over the same connection, once started:
+//
+//	wsClient := graphql.NewClientUsingWebSocket(endpoint, dialer, nil)
+//	errChan, err := wsClient.Start(ctx)
+//	...
+//	resp, err := getUser(ctx, wsClient.(graphql.Client), userID)
+//
+// Additional behavior, such as reconnecting, may be enabled by passing one or
+// more [WebSocketOption] values.
+func NewClientUsingWebSocket(endpoint string, wsDialer Dialer, headers http.Header, opts ...WebSocketOption) WebSocketClient {
+	return NewClientUsingWebSocketWithConnectionParams(endpoint, wsDialer, headers, nil, opts...)
 }
 
 // NewClientUsingWebSocketWithConnectionParams returns a [WebSocketClient] which makes subscription requests
@@ -141,28 +216,55 @@ func NewClientUsingWebSocket(endpoint string, wsDialer Dialer, headers http.Head
 //
 // connectionParams is a map of connection parameters to be sent to the server
 // during the initial connection handshake.
-func NewClientUsingWebSocketWithConnectionParams(endpoint string, wsDialer Dialer, headers http.Header, connParams map[string]interface{}) WebSocketClient {
+//
+// The client speaks the protocol given by the Sec-WebSocket-Protocol header:
+// graphql-transport-ws (the default) or the legacy graphql-ws.  If the header
+// lists both, the client speaks whichever the server selects, provided the
+// connection returned by wsDialer has a Subprotocol() string method.
+func NewClientUsingWebSocketWithConnectionParams(
+	endpoint string,
+	wsDialer Dialer,
+	headers http.Header,
+	connParams map[string]interface{},
+	opts ...WebSocketOption,
+) WebSocketClient {
 	if headers == nil {
 		headers = http.Header{}
 	}
 	if headers.Get("Sec-WebSocket-Protocol") == "" {
-		headers.Add("Sec-WebSocket-Protocol", "graphql-transport-ws")
+		headers.Add("Sec-WebSocket-Protocol", protocolGraphQLTransportWS)
+	}
+	w := &webSocketClient{
+		Dialer:         wsDialer,
+		Header:         headers,
+		connParams:     connParams,
+		errChan:        make(chan error),
+		endpoint:       endpoint,
+		subscriptions:  subscriptionMap{map_: make(map[string]subscription)},
+		connAckTimeout: websocketConnAckTimeOut,
 	}
-	return &webSocketClient{
-		Dialer:        wsDialer,
-		Header:        headers,
-		connParams:    connParams,
-		errChan:       make(chan error),
-		endpoint:      endpoint,
-		subscriptions: subscriptionMap{map_: make(map[string]subscription)},
+	w.subscriptions.onForwardError = w.handleErr
+	for _, opt := range opts {
+		opt(w)
 	}
+	return w
 }
 
-func newClient(endpoint string, httpClient Doer, method string) Client {
+func newClient(endpoint string, httpClient Doer, method string, opts []ClientOption) Client {
 	if httpClient == nil || httpClient == (*http.Client)(nil) {
 		httpClient = http.DefaultClient
 	}
-	return &client{httpClient, endpoint, method}
+	c := &client{httpClient: httpClient, endpoint: endpoint, method: method}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.batchWindow > 0 && method == http.MethodPost {
+		c.batcher = &batcher{client: c, window: c.batchWindow, maxSize: c.batchMaxSize}
+	}
+	if c.httpCacheStore != nil && method == http.MethodGet {
+		c.httpClient = &httpCache{wrapped: c.httpClient, store: c.httpCacheStore}
+	}
+	return c
 }
 
 // Doer encapsulates the methods from [*http.Client] needed by [Client].
@@ -198,7 +300,7 @@ type WSConn interface {
 type Request struct {
 	// The literal string representing the GraphQL query, e.g.
 	// `query myQuery { myField }`.
-	Query string `json:"query"`
+	Query string `json:"query,omitempty"`
 	// A JSON-marshalable value containing the variables to be sent
 	// along with the query, or nil if there are none.
 	Variables interface{} `json:"variables,omitempty"`
@@ -206,6 +308,74 @@ type Request struct {
 	// require this unless there are multiple queries in the
 	// document, but genqlient sets it unconditionally anyway.
 	OpName string `json:"operationName"`
+	// A JSON-marshalable map of GraphQL protocol extensions to be sent
+	// along with the request, or nil if there are none.  For example,
+	// clients using automatic persisted queries send the query's hash in
+	// the "persistedQuery" extension.
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+	// The hex-encoded SHA-256 hash of Query, used by clients configured
+	// [WithAutomaticPersistedQueries].  genqlient sets it from the
+	// precomputed <Operation>_OperationHash constant; if empty, the client
+	// will compute it as needed.  It is not itself sent to the server.
+	QueryHash string `json:"-"`
+	// The ID of the stored document corresponding to Query, used by clients
+	// configured [WithTrustedDocuments].  genqlient sets it if
+	// use_document_ids is set in genqlient.yaml.  Other clients ignore it.
+	DocumentID string `json:"-"`
+}
+
+// operationTypes caches the result of OperationType, by operation name and
+// query.  Generated code sends a fixed set of queries, so it stays small.
+var operationTypes sync.Map
+
+// OperationType returns the type of the request's operation (query,
+// mutation, or subscription), or "" if it can't tell, for example because
+// the request has no query (as for a persisted query) or it doesn't parse.
+// If the query has several operations, it's the one named OpName.
+func (req *Request) OperationType() ast.Operation {
+	if req.Query == "" {
+		return ""
+	}
+	key := req.OpName + " " + req.Query
+	if opType, ok := operationTypes.Load(key); ok {
+		return opType.(ast.Operation)
+	}
+
+	var opType ast.Operation
+	doc, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if err == nil {
+		operation := doc.Operations.ForName(req.OpName)
+		if operation == nil && len(doc.Operations) == 1 {
+			operation = doc.Operations[0]
+		}
+		if operation != nil {
+			opType = operation.Operation
+		}
+	}
+	operationTypes.Store(key, opType)
+	return opType
+}
+
+// documentIDRequest is the form in which a Request is sent by a client
+// configured [WithTrustedDocuments].
+type documentIDRequest struct {
+	*Request
+	// Shadows Request.DocumentID, which is omitted from the JSON.
+	DocumentID string `json:"documentId"`
+}
+
+// Hash returns the hex-encoded SHA-256 hash of req.Query: req.QueryHash,
+// if the caller computed it already, or else computed now.  It returns "" if
+// the request has neither.
+func (req *Request) Hash() string {
+	if req.QueryHash != "" {
+		return req.QueryHash
+	}
+	if req.Query == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(req.Query))
+	return hex.EncodeToString(sum[:])
 }
 
 // Response that contains data returned by the GraphQL API.
@@ -224,56 +394,313 @@ type Response struct {
 }
 
 func (c *client) MakeRequest(ctx context.Context, req *Request, resp *Response) error {
+	if c.metrics == nil {
+		return c.makeOperation(ctx, req, resp)
+	}
+	m, start := &RequestMetrics{OpName: req.OpName}, time.Now()
+	err := c.makeOperation(withRequestMetrics(ctx, m), req, resp)
+	recordRequest(ctx, c.metrics, m, start, err)
+	return err
+}
+
+// makeOperation implements MakeRequest, apart from metrics.
+func (c *client) makeOperation(ctx context.Context, req *Request, resp *Response) error {
+	// Finding the uploads walks the variables, so we do it just once.
+	uploads := findUploads(req.Variables)
+	err := c.checkOperation(req, uploads)
+	if err != nil {
+		return err
+	}
+	req = withContextExtensions(ctx, req)
+	// This must be checked before we (perhaps) omit the query below.
+	incremental := isIncremental(req)
+	if c.trustedDocuments {
+		if req.DocumentID == "" {
+			return fmt.Errorf("request %s has no DocumentID "+
+				"(set use_document_ids in genqlient.yaml)", req.OpName)
+		}
+		docReq := *req
+		docReq.Query = ""
+		return c.makeRequest(ctx, &docReq, resp, incremental, uploads)
+	}
+	if c.persistedQueries && req.Query != "" && len(uploads) == 0 {
+		return c.makePersistedQueryRequest(ctx, req, resp, incremental)
+	}
+	return c.makeRequest(ctx, req, resp, incremental, uploads)
+}
+
+// checkOperation returns an error if req, which has the given uploads, is an
+// operation of a type this client can't make.
+func (c *client) checkOperation(req *Request, uploads []foundUpload) error {
+	query := strings.TrimSpace(req.Query)
+	if c.method == http.MethodGet && strings.HasPrefix(query, "mutation") {
+		return errors.New("client does not support mutations")
+	}
+	if strings.HasPrefix(query, "subscription") {
+		return errors.New("client does not support subscriptions")
+	}
+	if c.method == http.MethodGet && len(uploads) > 0 {
+		return errUploadsNotSupported
+	}
+	return nil
+}
+
+// makePersistedQueryRequest makes the request using the automatic persisted
+// query protocol: first send just the hash, then if the server doesn't know
+// it, send the full query (with the hash) so the server can cache it.  Such
+// requests never have uploads.
+func (c *client) makePersistedQueryRequest(ctx context.Context, req *Request, resp *Response, incremental bool) error {
+	extensions := make(map[string]interface{}, len(req.Extensions)+1)
+	for k, v := range req.Extensions {
+		extensions[k] = v
+	}
+	extensions["persistedQuery"] = map[string]interface{}{
+		"version":    1,
+		"sha256Hash": req.Hash(),
+	}
+
+	hashReq := *req
+	hashReq.Query = ""
+	hashReq.Extensions = extensions
+	// A null "data" will clear resp.Data, so save it for the retry.
+	data := resp.Data
+	err := c.makeRequest(ctx, &hashReq, resp, incremental, nil)
+	if !isPersistedQueryMiss(resp.Errors) {
+		return err
+	}
+
+	fullReq := *req
+	fullReq.Extensions = extensions
+	resp.Data = data
+	resp.Errors = nil
+	resp.Extensions = nil
+	return c.makeRequest(ctx, &fullReq, resp, incremental, nil)
+}
+
+// isPersistedQueryMiss returns true if the errors indicate that the server
+// couldn't (or wouldn't) look up a persisted query by its hash, in which case
+// the client should send the full query.
+func isPersistedQueryMiss(errs gqlerror.List) bool {
+	for _, err := range errs {
+		switch err.Message {
+		case "PersistedQueryNotFound", "PersistedQueryNotSupported":
+			return true
+		}
+		switch err.Extensions["code"] {
+		case "PERSISTED_QUERY_NOT_FOUND", "PERSISTED_QUERY_NOT_SUPPORTED":
+			return true
+		}
+	}
+	return false
+}
+
+// makeRequest makes a single HTTP request.  If incremental is set, it asks
+// for (and accepts) an incremental response; see isIncremental.  uploads are
+// the uploads in req's variables (see findUploads).
+func (c *client) makeRequest(
+	ctx context.Context,
+	req *Request,
+	resp *Response,
+	incremental bool,
+	uploads []foundUpload,
+) error {
+	header := contextHeader(ctx)
+	if c.batcher != nil && len(uploads) == 0 && !incremental && header == nil {
+		return c.batcher.makeRequest(ctx, req, resp)
+	}
+
 	var httpReq *http.Request
 	var err error
 	if c.method == http.MethodGet {
 		httpReq, err = c.createGetRequest(req)
 	} else {
-		httpReq, err = c.createPostRequest(req)
+		httpReq, err = c.createPostRequest(req, uploads)
 	}
 
 	if err != nil {
 		return err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
+	if incremental {
+		httpReq.Header.Set("Accept", acceptIncremental)
+	}
+	for key, values := range header {
+		httpReq.Header[key] = append([]string(nil), values...)
+	}
+
+	httpResp, err := c.do(ctx, httpReq)
+	if err != nil {
+		decodeHTTPError(err, resp)
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	mediaType, params, _ := mime.ParseMediaType(httpResp.Header.Get("Content-Type"))
+	if mediaType == "multipart/mixed" {
+		return readIncrementalResponse(ctx, httpResp.Body, params["boundary"], resp)
+	}
+
+	err = json.NewDecoder(httpResp.Body).Decode(resp)
+	if err != nil {
+		return err
+	}
+	if handler := incrementalHandler(ctx); handler != nil && incremental {
+		handler(resp)
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
+// do sends the given request, and returns the response if it's a GraphQL
+// response with a successful status (see isSuccess), or an error (including
+// for other statuses) otherwise.  The caller must close the response body.
+func (c *client) do(ctx context.Context, httpReq *http.Request) (*http.Response, error) {
+	if httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if httpReq.Header.Get("Accept") == "" {
+		httpReq.Header.Set("Accept", acceptGraphQLResponse)
+	}
 
 	if ctx != nil {
 		httpReq = httpReq.WithContext(ctx)
 	}
 
+	md := responseMetadata(ctx)
+	if md != nil {
+		*md = ResponseMetadata{StartTime: time.Now()}
+	}
 	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if md != nil {
+		md.Duration = time.Since(md.StartTime)
+		md.Header = httpResp.Header
+		md.StatusCode = httpResp.StatusCode
+	}
+	if m := requestMetrics(ctx); m != nil {
+		m.recordHTTPRequest(httpReq, httpResp)
 	}
-	defer httpResp.Body.Close()
 
-	if httpResp.StatusCode != http.StatusOK {
+	if !isSuccess(httpResp) {
+		defer httpResp.Body.Close()
 		var respBody []byte
 		respBody, err = io.ReadAll(httpResp.Body)
 		if err != nil {
 			respBody = []byte(fmt.Sprintf("<unreadable: %v>", err))
 		}
-		return fmt.Errorf("returned error %v: %s", httpResp.Status, respBody)
+		return nil, &HTTPError{
+			StatusCode: httpResp.StatusCode,
+			Header:     httpResp.Header,
+			Body:       respBody,
+		}
 	}
+	return httpResp, nil
+}
 
-	err = json.NewDecoder(httpResp.Body).Decode(resp)
-	if err != nil {
-		return err
+const (
+	// The media type of GraphQL responses, per the GraphQL-over-HTTP spec:
+	// https://graphql.github.io/graphql-over-http/draft/#sec-Media-Types
+	mediaTypeGraphQLResponse = "application/graphql-response+json"
+	// The Accept header we send, which prefers the above, but also accepts
+	// plain JSON from servers which predate it.
+	acceptGraphQLResponse = mediaTypeGraphQLResponse + ", application/json"
+)
+
+// isGraphQLResponse returns true if the header declares the body to be of
+// the GraphQL response media type.  Such a body is a GraphQL response
+// whatever the status code.
+func isGraphQLResponse(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == mediaTypeGraphQLResponse
+}
+
+// isSuccess returns true if the status of httpResp means the request
+// succeeded.  With the GraphQL response media type, that's any 2xx status;
+// with any other (typically application/json) it's only 200, per the
+// GraphQL-over-HTTP spec.
+func isSuccess(httpResp *http.Response) bool {
+	if isGraphQLResponse(httpResp.Header) {
+		return httpResp.StatusCode >= 200 && httpResp.StatusCode < 300
 	}
-	if len(resp.Errors) > 0 {
-		return resp.Errors
+	return httpResp.StatusCode == http.StatusOK
+}
+
+// HTTPError is returned by client.MakeRequest when the server responds
+// with an unsuccessful status: for most servers, anything other than 200.
+//
+// Some servers, notably those following the GraphQL-over-HTTP spec, respond
+// to invalid requests with a 4xx status and a GraphQL response explaining the
+// problem.  In that case, MakeRequest also decodes the response into the
+// Response, so its errors (and, if the server used the
+// application/graphql-response+json media type, any partial data) may be
+// inspected as usual.  The errors may also be obtained via errors.As, as a
+// gqlerror.List.
+type HTTPError struct {
+	Header     http.Header
+	Body       []byte
+	errors     gqlerror.List
+	StatusCode int
+}
+
+func (err *HTTPError) Error() string {
+	return fmt.Sprintf("returned error %v %v: %s",
+		err.StatusCode, http.StatusText(err.StatusCode), err.Body)
+}
+
+// Unwrap returns the GraphQL errors in the body, if any.
+func (err *HTTPError) Unwrap() error {
+	if len(err.errors) == 0 {
+		return nil
 	}
-	return nil
+	return err.errors
 }
 
-func (c *client) createPostRequest(req *Request) (*http.Request, error) {
-	if req.Query != "" {
-		if strings.HasPrefix(strings.TrimSpace(req.Query), "subscription") {
-			return nil, errors.New("client does not support subscriptions")
+// decodeHTTPError decodes the body of err into resp, if err is an
+// *HTTPError whose body is a GraphQL response.
+//
+// If the server said the body is a GraphQL response, we decode all of it;
+// otherwise, since it might be from a proxy or the like, we decode only the
+// errors, and only if there are some.
+func decodeHTTPError(err error, resp *Response) {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return
+	}
+	if isGraphQLResponse(httpErr.Header) {
+		if json.Unmarshal(httpErr.Body, resp) == nil {
+			httpErr.errors = resp.Errors
 		}
+		return
+	}
+	var body struct {
+		Extensions map[string]interface{} `json:"extensions"`
+		Errors     gqlerror.List          `json:"errors"`
+	}
+	if json.Unmarshal(httpErr.Body, &body) == nil && len(body.Errors) > 0 {
+		resp.Errors = body.Errors
+		resp.Extensions = body.Extensions
+		httpErr.errors = body.Errors
+	}
+}
+
+// postPayload returns the value to be marshaled as the JSON body of a POST
+// request for req.
+func (c *client) postPayload(req *Request) interface{} {
+	if c.trustedDocuments {
+		return documentIDRequest{Request: req, DocumentID: req.DocumentID}
+	}
+	return req
+}
+
+func (c *client) createPostRequest(req *Request, uploads []foundUpload) (*http.Request, error) {
+	if len(uploads) > 0 {
+		return c.createMultipartRequest(c.postPayload(req), uploads)
 	}
 
-	body, err := json.Marshal(req)
+	body, err := json.Marshal(c.postPayload(req))
 	if err != nil {
 		return nil, err
 	}
@@ -299,16 +726,15 @@ func (c *client) createGetRequest(req *Request) (*http.Request, error) {
 	queryUpdated := false
 
 	if req.Query != "" {
-		if strings.HasPrefix(strings.TrimSpace(req.Query), "mutation") {
-			return nil, errors.New("client does not support mutations")
-		}
-		if strings.HasPrefix(strings.TrimSpace(req.Query), "subscription") {
-			return nil, errors.New("client does not support subscriptions")
-		}
 		queryParams.Set("query", req.Query)
 		queryUpdated = true
 	}
 
+	if c.trustedDocuments {
+		queryParams.Set("documentId", req.DocumentID)
+		queryUpdated = true
+	}
+
 	if req.OpName != "" {
 		queryParams.Set("operationName", req.OpName)
 		queryUpdated = true
@@ -323,6 +749,15 @@ func (c *client) createGetRequest(req *Request) (*http.Request, error) {
 		queryUpdated = true
 	}
 
+	if req.Extensions != nil {
+		extensions, extensionsErr := json.Marshal(req.Extensions)
+		if extensionsErr != nil {
+			return nil, extensionsErr
+		}
+		queryParams.Set("extensions", string(extensions))
+		queryUpdated = true
+	}
+
 	if queryUpdated {
 		parsedURL.RawQuery = queryParams.Encode()
 	}
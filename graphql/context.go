@@ -0,0 +1,116 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+)
+
+type (
+	headerKey           struct{}
+	extensionsKey       struct{}
+	connectionParamsKey struct{}
+)
+
+// WithHeader returns a context which, when passed to a genqlient function
+// (or [Client.MakeRequest]), causes the client to add the given HTTP header
+// to the request.  This is useful for headers which vary from request to
+// request, such as idempotency keys; headers which apply to all requests are
+// better set by the [http.Client] passed to [NewClient].
+//
+// The header replaces any value of the same header the client would
+// otherwise send; calling WithHeader several times for the same key sends
+// all the values.  Since a batch is a single HTTP request, a client with
+// [WithBatching] sends requests with such headers on their own.
+//
+// Per-request headers are supported only by the clients returned by
+// [NewClient] and [NewClientUsingGet].
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	header := contextHeader(ctx).Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Add(key, value)
+	return context.WithValue(ctx, headerKey{}, header)
+}
+
+func contextHeader(ctx context.Context) http.Header {
+	if ctx == nil {
+		return nil
+	}
+	header, _ := ctx.Value(headerKey{}).(http.Header)
+	return header
+}
+
+// WithExtensions returns a context which, when passed to a genqlient
+// function (or [Client.MakeRequest]), causes the client to add the given
+// extensions to the request (see [Request.Extensions]).  Extensions from
+// several calls to WithExtensions are merged, with later calls taking
+// precedence; both take precedence over those already in the Request.
+//
+// Per-request extensions are supported only by the clients returned by
+// [NewClient] and [NewClientUsingGet].
+func WithExtensions(ctx context.Context, extensions map[string]interface{}) context.Context {
+	merged := make(map[string]interface{})
+	for k, v := range contextExtensions(ctx) {
+		merged[k] = v
+	}
+	for k, v := range extensions {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, extensionsKey{}, merged)
+}
+
+func contextExtensions(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	extensions, _ := ctx.Value(extensionsKey{}).(map[string]interface{})
+	return extensions
+}
+
+// withContextExtensions returns req, or if ctx has extensions (from
+// WithExtensions), a copy of req with them added.
+func withContextExtensions(ctx context.Context, req *Request) *Request {
+	extensions := contextExtensions(ctx)
+	if len(extensions) == 0 {
+		return req
+	}
+	merged := make(map[string]interface{}, len(req.Extensions)+len(extensions))
+	for k, v := range req.Extensions {
+		merged[k] = v
+	}
+	for k, v := range extensions {
+		merged[k] = v
+	}
+	reqCopy := *req
+	reqCopy.Extensions = merged
+	return &reqCopy
+}
+
+// WithConnectionParams returns a context which, when passed to
+// [WebSocketClient.Start], causes the client to add the given parameters to
+// the payload of its connection_init message, including when it reconnects.
+// Parameters from several calls to WithConnectionParams are merged, with
+// later calls taking precedence; both take precedence over those passed to
+// [NewClientUsingWebSocketWithConnectionParams].
+//
+// Per-connection parameters are supported only by the client returned by
+// [NewClientUsingWebSocket] and [NewClientUsingWebSocketWithConnectionParams].
+func WithConnectionParams(ctx context.Context, params map[string]interface{}) context.Context {
+	merged := make(map[string]interface{})
+	for k, v := range contextConnectionParams(ctx) {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, connectionParamsKey{}, merged)
+}
+
+func contextConnectionParams(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	params, _ := ctx.Value(connectionParamsKey{}).(map[string]interface{})
+	return params
+}
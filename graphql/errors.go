@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// graphQLErrors returns the GraphQL errors in err, which is typically an
+// error returned by Client.MakeRequest or a genqlient-generated function.
+func graphQLErrors(err error) gqlerror.List {
+	var errList gqlerror.List
+	if errors.As(err, &errList) {
+		return errList
+	}
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		return gqlerror.List{gqlErr}
+	}
+	return nil
+}
+
+// ErrorsAt returns the GraphQL errors in err whose path is at or below the
+// given path, or nil if there are none (including if err is not a GraphQL
+// error at all).
+//
+// The path is made up of field names (or, if the query uses them, aliases)
+// as strings, and list indexes as ints.  For example, for the query
+//
+//	query GetUser { user { name friends { name } } }
+//
+// the errors from user.name are ErrorsAt(err, "user", "name"), those from
+// the name of the user's first friend are ErrorsAt(err, "user", "friends",
+// 0, "name"), and those from anywhere in user are ErrorsAt(err, "user").
+// Since GraphQL nulls out a field which errors (along with its parents, up
+// to the nearest nullable one), if ErrorsAt returns any errors, the field at
+// that path is null or partly null in the response.
+//
+// If no path is given, ErrorsAt returns all the errors.
+func ErrorsAt(err error, path ...interface{}) gqlerror.List {
+	var retval gqlerror.List
+	for _, gqlErr := range graphQLErrors(err) {
+		if pathHasPrefix(gqlErr.Path, path) {
+			retval = append(retval, gqlErr)
+		}
+	}
+	return retval
+}
+
+// pathHasPrefix returns true if the given error path begins with prefix,
+// whose elements are as described in ErrorsAt.
+func pathHasPrefix(path ast.Path, prefix []interface{}) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i, want := range prefix {
+		switch elem := path[i].(type) {
+		case ast.PathName:
+			if name, ok := want.(string); !ok || string(elem) != name {
+				return false
+			}
+		case ast.PathIndex:
+			if index, ok := want.(int); !ok || int(elem) != index {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// HasErrorCode returns true if any of the GraphQL errors in err has the
+// given code, in its extensions.code, as is the convention for GraphQL
+// servers.
+func HasErrorCode(err error, code string) bool {
+	for _, gqlErr := range graphQLErrors(err) {
+		if errCode, ok := gqlErr.Extensions["code"].(string); ok && errCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeErrorExtensions decodes the extensions of the given GraphQL error
+// into v, which should be a pointer to a struct (or other value) suitable
+// for json.Unmarshal.  This is useful for servers which put structured
+// information about the error in its extensions, for example:
+//
+//	var ext struct {
+//		Code       string `json:"code"`
+//		RetryAfter int    `json:"retryAfter"`
+//	}
+//	for _, gqlErr := range graphql.ErrorsAt(err, "user") {
+//		if graphql.DecodeErrorExtensions(gqlErr, &ext) == nil && ext.Code == "RATE_LIMITED" {
+//			...
+//		}
+//	}
+func DecodeErrorExtensions(gqlErr *gqlerror.Error, v interface{}) error {
+	// The extensions have already been decoded into a map, so we must
+	// re-encode them to decode them again into v.
+	b, err := json.Marshal(gqlErr.Extensions)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,158 @@
+// Package expvarmetrics provides a [graphql.MetricsRecorder] which publishes
+// metrics via the standard [expvar] package, so they can be served (as JSON,
+// at /debug/vars) without any additional dependencies:
+//
+//	recorder := expvarmetrics.New()
+//	expvar.Publish("genqlient", recorder)
+//	client := graphql.NewClient(url, http.DefaultClient, graphql.WithMetrics(recorder))
+//
+// The published value is a JSON object of the form:
+//
+//	{
+//		"requests": {"getUser": 3},
+//		"errors": {"getUser": 1},
+//		"errorCodes": {"NOT_FOUND": 1},
+//		"latency": {"getUser": {"10ms": 1, "25ms": 2}},
+//		"requestBytes": {"getUser": 300},
+//		"responseBytes": {"getUser": 1200},
+//		"activeSubscriptions": 2,
+//		"reconnects": 1,
+//		"reconnectFailures": 0
+//	}
+//
+// Each key of requests, errors, latency, requestBytes and responseBytes is
+// an operation name.  latency is a histogram: each request is counted in the
+// smallest bucket (see [LatencyBuckets]) which is at least its duration, or
+// "+Inf".  requestBytes and responseBytes are totals.
+package expvarmetrics
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// LatencyBuckets are the upper bounds of the buckets of the latency
+// histogram.  To change them, do so before creating any recorders.
+var LatencyBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Recorder is a [graphql.MetricsRecorder] which records metrics as expvar
+// variables.  It is itself an [expvar.Var], which should typically be
+// published with [expvar.Publish].
+//
+// A Recorder may be shared by several clients, in which case it records the
+// total of their metrics; activeSubscriptions, however, is only meaningful
+// for a single WebSocket client.
+type Recorder struct {
+	vars                *expvar.Map
+	requests            *expvar.Map
+	errors              *expvar.Map
+	errorCodes          *expvar.Map
+	latency             *expvar.Map
+	requestBytes        *expvar.Map
+	responseBytes       *expvar.Map
+	activeSubscriptions *expvar.Int
+	reconnects          *expvar.Int
+	reconnectFailures   *expvar.Int
+	// latencyMu guards the creation of the per-operation histograms in
+	// latency.
+	latencyMu sync.Mutex
+}
+
+var _ graphql.MetricsRecorder = (*Recorder)(nil)
+
+// New returns a new, unpublished, Recorder.
+func New() *Recorder {
+	r := &Recorder{
+		vars:                new(expvar.Map).Init(),
+		requests:            new(expvar.Map).Init(),
+		errors:              new(expvar.Map).Init(),
+		errorCodes:          new(expvar.Map).Init(),
+		latency:             new(expvar.Map).Init(),
+		requestBytes:        new(expvar.Map).Init(),
+		responseBytes:       new(expvar.Map).Init(),
+		activeSubscriptions: new(expvar.Int),
+		reconnects:          new(expvar.Int),
+		reconnectFailures:   new(expvar.Int),
+	}
+	r.vars.Set("requests", r.requests)
+	r.vars.Set("errors", r.errors)
+	r.vars.Set("errorCodes", r.errorCodes)
+	r.vars.Set("latency", r.latency)
+	r.vars.Set("requestBytes", r.requestBytes)
+	r.vars.Set("responseBytes", r.responseBytes)
+	r.vars.Set("activeSubscriptions", r.activeSubscriptions)
+	r.vars.Set("reconnects", r.reconnects)
+	r.vars.Set("reconnectFailures", r.reconnectFailures)
+	return r
+}
+
+// String implements [expvar.Var], returning the metrics as JSON.
+func (r *Recorder) String() string {
+	return r.vars.String()
+}
+
+// RecordRequest implements [graphql.MetricsRecorder].
+func (r *Recorder) RecordRequest(_ context.Context, metrics *graphql.RequestMetrics) {
+	r.requests.Add(metrics.OpName, 1)
+	if metrics.Err != nil {
+		r.errors.Add(metrics.OpName, 1)
+	}
+	for _, code := range metrics.ErrorCodes {
+		r.errorCodes.Add(code, 1)
+	}
+	r.histogram(metrics.OpName).Add(bucket(metrics.Duration), 1)
+	r.requestBytes.Add(metrics.OpName, metrics.RequestSize)
+	r.responseBytes.Add(metrics.OpName, metrics.ResponseSize)
+}
+
+// RecordActiveSubscriptions implements [graphql.MetricsRecorder].
+func (r *Recorder) RecordActiveSubscriptions(n int) {
+	r.activeSubscriptions.Set(int64(n))
+}
+
+// RecordReconnect implements [graphql.MetricsRecorder].
+func (r *Recorder) RecordReconnect(err error) {
+	r.reconnects.Add(1)
+	if err != nil {
+		r.reconnectFailures.Add(1)
+	}
+}
+
+// histogram returns the latency histogram for the given operation, creating
+// it if needed.
+func (r *Recorder) histogram(opName string) *expvar.Map {
+	r.latencyMu.Lock()
+	defer r.latencyMu.Unlock()
+	histogram, ok := r.latency.Get(opName).(*expvar.Map)
+	if !ok {
+		histogram = new(expvar.Map).Init()
+		r.latency.Set(opName, histogram)
+	}
+	return histogram
+}
+
+// bucket returns the name of the histogram bucket for the given duration.
+func bucket(d time.Duration) string {
+	for _, bound := range LatencyBuckets {
+		if d <= bound {
+			return bound.String()
+		}
+	}
+	return "+Inf"
+}
@@ -0,0 +1,469 @@
+// Package graphqltest provides utilities for testing code which uses
+// genqlient.
+//
+// The main utility is [Client], a mock [graphql.Client] (and
+// [graphql.WebSocketClient]) which returns canned responses to the
+// operations a test expects:
+//
+//	func TestGreet(t *testing.T) {
+//		client := graphqltest.NewClient(t)
+//		client.Expect("getUser").
+//			WithVariables(map[string]interface{}{"id": "1"}).
+//			Respond(&getUserResponse{User: getUserUser{Name: "Ada"}})
+//
+//		greeting, err := Greet(context.Background(), client, "1")
+//		...
+//	}
+//
+// At the end of the test, the client checks that each expected operation was
+// made.
+package graphqltest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// Client is a mock [graphql.Client] and [graphql.WebSocketClient], which
+// responds to each operation with the response registered by [Client.Expect]
+// or [Client.ExpectSubscription].  Operations it doesn't expect fail the
+// test.  It may be used concurrently.
+type Client struct {
+	t            testing.TB
+	errChan      chan error
+	expectations []*Expectation
+	streams      []*Stream
+	mu           sync.Mutex
+	closed       bool
+}
+
+var (
+	_ graphql.Client          = (*Client)(nil)
+	_ graphql.WebSocketClient = (*Client)(nil)
+)
+
+// NewClient returns a new Client, which fails t if any operation is made
+// which it doesn't expect, and, at the end of the test, if any expected
+// operation wasn't made.
+func NewClient(t testing.TB) *Client {
+	c := &Client{t: t, errChan: make(chan error)}
+	t.Cleanup(func() {
+		c.checkExpectations()
+		_ = c.Close()
+	})
+	return c
+}
+
+// matcher describes the requests an [Expectation] or [Stream] matches.
+type matcher struct {
+	// If set, the variables must match; see Expectation.WithVariables.
+	matchVariables func(variables map[string]interface{}) bool
+	opName         string
+	// How to describe the variables we expect, for error messages.
+	variables string
+}
+
+func (m *matcher) matches(req *graphql.Request, variables map[string]interface{}) bool {
+	return req.OpName == m.opName && (m.matchVariables == nil || m.matchVariables(variables))
+}
+
+func (m *matcher) String() string {
+	if m.variables == "" {
+		return m.opName
+	}
+	return m.opName + " with variables " + m.variables
+}
+
+func (m *matcher) withVariables(variables interface{}) {
+	want, err := normalizeVariables(variables)
+	if err != nil {
+		panic(fmt.Sprintf("graphqltest: invalid variables %v: %v", variables, err))
+	}
+	m.matchVariables = func(got map[string]interface{}) bool {
+		return reflect.DeepEqual(got, want)
+	}
+	b, _ := json.Marshal(want)
+	m.variables = string(b)
+}
+
+func (m *matcher) withVariablesMatching(f func(variables map[string]interface{}) bool) {
+	m.matchVariables = f
+	m.variables = "(matching a function)"
+}
+
+// normalizeVariables returns the given variables as they would be sent to
+// the server, decoded as a map.
+func normalizeVariables(variables interface{}) (map[string]interface{}, error) {
+	if variables == nil {
+		return map[string]interface{}{}, nil
+	}
+	b, err := json.Marshal(variables)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	err = json.Unmarshal(b, &normalized)
+	if normalized == nil {
+		normalized = map[string]interface{}{}
+	}
+	return normalized, err
+}
+
+// Expectation is a query or mutation a [Client] expects; see
+// [Client.Expect].
+type Expectation struct {
+	// The error to return, if any.
+	err error
+	matcher
+	// The response to return, as JSON.
+	response json.RawMessage
+	// How many times the operation is expected, and how many times it has
+	// been made.
+	times, calls int
+}
+
+// Expect registers an expected query or mutation with the given operation
+// name, and returns it so that the test may configure its variables (if
+// they matter) and its response.  By default, it's expected exactly once,
+// with any variables, and its response is empty.
+//
+// When the client receives a request, it responds as the first expectation
+// which matches it and hasn't already been made the expected number of
+// times.
+func (c *Client) Expect(opName string) *Expectation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := &Expectation{matcher: matcher{opName: opName}, times: 1, response: json.RawMessage(`{}`)}
+	c.expectations = append(c.expectations, e)
+	return e
+}
+
+// WithVariables configures the expectation to match only requests with the
+// given variables, which may be a map or a struct.  Variables are compared
+// as JSON, so a map[string]interface{} with the same values as the
+// generated input type will match.
+func (e *Expectation) WithVariables(variables interface{}) *Expectation {
+	e.withVariables(variables)
+	return e
+}
+
+// WithVariablesMatching configures the expectation to match only requests
+// whose variables (as decoded from JSON) satisfy f.
+func (e *Expectation) WithVariablesMatching(f func(variables map[string]interface{}) bool) *Expectation {
+	e.withVariablesMatching(f)
+	return e
+}
+
+// Times configures the expectation to be expected n times, rather than once.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Respond configures the expectation to respond with the given data, which
+// is typically a value of the genqlient-generated response type for the
+// operation, but may be anything which marshals to the appropriate JSON.
+func (e *Expectation) Respond(data interface{}) *Expectation {
+	b, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		panic(fmt.Sprintf("graphqltest: invalid response %v: %v", data, err))
+	}
+	e.response = b
+	return e
+}
+
+// RespondJSON configures the expectation to respond with the given JSON,
+// which is a complete GraphQL response, as a server would send it, e.g.
+//
+//	{"data": {"user": null}, "errors": [{"message": "not found", "path": ["user"]}]}
+//
+// As with a real client, if the response has errors, the operation returns
+// them as an error, along with the data.
+func (e *Expectation) RespondJSON(response string) *Expectation {
+	if !json.Valid([]byte(response)) {
+		panic(fmt.Sprintf("graphqltest: invalid JSON response %s", response))
+	}
+	e.response = json.RawMessage(response)
+	return e
+}
+
+// RespondError configures the expectation to fail with the given error, as
+// if, for example, there were a network error.
+func (e *Expectation) RespondError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// MakeRequest implements [graphql.Client].
+func (c *Client) MakeRequest(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+	if req.OperationType() == ast.Subscription {
+		return c.fail("graphqltest: subscription %s made via MakeRequest; use Subscribe", req.OpName)
+	}
+	variables, err := normalizeVariables(req.Variables)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	var match *Expectation
+	for _, e := range c.expectations {
+		if e.calls < e.times && e.matches(req, variables) {
+			match = e
+			match.calls++
+			break
+		}
+	}
+	c.mu.Unlock()
+	if match == nil {
+		return c.unexpected(req, variables)
+	}
+
+	if match.err != nil {
+		return match.err
+	}
+	err = json.Unmarshal(match.response, resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
+// unexpected fails the test, and returns an error, for an unexpected
+// request.
+func (c *Client) unexpected(req *graphql.Request, variables map[string]interface{}) error {
+	b, _ := json.Marshal(variables)
+	return c.fail("graphqltest: unexpected operation %s with variables %s", req.OpName, b)
+}
+
+// fail fails the test, and returns an error, with the given message.
+func (c *Client) fail(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	c.t.Errorf("%v", err)
+	return err
+}
+
+// checkExpectations fails the test if any expected operation wasn't made.
+func (c *Client) checkExpectations() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, e := range c.expectations {
+		if e.calls < e.times {
+			c.t.Errorf("graphqltest: expected operation %v %d times, but it was made %d times",
+				&e.matcher, e.times, e.calls)
+		}
+	}
+	for _, s := range c.streams {
+		if s.id == "" {
+			c.t.Errorf("graphqltest: expected subscription %v, but it was not made", &s.matcher)
+		}
+	}
+}
+
+// Stream is a subscription a [Client] expects, to which the test may send
+// data; see [Client.ExpectSubscription].
+type Stream struct {
+	matcher
+	// Signaled (without blocking) when pending, completed, or unsubscribed
+	// changes.
+	wake chan struct{}
+	// The subscription ID, once subscribed.
+	id string
+	// The data to be forwarded to the subscriber, in order.
+	pending   []json.RawMessage
+	mu        sync.Mutex
+	completed bool
+	// Whether the subscriber has unsubscribed.
+	unsubscribed bool
+}
+
+// ExpectSubscription registers an expected subscription with the given
+// operation name, and returns its stream, to which the test may send data
+// and errors.  It's expected exactly once, with any variables.
+//
+// Data may be sent before or after the subscription starts; the client
+// forwards it to the subscriber in order.
+func (c *Client) ExpectSubscription(opName string) *Stream {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &Stream{matcher: matcher{opName: opName}, wake: make(chan struct{}, 1)}
+	c.streams = append(c.streams, s)
+	return s
+}
+
+// WithVariables is as for [Expectation.WithVariables].
+func (s *Stream) WithVariables(variables interface{}) *Stream {
+	s.withVariables(variables)
+	return s
+}
+
+// WithVariablesMatching is as for [Expectation.WithVariablesMatching].
+func (s *Stream) WithVariablesMatching(f func(variables map[string]interface{}) bool) *Stream {
+	s.withVariablesMatching(f)
+	return s
+}
+
+// Send sends the given data to the subscriber.  As for [Expectation.Respond],
+// it's typically a value of the genqlient-generated response type.
+func (s *Stream) Send(data interface{}) {
+	b, err := json.Marshal(map[string]interface{}{"data": data})
+	if err != nil {
+		panic(fmt.Sprintf("graphqltest: invalid data %v: %v", data, err))
+	}
+	s.push(b)
+}
+
+// SendJSON sends the given JSON, which is a complete GraphQL response,
+// to the subscriber; see [Expectation.RespondJSON].
+func (s *Stream) SendJSON(response string) {
+	if !json.Valid([]byte(response)) {
+		panic(fmt.Sprintf("graphqltest: invalid JSON response %s", response))
+	}
+	s.push(json.RawMessage(response))
+}
+
+// Complete ends the subscription, as if the server completed it: once the
+// subscriber has received all the data sent so far, its channel is closed.
+func (s *Stream) Complete() {
+	s.mu.Lock()
+	s.completed = true
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *Stream) push(response json.RawMessage) {
+	s.mu.Lock()
+	if s.completed {
+		s.mu.Unlock()
+		panic("graphqltest: Send after Complete")
+	}
+	s.pending = append(s.pending, response)
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *Stream) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// forward forwards the stream's data to the subscriber, until the stream is
+// completed or the subscriber unsubscribes, and then closes its channel.
+func (s *Stream) forward(t testing.TB, interfaceChan interface{}, forwardDataFunc graphql.ForwardDataFunction) {
+	defer reflect.ValueOf(interfaceChan).Close()
+	for {
+		s.mu.Lock()
+		pending := s.pending
+		s.pending = nil
+		done := s.unsubscribed || (s.completed && len(pending) == 0)
+		s.mu.Unlock()
+		if done {
+			return
+		}
+
+		for _, response := range pending {
+			s.mu.Lock()
+			unsubscribed := s.unsubscribed
+			s.mu.Unlock()
+			if unsubscribed {
+				return
+			}
+			err := forwardDataFunc(interfaceChan, response)
+			if err != nil {
+				t.Errorf("graphqltest: failed to forward data for %s: %v", s.opName, err)
+			}
+		}
+		if len(pending) == 0 {
+			<-s.wake
+		}
+	}
+}
+
+// Start implements [graphql.WebSocketClient]; it does nothing.
+func (c *Client) Start(ctx context.Context) (errChan chan error, err error) {
+	return c.errChan, nil
+}
+
+// Close implements [graphql.WebSocketClient], unsubscribing from all
+// subscriptions and closing the error channel.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	streams := c.streams
+	if !c.closed {
+		c.closed = true
+		close(c.errChan)
+	}
+	c.mu.Unlock()
+	for _, s := range streams {
+		s.unsubscribe()
+	}
+	return nil
+}
+
+// Subscribe implements [graphql.WebSocketClient], starting the first
+// matching subscription registered by [Client.ExpectSubscription].
+func (c *Client) Subscribe(
+	req *graphql.Request,
+	interfaceChan interface{},
+	forwardDataFunc graphql.ForwardDataFunction,
+) (string, error) {
+	variables, err := normalizeVariables(req.Variables)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	var match *Stream
+	for i, s := range c.streams {
+		if s.id == "" && s.matches(req, variables) {
+			match = s
+			match.id = fmt.Sprintf("%s-%d", req.OpName, i)
+			break
+		}
+	}
+	c.mu.Unlock()
+	if match == nil {
+		return "", c.unexpected(req, variables)
+	}
+
+	go match.forward(c.t, interfaceChan, forwardDataFunc)
+	return match.id, nil
+}
+
+// Unsubscribe implements [graphql.WebSocketClient].  The subscriber's
+// channel is closed once any data being forwarded has been received.
+func (c *Client) Unsubscribe(subscriptionID string) error {
+	c.mu.Lock()
+	var match *Stream
+	for _, s := range c.streams {
+		if s.id == subscriptionID && subscriptionID != "" {
+			match = s
+		}
+	}
+	c.mu.Unlock()
+	if match == nil {
+		return errors.New("graphqltest: unknown subscription ID " + subscriptionID)
+	}
+	match.unsubscribe()
+	return nil
+}
+
+func (s *Stream) unsubscribe() {
+	s.mu.Lock()
+	s.unsubscribed = true
+	s.mu.Unlock()
+	s.signal()
+}
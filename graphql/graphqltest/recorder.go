@@ -0,0 +1,581 @@
+package graphqltest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// RecordEnvVar is the environment variable which, if set to a nonempty
+// value, causes a [Recorder] to record even if its file already exists.
+const RecordEnvVar = "GRAPHQLTEST_RECORD"
+
+// Recorder records the requests a client makes to a real server, and their
+// responses, to a file, and then replays them in later runs, so that tests
+// may run offline against real responses.  It plugs in as the [graphql.Doer]
+// for [graphql.NewClient] (see [Recorder.Doer]), and the [graphql.Dialer]
+// for [graphql.NewClientUsingWebSocket] (see [Recorder.Dialer]):
+//
+//	rec := graphqltest.NewRecorder(t, "testdata/get_user.json")
+//	client := graphql.NewClient(url, rec.Doer(http.DefaultClient))
+//
+// If the file doesn't exist, or the environment variable GRAPHQLTEST_RECORD
+// is set, the recorder records: it passes requests through to the real Doer
+// or Dialer, and at the end of the test writes them and their responses to
+// the file (which should be checked in).  Otherwise, it replays: it
+// responds to each request with the recorded response, and never contacts
+// the server.
+//
+// HTTP requests are matched by operation name and variables (or, for a
+// batch, those of each operation), so the order of the requests needn't be
+// the same as when they were recorded; if the same request was made several
+// times, the recorded responses are replayed in order.  WebSocket
+// connections are replayed in the order they were made; on each, messages
+// the client sends are matched similarly, and the server's messages are
+// replayed after the client messages which preceded them.
+//
+// Sensitive headers (by default, Authorization, Cookie, and Set-Cookie; see
+// [RedactHeaders]) are not recorded, nor are connection parameters with the
+// same names.
+type Recorder struct {
+	t testing.TB
+	// The headers to redact, in canonical form.
+	redact map[string]bool
+	// In replay mode, how many of the recorded responses to each request we
+	// have replayed.
+	replayed map[string]int
+	path     string
+	// What we've recorded, or are replaying.
+	cassette cassette
+	// In replay mode, how many WebSocket connections we have replayed.
+	dials     int
+	mu        sync.Mutex
+	recording bool
+}
+
+// RecorderOption configures a [Recorder].
+type RecorderOption func(*Recorder)
+
+// RedactHeaders configures the recorder to redact the given headers, in
+// addition to Authorization, Cookie, and Set-Cookie, in both requests and
+// responses, and the connection parameters of the same names.
+func RedactHeaders(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		for _, name := range names {
+			r.redact[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// NewRecorder returns a Recorder which records to, or replays from, the
+// given file; see [Recorder] for details.  In recording mode, it writes the
+// file when the test completes (even if it failed).
+func NewRecorder(t testing.TB, path string, opts ...RecorderOption) *Recorder {
+	r := &Recorder{
+		t:    t,
+		path: path,
+		redact: map[string]bool{
+			"Authorization": true,
+			"Cookie":        true,
+			"Set-Cookie":    true,
+		},
+		replayed: map[string]int{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case os.Getenv(RecordEnvVar) != "" || errors.Is(err, os.ErrNotExist):
+		r.recording = true
+		t.Cleanup(r.save)
+	case err != nil:
+		t.Fatalf("graphqltest: %v", err)
+	default:
+		err = json.Unmarshal(b, &r.cassette)
+		if err != nil {
+			t.Fatalf("graphqltest: invalid recording %v: %v", path, err)
+		}
+	}
+	return r
+}
+
+// Recording returns true if the recorder is recording, rather than
+// replaying.
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// cassette is the format of the recorder's file.
+type cassette struct {
+	HTTP []*httpInteraction `json:"http,omitempty"`
+	// The messages sent and received on each WebSocket connection.
+	WebSocket [][]*wsMessage `json:"websocket,omitempty"`
+}
+
+type httpInteraction struct {
+	// The key by which requests are matched; see requestKey.
+	Key      string           `json:"key"`
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Header http.Header `json:"header,omitempty"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	body
+}
+
+type recordedResponse struct {
+	Header http.Header `json:"header,omitempty"`
+	body
+	StatusCode int `json:"status"`
+}
+
+// body is a request or response body, or a WebSocket message, which is
+// recorded as JSON if it is JSON, and as (base64-encoded) bytes otherwise.
+type body struct {
+	JSON json.RawMessage `json:"json,omitempty"`
+	Raw  []byte          `json:"raw,omitempty"`
+}
+
+func newBody(b []byte) body {
+	if len(b) > 0 && json.Valid(b) {
+		return body{JSON: b}
+	}
+	return body{Raw: b}
+}
+
+func (b body) bytes() []byte {
+	if b.JSON != nil {
+		return b.JSON
+	}
+	return b.Raw
+}
+
+type wsMessage struct {
+	// "sent" (by the client) or "received" (from the server).
+	Direction string `json:"direction"`
+	body
+	MessageType int `json:"messageType"`
+}
+
+// save writes the recording to the file.
+func (r *Recorder) save() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(r.path), 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(r.path, append(b, '\n'), 0o644)
+	}
+	if err != nil {
+		r.t.Errorf("graphqltest: failed to write recording: %v", err)
+	}
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for name := range header {
+		if r.redact[http.CanonicalHeaderKey(name)] {
+			header[name] = []string{"REDACTED"}
+		}
+	}
+	return header
+}
+
+// Doer returns a [graphql.Doer] which, when recording, makes requests with
+// wrapped (or, if it's nil, [http.DefaultClient]), and when replaying,
+// responds with the recorded responses.
+func (r *Recorder) Doer(wrapped graphql.Doer) graphql.Doer {
+	if wrapped == nil {
+		wrapped = http.DefaultClient
+	}
+	return &recordingDoer{recorder: r, wrapped: wrapped}
+}
+
+type recordingDoer struct {
+	recorder *Recorder
+	wrapped  graphql.Doer
+}
+
+func (d *recordingDoer) Do(httpReq *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if httpReq.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(httpReq.Body)
+		httpReq.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	key := requestKey(httpReq, reqBody)
+
+	r := d.recorder
+	if !r.recording {
+		return r.replayHTTP(httpReq, key)
+	}
+
+	httpResp, err := d.wrapped.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	httpResp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.HTTP = append(r.cassette.HTTP, &httpInteraction{
+		Key: key,
+		Request: recordedRequest{
+			Method: httpReq.Method,
+			URL:    httpReq.URL.String(),
+			Header: r.redactHeader(httpReq.Header),
+			body:   newBody(reqBody),
+		},
+		Response: recordedResponse{
+			StatusCode: httpResp.StatusCode,
+			Header:     r.redactHeader(httpResp.Header),
+			body:       newBody(respBody),
+		},
+	})
+	return httpResp, nil
+}
+
+// replayHTTP returns the next recorded response to the request with the
+// given key.
+func (r *Recorder) replayHTTP(httpReq *http.Request, key string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []*httpInteraction
+	for _, interaction := range r.cassette.HTTP {
+		if interaction.Key == key {
+			matches = append(matches, interaction)
+		}
+	}
+	if len(matches) == 0 {
+		err := fmt.Errorf("graphqltest: no recorded response for %s in %s "+
+			"(to re-record, set %s=1)", key, r.path, RecordEnvVar)
+		r.t.Errorf("%v", err)
+		return nil, err
+	}
+
+	// Replay the responses in order, repeating the last once we run out.
+	i := r.replayed[key]
+	if i < len(matches)-1 {
+		r.replayed[key]++
+	}
+	recorded := matches[i].Response
+	b := recorded.bytes()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(b)),
+		ContentLength: int64(len(b)),
+		Request:       httpReq,
+	}, nil
+}
+
+// requestKey returns the key by which the given request is matched: its
+// operation name and variables, or for a batch, those of each operation.
+func requestKey(httpReq *http.Request, reqBody []byte) string {
+	if httpReq.Method == http.MethodGet {
+		query := httpReq.URL.Query()
+		var variables interface{}
+		_ = json.Unmarshal([]byte(query.Get("variables")), &variables)
+		return operationKey(query.Get("operationName"), variables)
+	}
+
+	type operation struct {
+		Variables     interface{} `json:"variables"`
+		OperationName string      `json:"operationName"`
+	}
+	var op operation
+	if json.Unmarshal(reqBody, &op) == nil {
+		return operationKey(op.OperationName, op.Variables)
+	}
+	var batch []operation
+	if json.Unmarshal(reqBody, &batch) == nil {
+		keys := make([]string, len(batch))
+		for i, op := range batch {
+			keys[i] = operationKey(op.OperationName, op.Variables)
+		}
+		return strings.Join(keys, "; ")
+	}
+	// Some other request, such as a file upload; we match it exactly.
+	sum := sha256.Sum256(reqBody)
+	return httpReq.Method + " " + hex.EncodeToString(sum[:])
+}
+
+// operationKey returns the key for the given operation.  The variables are
+// normalized by re-marshaling them, which sorts their keys.
+func operationKey(opName string, variables interface{}) string {
+	b, _ := json.Marshal(variables)
+	return opName + " " + string(b)
+}
+
+// Dialer returns a [graphql.Dialer] which, when recording, connects with
+// wrapped, and when replaying, replays the recorded connections in the order
+// they were made.
+func (r *Recorder) Dialer(wrapped graphql.Dialer) graphql.Dialer {
+	return &recordingDialer{recorder: r, wrapped: wrapped}
+}
+
+type recordingDialer struct {
+	recorder *Recorder
+	wrapped  graphql.Dialer
+}
+
+func (d *recordingDialer) DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (graphql.WSConn, error) {
+	r := d.recorder
+	if !r.recording {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.dials >= len(r.cassette.WebSocket) {
+			err := fmt.Errorf("graphqltest: no recorded connection for %s in %s "+
+				"(to re-record, set %s=1)", urlStr, r.path, RecordEnvVar)
+			r.t.Errorf("%v", err)
+			return nil, err
+		}
+		conn := newReplayConn(r, r.cassette.WebSocket[r.dials])
+		r.dials++
+		return conn, nil
+	}
+
+	conn, err := d.wrapped.DialContext(ctx, urlStr, requestHeader)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.WebSocket = append(r.cassette.WebSocket, nil)
+	return &recordingConn{WSConn: conn, recorder: r, index: len(r.cassette.WebSocket) - 1}, nil
+}
+
+// recordingConn is a WSConn which records the messages on the wrapped
+// connection.
+type recordingConn struct {
+	graphql.WSConn
+	recorder *Recorder
+	index    int
+}
+
+func (c *recordingConn) record(direction string, messageType int, data []byte) {
+	r := c.recorder
+	data = r.redactConnectionParams(data)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.WebSocket[c.index] = append(r.cassette.WebSocket[c.index],
+		&wsMessage{Direction: direction, MessageType: messageType, body: newBody(data)})
+}
+
+func (c *recordingConn) WriteMessage(messageType int, data []byte) error {
+	err := c.WSConn.WriteMessage(messageType, data)
+	if err == nil {
+		c.record("sent", messageType, data)
+	}
+	return err
+}
+
+func (c *recordingConn) ReadMessage() (messageType int, p []byte, err error) {
+	messageType, p, err = c.WSConn.ReadMessage()
+	if err == nil {
+		c.record("received", messageType, p)
+	}
+	return messageType, p, err
+}
+
+// redactConnectionParams returns data, or if it's a connection_init or
+// connection_ack message (which some servers use to echo the parameters),
+// a copy with redacted parameters.
+func (r *Recorder) redactConnectionParams(data []byte) []byte {
+	var msg struct {
+		Payload map[string]interface{} `json:"payload"`
+		Type    string                 `json:"type"`
+	}
+	if json.Unmarshal(data, &msg) != nil ||
+		(msg.Type != "connection_init" && msg.Type != "connection_ack") {
+		return data
+	}
+	redacted := false
+	for name := range msg.Payload {
+		if r.redact[http.CanonicalHeaderKey(name)] {
+			msg.Payload[name] = "REDACTED"
+			redacted = true
+		}
+	}
+	if !redacted {
+		return data
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return data
+	}
+	return b
+}
+
+// closeMessage is the WebSocket message type of a close message.
+const closeMessage = 8
+
+// replayConn is a WSConn which replays a recorded connection.
+type replayConn struct {
+	recorder *Recorder
+	// The IDs the client used for each operation, keyed by the recorded ID.
+	ids      map[string]string
+	cond     *sync.Cond
+	messages []*wsMessage
+	// Which of the sent messages the client has sent.
+	sent []bool
+	// The index of the next message to consider for ReadMessage.
+	next   int
+	mu     sync.Mutex
+	closed bool
+}
+
+func newReplayConn(r *Recorder, messages []*wsMessage) *replayConn {
+	c := &replayConn{
+		recorder: r,
+		messages: messages,
+		sent:     make([]bool, len(messages)),
+		ids:      map[string]string{},
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// wsMessageKey returns the key by which client messages are matched: their
+// type, and for operations, their operation name and variables.
+func wsMessageKey(messageType int, data []byte) (key, id string) {
+	var msg struct {
+		Payload struct {
+			Variables     interface{} `json:"variables"`
+			OperationName string      `json:"operationName"`
+		} `json:"payload"`
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(data, &msg) != nil {
+		return fmt.Sprintf("message type %d", messageType), ""
+	}
+	key = msg.Type
+	if msg.Payload.OperationName != "" {
+		key += " " + operationKey(msg.Payload.OperationName, msg.Payload.Variables)
+	}
+	return key, msg.ID
+}
+
+func (c *replayConn) WriteMessage(messageType int, data []byte) error {
+	key, id := wsMessageKey(messageType, data)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errors.New("graphqltest: connection closed")
+	}
+	for i, msg := range c.messages {
+		if msg.Direction != "sent" || c.sent[i] {
+			continue
+		}
+		recordedKey, recordedID := wsMessageKey(msg.MessageType, msg.bytes())
+		if recordedKey == key {
+			c.sent[i] = true
+			if recordedID != "" {
+				c.ids[recordedID] = id
+			}
+			c.cond.Broadcast()
+			return nil
+		}
+	}
+	switch key {
+	case "complete", "stop", "connection_terminate", "ping", "pong",
+		fmt.Sprintf("message type %d", closeMessage):
+		// These may reasonably differ from the recording, e.g. if the
+		// test ended at a different point.
+		return nil
+	}
+	err := fmt.Errorf("graphqltest: no recorded message matching %s in %s "+
+		"(to re-record, set %s=1)", key, c.recorder.path, RecordEnvVar)
+	c.recorder.t.Errorf("%v", err)
+	return err
+}
+
+// ReadMessage returns the next message received from the server, once the
+// client has sent all the messages which preceded it.  Once there are no
+// more, it blocks until the connection is closed.
+func (c *replayConn) ReadMessage() (messageType int, p []byte, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if c.closed {
+			return 0, nil, errors.New("graphqltest: connection closed")
+		}
+		for c.next < len(c.messages) && c.messages[c.next].Direction == "sent" {
+			if !c.sent[c.next] {
+				break
+			}
+			c.next++
+		}
+		if c.next < len(c.messages) && c.messages[c.next].Direction == "received" {
+			msg := c.messages[c.next]
+			c.next++
+			return msg.MessageType, c.rewriteID(msg.bytes()), nil
+		}
+		c.cond.Wait()
+	}
+}
+
+// rewriteID returns the given server message, with its operation ID
+// replaced by the one the client used.  c.mu must be held.
+func (c *replayConn) rewriteID(data []byte) []byte {
+	var msg map[string]json.RawMessage
+	if json.Unmarshal(data, &msg) != nil {
+		return data
+	}
+	var recordedID string
+	if json.Unmarshal(msg["id"], &recordedID) != nil {
+		return data
+	}
+	id, ok := c.ids[recordedID]
+	if !ok {
+		return data
+	}
+	msg["id"], _ = json.Marshal(id)
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return data
+	}
+	return b
+}
+
+func (c *replayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.cond.Broadcast()
+	return nil
+}
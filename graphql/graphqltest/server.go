@@ -0,0 +1,763 @@
+package graphqltest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+
+	"github.com/Khan/genqlient/internal/gqlschema"
+	"github.com/Khan/genqlient/internal/wsconn"
+)
+
+// Server is a fake GraphQL server, which responds to any operation which is
+// valid against its schema with fake data.  It's an [httptest.Server], so
+// point a client at its URL (or, for a WebSocket client, its
+// [Server.WebSocketURL]):
+//
+//	server := graphqltest.NewServer(t, []string{"schema.graphql"},
+//		graphqltest.WithResolver("Query", "user",
+//			func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+//				return map[string]interface{}{"id": args["id"], "name": "Ada"}, nil
+//			}))
+//	client := graphql.NewClient(server.URL, http.DefaultClient)
+//
+// The fake data is deterministic: each value depends only on the seed (see
+// [WithSeed]) and its path in the response.  Each scalar is a random value of
+// its type (for strings, the field name and a number), each enum one of its
+// values, each list [WithListLength] items long, and each interface or union
+// one of its concrete types.  The server can't know what values of a custom
+// scalar look like, so each custom scalar which is the type of some field
+// needs a [WithScalar].
+// Nullable fields are never null, unless a resolver makes them so; see
+// [WithResolver].
+//
+// The server accepts queries and mutations as POST (including batches) or
+// GET requests, and subscriptions over a WebSocket, speaking the
+// graphql-transport-ws protocol; each subscription receives
+// [WithSubscriptionEvents] events, and then completes.  It does not support
+// file uploads, persisted queries, or introspection.
+type Server struct {
+	*httptest.Server
+	schema             *ast.Schema
+	resolvers          map[string]Resolver
+	scalars            map[string]func(r *rand.Rand) interface{}
+	seed               int64
+	listLength         int
+	subscriptionEvents int
+}
+
+// Resolver computes the value of a field, in place of fake data; see
+// [WithResolver].  args are the field's arguments, with any variables
+// substituted.
+//
+// For a field of scalar or enum type, the resolver should return a value
+// which marshals to the JSON representation of that type; for a list, a
+// slice.  For a field of object, interface, or union type, it may return a
+// map or struct, whose JSON fields are the values of the object's fields;
+// any fields it omits are faked as usual, and for an interface or union, its
+// __typename chooses the concrete type.  If the resolver returns nil, the
+// field is null; if it returns an error, the field is null and the error is
+// added to the response.
+type Resolver func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+
+// ServerOption configures a [Server]; see [NewServer].
+type ServerOption func(*Server)
+
+// WithSeed sets the seed from which the server generates fake data.  By
+// default it's 0, so the data is the same in every run.
+func WithSeed(seed int64) ServerOption {
+	return func(s *Server) { s.seed = seed }
+}
+
+// WithListLength sets the number of items in each fake list.  The default is
+// 2.
+func WithListLength(n int) ServerOption {
+	return func(s *Server) { s.listLength = n }
+}
+
+// WithSubscriptionEvents sets the number of events the server sends for each
+// subscription, before completing it.  The default is 3.
+func WithSubscriptionEvents(n int) ServerOption {
+	return func(s *Server) { s.subscriptionEvents = n }
+}
+
+// WithResolver configures the server to compute the value of the field
+// typeName.fieldName (e.g. "Query", "user") by calling resolver, rather than
+// faking it.
+func WithResolver(typeName, fieldName string, resolver Resolver) ServerOption {
+	return func(s *Server) { s.resolvers[typeName+"."+fieldName] = resolver }
+}
+
+// WithScalar configures the server to generate fake values of the given
+// scalar type (e.g. "DateTime") by calling fake, which should return a value
+// which marshals to the JSON representation of that type, computed only
+// from r.
+func WithScalar(name string, fake func(r *rand.Rand) interface{}) ServerOption {
+	return func(s *Server) { s.scalars[name] = fake }
+}
+
+// NewServer starts a new Server for the schema in the given files, which
+// is closed at the end of the test.  It fails the test if the schema is
+// invalid, a resolver is for a field not in the schema, or a custom scalar
+// which is the type of some field has no [WithScalar].
+func NewServer(t testing.TB, schemaFiles []string, opts ...ServerOption) *Server {
+	t.Helper()
+	sources := make([]*ast.Source, len(schemaFiles))
+	for i, filename := range schemaFiles {
+		text, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("graphqltest: unreadable schema file %v: %v", filename, err)
+		}
+		sources[i] = &ast.Source{Name: filename, Input: string(text)}
+	}
+	schema, err := gqlschema.Load(sources...)
+	if err != nil {
+		t.Fatalf("graphqltest: invalid schema: %v", err)
+	}
+
+	s := &Server{
+		schema:             schema,
+		resolvers:          map[string]Resolver{},
+		scalars:            map[string]func(r *rand.Rand) interface{}{},
+		listLength:         2,
+		subscriptionEvents: 3,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for name := range s.resolvers {
+		typeName, fieldName, _ := strings.Cut(name, ".")
+		if def := schema.Types[typeName]; def == nil || def.Fields.ForName(fieldName) == nil {
+			t.Fatalf("graphqltest: resolver for unknown field %v", name)
+		}
+	}
+	if missing := s.unfakeableScalars(); len(missing) > 0 {
+		t.Fatalf("graphqltest: no fake values for custom scalars %v; see WithScalar",
+			strings.Join(missing, ", "))
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// unfakeableScalars returns the names of the custom scalars which are the
+// type of some field, but have no WithScalar, in order.  (Those used only in
+// arguments and input objects, like Upload, need none.)
+func (s *Server) unfakeableScalars() []string {
+	missing := map[string]bool{}
+	for _, def := range s.schema.Types {
+		if def.BuiltIn || (def.Kind != ast.Object && def.Kind != ast.Interface) {
+			continue
+		}
+		for _, field := range def.Fields {
+			name := field.Type.Name()
+			if s.schema.Types[name].Kind == ast.Scalar && !builtinScalars[name] && s.scalars[name] == nil {
+				missing[name] = true
+			}
+		}
+	}
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builtinScalars are the scalars for which fake has a default.
+var builtinScalars = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+// WebSocketURL returns the URL at which the server accepts WebSocket
+// connections, for use with [graphql.NewClientUsingWebSocket].
+func (s *Server) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// serverRequest is a GraphQL request, as the server receives it.
+type serverRequest struct {
+	Variables     map[string]interface{} `json:"variables"`
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+}
+
+// serverResponse is a GraphQL response, as the server sends it.
+type serverResponse struct {
+	Data   interface{}   `json:"data"`
+	Errors gqlerror.List `json:"errors,omitempty"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if wsconn.IsUpgrade(r) {
+		s.serveWebSocket(w, r)
+		return
+	}
+
+	var resp interface{}
+	switch r.Method {
+	case http.MethodGet:
+		params := r.URL.Query()
+		req := serverRequest{Query: params.Get("query"), OperationName: params.Get("operationName")}
+		if variables := params.Get("variables"); variables != "" {
+			err := json.Unmarshal([]byte(variables), &req.Variables)
+			if err != nil {
+				http.Error(w, "invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		resp = s.execute(r.Context(), &req)
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			var reqs []*serverRequest
+			err = json.Unmarshal(body, &reqs)
+			if err != nil {
+				http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			resps := make([]*serverResponse, len(reqs))
+			for i, req := range reqs {
+				resps[i] = s.execute(r.Context(), req)
+			}
+			resp = resps
+		} else {
+			var req serverRequest
+			err = json.Unmarshal(body, &req)
+			if err != nil {
+				http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			resp = s.execute(r.Context(), &req)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// serverMessage is a message of the graphql-transport-ws protocol, as the
+// server sends it.
+type serverMessage struct {
+	Payload interface{} `json:"payload,omitempty"`
+	ID      string      `json:"id,omitempty"`
+	Type    string      `json:"type"`
+}
+
+// serveWebSocket serves a WebSocket connection, speaking the
+// graphql-transport-ws protocol.
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsconn.Upgrade(w, r, []string{"graphql-transport-ws"})
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(r.Context())
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	send := func(msg *serverMessage) {
+		data, err := json.Marshal(msg)
+		if err == nil {
+			_ = conn.WriteMessage(wsconn.TextMessage, data)
+		}
+	}
+
+	var mu sync.Mutex
+	cancels := map[string]context.CancelFunc{}
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg struct {
+			ID      string          `json:"id"`
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if json.Unmarshal(data, &msg) != nil {
+			return
+		}
+
+		switch msg.Type {
+		case "connection_init":
+			send(&serverMessage{Type: "connection_ack"})
+		case "ping":
+			send(&serverMessage{Type: "pong"})
+		case "subscribe":
+			var req serverRequest
+			if json.Unmarshal(msg.Payload, &req) != nil {
+				return
+			}
+			opCtx, opCancel := context.WithCancel(ctx)
+			mu.Lock()
+			cancels[msg.ID] = opCancel
+			mu.Unlock()
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				defer opCancel()
+				s.serveOperation(opCtx, id, &req, send)
+			}(msg.ID)
+		case "complete":
+			mu.Lock()
+			if opCancel := cancels[msg.ID]; opCancel != nil {
+				opCancel()
+			}
+			mu.Unlock()
+		}
+	}
+}
+
+// serveOperation serves a single operation over a WebSocket, sending its
+// response (or, for a subscription, each event), and then completing it.
+func (s *Server) serveOperation(ctx context.Context, id string, req *serverRequest, send func(*serverMessage)) {
+	e, errs := s.prepare(ctx, req)
+	if errs != nil {
+		send(&serverMessage{ID: id, Type: "error", Payload: errs})
+		return
+	}
+	events := 1
+	if e.op.Operation == ast.Subscription {
+		events = s.subscriptionEvents
+	}
+	for e.event = 0; e.event < events && ctx.Err() == nil; e.event++ {
+		send(&serverMessage{ID: id, Type: "next", Payload: e.run()})
+	}
+	// We complete the operation even if the client unsubscribed (or the
+	// connection closed, in which case the send just fails).
+	send(&serverMessage{ID: id, Type: "complete"})
+}
+
+// execute executes a query or mutation, returning its response.
+func (s *Server) execute(ctx context.Context, req *serverRequest) *serverResponse {
+	e, errs := s.prepare(ctx, req)
+	if errs != nil {
+		return &serverResponse{Errors: errs}
+	}
+	return e.run()
+}
+
+// execution is the state of a single operation being executed.
+type execution struct {
+	ctx       context.Context
+	server    *Server
+	op        *ast.OperationDefinition
+	variables map[string]interface{}
+	errors    gqlerror.List
+	// For a subscription, the index of the event being generated, which
+	// seeds its fake data.
+	event int
+}
+
+// prepare parses and validates the request, returning the errors if it's
+// invalid.
+func (s *Server) prepare(ctx context.Context, req *serverRequest) (*execution, gqlerror.List) {
+	doc, errs := gqlparser.LoadQuery(s.schema, req.Query)
+	if errs != nil {
+		return nil, errs
+	}
+
+	var op *ast.OperationDefinition
+	switch {
+	case req.OperationName != "":
+		op = doc.Operations.ForName(req.OperationName)
+	case len(doc.Operations) == 1:
+		op = doc.Operations[0]
+	}
+	if op == nil {
+		return nil, gqlerror.List{gqlerror.Errorf("unknown operation %q", req.OperationName)}
+	}
+
+	variables, err := validator.VariableValues(s.schema, op, req.Variables)
+	if err != nil {
+		var gqlErr *gqlerror.Error
+		if !errors.As(err, &gqlErr) {
+			gqlErr = gqlerror.Wrap(err)
+		}
+		return nil, gqlerror.List{gqlErr}
+	}
+
+	return &execution{ctx: ctx, server: s, op: op, variables: variables}, nil
+}
+
+// run executes the operation, returning its response.
+func (e *execution) run() *serverResponse {
+	var root *ast.Definition
+	switch e.op.Operation {
+	case ast.Query:
+		root = e.server.schema.Query
+	case ast.Mutation:
+		root = e.server.schema.Mutation
+	case ast.Subscription:
+		root = e.server.schema.Subscription
+	}
+	e.errors = nil
+	data, ok := e.selectionSet(e.op.SelectionSet, root, nil, nil)
+	resp := &serverResponse{Errors: e.errors}
+	if ok {
+		resp.Data = data
+	}
+	return resp
+}
+
+// object is a JSON object which preserves the order of its fields.
+type object []objectField
+
+type objectField struct {
+	value interface{}
+	name  string
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// collectedField is a response key, and the fields which share it.
+type collectedField struct {
+	alias  string
+	fields []*ast.Field
+}
+
+// selectionSet computes the value of an object of type typ, which is
+// resolved (by a resolver) to parent, or nil if it wasn't.  It returns false
+// if a non-null field is null, in which case the object is null.
+func (e *execution) selectionSet(
+	selectionSet ast.SelectionSet,
+	typ *ast.Definition,
+	parent map[string]interface{},
+	path ast.Path,
+) (object, bool) {
+	collected := e.collectFields(selectionSet, typ, nil, map[string]bool{})
+	obj := make(object, 0, len(collected))
+	for _, cf := range collected {
+		field := cf.fields[0]
+		fieldPath := appendPath(path, ast.PathName(cf.alias))
+		if field.Name == "__typename" {
+			obj = append(obj, objectField{name: cf.alias, value: typ.Name})
+			continue
+		}
+
+		var subselection ast.SelectionSet
+		for _, f := range cf.fields {
+			subselection = append(subselection, f.SelectionSet...)
+		}
+		value, ok := e.field(field, subselection, typ, parent, fieldPath)
+		if !ok {
+			return nil, false
+		}
+		obj = append(obj, objectField{name: cf.alias, value: value})
+	}
+	return obj, true
+}
+
+// collectFields groups the fields of selectionSet, which apply to an object
+// of type typ, by their response key (their alias, if any, else their name),
+// following fragments.
+func (e *execution) collectFields(
+	selectionSet ast.SelectionSet,
+	typ *ast.Definition,
+	collected []*collectedField,
+	visited map[string]bool,
+) []*collectedField {
+	for _, selection := range selectionSet {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if e.skip(selection.Directives) {
+				continue
+			}
+			alias := selection.Alias
+			if alias == "" {
+				alias = selection.Name
+			}
+			found := false
+			for _, cf := range collected {
+				if cf.alias == alias {
+					cf.fields = append(cf.fields, selection)
+					found = true
+				}
+			}
+			if !found {
+				collected = append(collected, &collectedField{alias: alias, fields: []*ast.Field{selection}})
+			}
+		case *ast.InlineFragment:
+			if e.skip(selection.Directives) || !e.applies(selection.TypeCondition, typ) {
+				continue
+			}
+			collected = e.collectFields(selection.SelectionSet, typ, collected, visited)
+		case *ast.FragmentSpread:
+			if e.skip(selection.Directives) || visited[selection.Name] ||
+				!e.applies(selection.Definition.TypeCondition, typ) {
+				continue
+			}
+			visited[selection.Name] = true
+			collected = e.collectFields(selection.Definition.SelectionSet, typ, collected, visited)
+		}
+	}
+	return collected
+}
+
+// skip returns whether the @skip or @include directives exclude a selection.
+func (e *execution) skip(directives ast.DirectiveList) bool {
+	if skip := directives.ForName("skip"); skip != nil {
+		if value, _ := skip.ArgumentMap(e.variables)["if"].(bool); value {
+			return true
+		}
+	}
+	if include := directives.ForName("include"); include != nil {
+		if value, _ := include.ArgumentMap(e.variables)["if"].(bool); !value {
+			return true
+		}
+	}
+	return false
+}
+
+// applies returns whether a fragment on the given type condition applies to
+// an object of type typ.
+func (e *execution) applies(typeCondition string, typ *ast.Definition) bool {
+	if typeCondition == "" || typeCondition == typ.Name {
+		return true
+	}
+	if def := e.server.schema.Types[typeCondition]; def != nil {
+		for _, possible := range e.server.schema.GetPossibleTypes(def) {
+			if possible.Name == typ.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// field computes the value of a field of an object of type parentType,
+// which is resolved to parent, or nil if it wasn't.  It returns false if the
+// field is non-null but its value is null.
+func (e *execution) field(
+	field *ast.Field,
+	subselection ast.SelectionSet,
+	parentType *ast.Definition,
+	parent map[string]interface{},
+	path ast.Path,
+) (interface{}, bool) {
+	var value interface{}
+	resolved := false
+	if resolver := e.server.resolvers[parentType.Name+"."+field.Name]; resolver != nil {
+		var err error
+		value, err = resolver(e.ctx, field.ArgumentMap(e.variables))
+		if err == nil {
+			value, err = normalize(value)
+		}
+		if err != nil {
+			e.addError(err, field, path)
+			return nil, !field.Definition.Type.NonNull
+		}
+		resolved = true
+	} else if parent != nil {
+		value, resolved = parent[field.Name]
+	}
+	return e.value(field.Definition.Type, subselection, value, resolved, field, path)
+}
+
+// normalize converts a value returned by a resolver to the form in which
+// encoding/json unmarshals arbitrary JSON.
+func normalize(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+// value computes a value of the given type, given the value it's resolved
+// to, if resolved.  It returns false if the type is non-null but the value
+// is null.
+func (e *execution) value(
+	typ *ast.Type,
+	subselection ast.SelectionSet,
+	value interface{},
+	resolved bool,
+	field *ast.Field,
+	path ast.Path,
+) (interface{}, bool) {
+	if resolved && value == nil {
+		if typ.NonNull {
+			e.addError(errors.New("must not be null"), field, path)
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if typ.Elem != nil {
+		var items []interface{}
+		if resolved {
+			var ok bool
+			items, ok = value.([]interface{})
+			if !ok {
+				e.addError(fmt.Errorf("expected a list, got %v", value), field, path)
+				return nil, !typ.NonNull
+			}
+		} else {
+			items = make([]interface{}, e.server.listLength)
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			var ok bool
+			list[i], ok = e.value(typ.Elem, subselection, item, resolved, field, appendPath(path, ast.PathIndex(i)))
+			if !ok {
+				return nil, !typ.NonNull
+			}
+		}
+		return list, true
+	}
+
+	def := e.server.schema.Types[typ.NamedType]
+	switch def.Kind {
+	case ast.Object, ast.Interface, ast.Union:
+		var parent map[string]interface{}
+		if resolved {
+			var ok bool
+			parent, ok = value.(map[string]interface{})
+			if !ok {
+				e.addError(fmt.Errorf("expected an object, got %v", value), field, path)
+				return nil, !typ.NonNull
+			}
+		}
+		if def.IsAbstractType() {
+			def = e.concreteType(def, parent, path)
+			if def == nil {
+				e.addError(fmt.Errorf("no concrete type for %v", typ.NamedType), field, path)
+				return nil, !typ.NonNull
+			}
+		}
+		obj, ok := e.selectionSet(subselection, def, parent, path)
+		if !ok {
+			return nil, !typ.NonNull
+		}
+		return obj, true
+	default:
+		if resolved {
+			return value, true
+		}
+		return e.fake(def, field, path), true
+	}
+}
+
+// concreteType returns the concrete type of a value of abstract type def:
+// that given by its __typename, if it was resolved to an object with one,
+// or else a random possible type.
+func (e *execution) concreteType(def *ast.Definition, parent map[string]interface{}, path ast.Path) *ast.Definition {
+	possibleTypes := append([]*ast.Definition(nil), e.server.schema.GetPossibleTypes(def)...)
+	if typename, ok := parent["__typename"].(string); ok {
+		for _, possible := range possibleTypes {
+			if possible.Name == typename {
+				return possible
+			}
+		}
+	}
+	if len(possibleTypes) == 0 {
+		return nil
+	}
+	sort.Slice(possibleTypes, func(i, j int) bool { return possibleTypes[i].Name < possibleTypes[j].Name })
+	return possibleTypes[e.rand(appendPath(path, ast.PathName("__typename"))).Intn(len(possibleTypes))]
+}
+
+// fake returns a fake value of the scalar or enum type def, for the field at
+// the given path.
+func (e *execution) fake(def *ast.Definition, field *ast.Field, path ast.Path) interface{} {
+	r := e.rand(path)
+	if fake := e.server.scalars[def.Name]; fake != nil {
+		return fake(r)
+	}
+	if def.Kind == ast.Enum {
+		return def.EnumValues[r.Intn(len(def.EnumValues))].Name
+	}
+	switch def.Name {
+	case "Int":
+		return r.Intn(100)
+	case "Float":
+		return float64(r.Intn(10000)) / 100
+	case "Boolean":
+		return r.Intn(2) == 1
+	case "ID":
+		return strconv.Itoa(r.Intn(1000000))
+	default: // String (NewServer checked that custom scalars have a fake)
+		return field.Name + " " + strconv.Itoa(r.Intn(1000))
+	}
+}
+
+// rand returns a random source for the value at the given path, which
+// depends only on the seed, the event, and the path.
+func (e *execution) rand(path ast.Path) *rand.Rand {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d %d %v", e.server.seed, e.event, path)
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// addError adds an error in the given field to the response.
+func (e *execution) addError(err error, field *ast.Field, path ast.Path) {
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) {
+		copied := *gqlErr
+		gqlErr = &copied
+	} else {
+		gqlErr = &gqlerror.Error{Message: err.Error()}
+	}
+	if gqlErr.Path == nil {
+		gqlErr.Path = path
+	}
+	if gqlErr.Locations == nil && field.Position != nil {
+		gqlErr.Locations = []gqlerror.Location{{Line: field.Position.Line, Column: field.Position.Column}}
+	}
+	e.errors = append(e.errors, gqlErr)
+}
+
+// appendPath returns a copy of path with elem appended, so that sibling
+// paths don't share storage.
+func appendPath(path ast.Path, elem ast.PathElement) ast.Path {
+	return append(path[:len(path):len(path)], elem)
+}
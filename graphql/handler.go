@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Khan/genqlient/internal/wsconn"
+)
+
+// handlerEndpoint is the endpoint of a client returned by
+// [NewClientForHandler].  The host is arbitrary, since the client always
+// connects to the handler.
+const handlerEndpoint = "http://localhost/"
+
+// NewClientForHandler returns a [Client] which makes requests directly to
+// the given handler, in memory, with no network connection; this is useful
+// for testing a client against a server (such as a gqlgen handler) in the
+// same process.  The requests are made as POSTs to the path /.  To make them
+// to another path, or as GETs, use [NewHandlerDoer] with [NewClient] or
+// [NewClientUsingGet].
+//
+// The handler is served by an [http.Server], so it may stream its response
+// or hijack the connection as it would over the network.
+func NewClientForHandler(h http.Handler, opts ...ClientOption) Client {
+	return NewClient(handlerEndpoint, NewHandlerDoer(h), opts...)
+}
+
+// NewHandlerDoer returns a [Doer] which makes each request directly to the
+// given handler, in memory, whatever the host of its URL; see
+// [NewClientForHandler].
+func NewHandlerDoer(h http.Handler) Doer {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(context.Context, string, string) (net.Conn, error) {
+			return serveInMemory(h), nil
+		},
+		// Each connection is served by its own server, which exits when the
+		// connection closes, so we don't keep them around.
+		DisableKeepAlives: true,
+	}}
+}
+
+// NewHandlerDialer returns a [Dialer] which connects directly to the given
+// handler, in memory, whatever the host of the URL, so that a
+// [WebSocketClient] can subscribe to a server in the same process with no
+// network connection:
+//
+//	wsClient := graphql.NewClientUsingWebSocket(
+//		"ws://localhost/", graphql.NewHandlerDialer(h), nil)
+//
+// The handler must upgrade the connection as it would over the network, for
+// example using [github.com/gorilla/websocket].
+func NewHandlerDialer(h http.Handler) Dialer {
+	return handlerDialer{handler: h}
+}
+
+type handlerDialer struct {
+	handler http.Handler
+}
+
+func (d handlerDialer) DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (WSConn, error) {
+	conn := serveInMemory(d.handler)
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	wsConn, err := wsconn.Handshake(conn, urlStr, requestHeader)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return wsConn, nil
+}
+
+// serveInMemory returns one end of an in-memory connection, whose other end
+// is served by h.
+func serveInMemory(h http.Handler) net.Conn {
+	clientConn, serverConn := net.Pipe()
+	listener := &singleConnListener{conn: &notifyingConn{Conn: serverConn, closed: make(chan struct{})}}
+	go func() { _ = (&http.Server{Handler: h}).Serve(listener) }()
+	return clientConn
+}
+
+// singleConnListener is a [net.Listener] which accepts a single connection,
+// and then, once that connection closes, is itself closed.
+type singleConnListener struct {
+	conn     *notifyingConn
+	accepted bool
+}
+
+func (l *singleConnListener) Accept() (net.Conn, error) {
+	if !l.accepted {
+		l.accepted = true
+		return l.conn, nil
+	}
+	<-l.conn.closed
+	return nil, net.ErrClosed
+}
+
+func (l *singleConnListener) Close() error {
+	return l.conn.Close()
+}
+
+func (l *singleConnListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// notifyingConn is a [net.Conn] which closes a channel when it's closed.
+type notifyingConn struct {
+	net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *notifyingConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
@@ -0,0 +1,356 @@
+package graphql
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPCacheStore stores the responses cached by [WithHTTPCache].  The
+// values are opaque byte slices, so the store may be in memory (see
+// [NewMemoryHTTPCacheStore]), or shared, such as memcache or Redis.
+//
+// The interface matches that of [github.com/gregjones/httpcache], so its
+// stores may be used directly.
+//
+// [github.com/gregjones/httpcache]: https://pkg.go.dev/github.com/gregjones/httpcache#Cache
+type HTTPCacheStore interface {
+	// Get returns the value stored for the given key, and true, or false
+	// if there is none.
+	Get(key string) (value []byte, ok bool)
+	// Set stores the value for the given key.
+	Set(key string, value []byte)
+	// Delete removes the value for the given key, if any.
+	Delete(key string)
+}
+
+// defaultMemoryHTTPCacheEntries is the default size of the store returned by
+// NewMemoryHTTPCacheStore.
+const defaultMemoryHTTPCacheEntries = 1000
+
+// NewMemoryHTTPCacheStore returns an [HTTPCacheStore] which keeps up to
+// maxEntries responses in memory (or 1000, if maxEntries is 0 or less),
+// evicting the least recently used once it's full.
+func NewMemoryHTTPCacheStore(maxEntries int) HTTPCacheStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryHTTPCacheEntries
+	}
+	return &memoryHTTPCacheStore{
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+type memoryHTTPCacheStore struct {
+	// The entries, by key; each element's value is a memoryHTTPCacheEntry.
+	entries map[string]*list.Element
+	// The same elements, most recently used first.
+	order      *list.List
+	maxEntries int
+	mu         sync.Mutex
+}
+
+type memoryHTTPCacheEntry struct {
+	key   string
+	value []byte
+}
+
+func (s *memoryHTTPCacheStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*memoryHTTPCacheEntry).value, true
+}
+
+func (s *memoryHTTPCacheStore) Set(key string, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		elem.Value.(*memoryHTTPCacheEntry).value = value
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.entries[key] = s.order.PushFront(&memoryHTTPCacheEntry{key: key, value: value})
+	if s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryHTTPCacheEntry).key)
+	}
+}
+
+func (s *memoryHTTPCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+	}
+}
+
+// WithHTTPCache configures the client to cache the HTTP responses to its GET
+// requests in the given store, according to the standard HTTP caching
+// headers, as a browser would.  This is useful for public data served via a
+// CDN, which sets those headers for its own purposes.
+//
+// Specifically, the client caches successful responses which have a
+// Cache-Control max-age (or an Expires header), or a validator (ETag or
+// Last-Modified), unless Cache-Control says no-store.  While a response is
+// fresh, the client returns it without contacting the server.  Once it's
+// stale (or if Cache-Control says no-cache), the client makes a conditional
+// request, and if the server responds 304 Not Modified, returns the cached
+// response.  Responses are keyed by URL (which, for GET requests, includes
+// the query and variables), Accept header, and any Authorization header,
+// since that may make the response specific to the caller; and respect
+// Vary.  Other headers, such as those from [WithHeader] or added by the
+// [http.Client]'s Transport, don't affect the key: if they may affect the
+// response, the server should say so with Vary.
+//
+// This is separate from, and works below, the normalized cache of
+// [WithCache], and applies only to clients from [NewClientUsingGet]; it has
+// no effect on [NewClient].
+func WithHTTPCache(store HTTPCacheStore) ClientOption {
+	return func(c *client) { c.httpCacheStore = store }
+}
+
+// httpCache is a Doer which caches the responses of another.
+type httpCache struct {
+	wrapped Doer
+	store   HTTPCacheStore
+}
+
+// httpCacheEntry is a cached response, as stored (as JSON) in the store.
+type httpCacheEntry struct {
+	// The response's headers and body.
+	Header http.Header `json:"header"`
+	// The values of the request headers named in the response's Vary
+	// header, if any, which must match for the response to be used.
+	VaryHeader http.Header `json:"varyHeader,omitempty"`
+	// When we received (or last revalidated) the response.
+	Stored time.Time `json:"stored"`
+	Body   []byte    `json:"body"`
+}
+
+func (c *httpCache) Do(httpReq *http.Request) (*http.Response, error) {
+	if httpReq.Method != http.MethodGet {
+		return c.wrapped.Do(httpReq)
+	}
+
+	key := httpCacheKey(httpReq)
+	entry := c.load(key, httpReq)
+	now := time.Now()
+	if entry != nil && entry.fresh(now) {
+		return entry.response(httpReq), nil
+	}
+
+	if entry != nil {
+		etag := entry.Header.Get("ETag")
+		lastModified := entry.Header.Get("Last-Modified")
+		if etag == "" && lastModified == "" {
+			// There's no way to revalidate, so just make the request.
+			entry = nil
+		} else {
+			httpReq = httpReq.Clone(httpReq.Context())
+			if etag != "" {
+				httpReq.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				httpReq.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	httpResp, err := c.wrapped.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry != nil && httpResp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, httpResp.Body)
+		httpResp.Body.Close()
+		for name, values := range httpResp.Header {
+			entry.Header[name] = values
+		}
+		entry.Stored = now
+		c.save(key, entry)
+		return entry.response(httpReq), nil
+	}
+
+	if !cacheable(httpResp) {
+		if _, noStore := cacheControl(httpResp.Header)["no-store"]; noStore {
+			c.store.Delete(key)
+		}
+		return httpResp, nil
+	}
+
+	body, err := io.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	httpResp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry = &httpCacheEntry{Header: httpResp.Header.Clone(), Stored: now, Body: body}
+	for _, name := range varyNames(httpResp.Header) {
+		if entry.VaryHeader == nil {
+			entry.VaryHeader = http.Header{}
+		}
+		entry.VaryHeader[name] = httpReq.Header.Values(name)
+	}
+	c.save(key, entry)
+	return httpResp, nil
+}
+
+// httpCacheKey returns the key under which we cache the response to
+// httpReq; see WithHTTPCache.
+func httpCacheKey(httpReq *http.Request) string {
+	key := httpReq.Header.Get("Accept") + " " + httpReq.URL.String()
+	auth := httpReq.Header.Values("Authorization")
+	if len(auth) == 0 {
+		return key
+	}
+	// The store may be shared, so we hash the header rather than put
+	// credentials in the key.
+	sum := sha256.Sum256([]byte(strings.Join(auth, ",")))
+	return key + " " + hex.EncodeToString(sum[:])
+}
+
+// load returns the cached entry for the given key, if there is one and it
+// matches the request's Vary headers.
+func (c *httpCache) load(key string, httpReq *http.Request) *httpCacheEntry {
+	b, ok := c.store.Get(key)
+	if !ok {
+		return nil
+	}
+	var entry httpCacheEntry
+	if json.Unmarshal(b, &entry) != nil {
+		return nil
+	}
+	for name, values := range entry.VaryHeader {
+		if strings.Join(httpReq.Header.Values(name), ",") != strings.Join(values, ",") {
+			return nil
+		}
+	}
+	return &entry
+}
+
+func (c *httpCache) save(key string, entry *httpCacheEntry) {
+	b, err := json.Marshal(entry)
+	if err == nil {
+		c.store.Set(key, b)
+	}
+}
+
+// response returns the cached response, as a response to httpReq.
+func (entry *httpCacheEntry) response(httpReq *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       httpReq,
+	}
+}
+
+// fresh returns true if the response may be used without revalidation.
+func (entry *httpCacheEntry) fresh(now time.Time) bool {
+	directives := cacheControl(entry.Header)
+	if _, ok := directives["no-cache"]; ok {
+		return false
+	}
+
+	var lifetime time.Duration
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return false
+		}
+		lifetime = time.Duration(seconds) * time.Second
+	} else if expires := entry.Header.Get("Expires"); expires != "" {
+		expiresTime, err := http.ParseTime(expires)
+		if err != nil {
+			return false // per RFC 9111, an invalid Expires means expired
+		}
+		date, err := http.ParseTime(entry.Header.Get("Date"))
+		if err != nil {
+			date = entry.Stored
+		}
+		lifetime = expiresTime.Sub(date)
+	}
+
+	age := now.Sub(entry.Stored)
+	if ageSeconds, err := strconv.Atoi(entry.Header.Get("Age")); err == nil {
+		age += time.Duration(ageSeconds) * time.Second
+	}
+	return age < lifetime
+}
+
+// cacheable returns true if the client may store the response to a GET
+// request.
+func cacheable(httpResp *http.Response) bool {
+	if httpResp.StatusCode != http.StatusOK {
+		return false
+	}
+	directives := cacheControl(httpResp.Header)
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+	for _, name := range varyNames(httpResp.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+	// We don't cache incremental responses, which may be long-lived.
+	mediaType, _, _ := mime.ParseMediaType(httpResp.Header.Get("Content-Type"))
+	if mediaType == "multipart/mixed" {
+		return false
+	}
+	_, hasMaxAge := directives["max-age"]
+	return hasMaxAge || httpResp.Header.Get("Expires") != "" ||
+		httpResp.Header.Get("ETag") != "" || httpResp.Header.Get("Last-Modified") != ""
+}
+
+// cacheControl returns the directives of the Cache-Control header, mapped
+// to their values (or "" if they have none).
+func cacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// varyNames returns the canonicalized header names in the Vary header.
+func varyNames(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,336 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// acceptIncremental is the Accept header sent with requests which use @defer
+// or @stream, to ask the server for an incremental response.
+const acceptIncremental = "multipart/mixed; deferSpec=20220824, " + acceptGraphQLResponse
+
+// isIncremental returns true if the given request may get an incremental
+// response, i.e. it uses @defer or @stream.
+func isIncremental(req *Request) bool {
+	return strings.Contains(req.Query, "@defer") || strings.Contains(req.Query, "@stream")
+}
+
+type incrementalHandlerKey struct{}
+
+// WithIncrementalHandler returns a context which, when passed to a genqlient
+// function (or [Client.MakeRequest]) for a query using @defer or @stream,
+// calls f each time more of the response arrives.
+//
+// f is passed the response as it stands, with all the parts received so far
+// merged into resp.Data, which has the same type as genqlient's return value
+// (e.g. *MyQueryResponse).  In the generated types, the Received field for
+// each deferred fragment says whether that fragment has arrived yet.  f is
+// called synchronously, and must not retain resp (or its Data) after it
+// returns; the genqlient function returns the complete response once the
+// server is done.  If the server doesn't support incremental delivery, f is
+// called just once, with the complete response.
+//
+// Incremental responses are supported only by the clients returned by
+// [NewClient] and [NewClientUsingGet].
+func WithIncrementalHandler(ctx context.Context, f func(resp *Response)) context.Context {
+	return context.WithValue(ctx, incrementalHandlerKey{}, f)
+}
+
+func incrementalHandler(ctx context.Context) func(resp *Response) {
+	if ctx == nil {
+		return nil
+	}
+	f, _ := ctx.Value(incrementalHandlerKey{}).(func(resp *Response))
+	return f
+}
+
+// incrementalPayload is a single part of an incremental response.
+//
+// We support both the format of the 2022 RFC (in which each subsequent part
+// has an "incremental" list of results) and that of earlier drafts (in which
+// each subsequent part is itself a single result, as sent by gqlgen).
+type incrementalPayload struct {
+	HasNext     *bool               `json:"hasNext"`
+	Incremental []incrementalResult `json:"incremental"`
+	incrementalResult
+}
+
+// incrementalResult is a single deferred fragment or batch of streamed list
+// items.
+type incrementalResult struct {
+	Extensions map[string]interface{} `json:"extensions"`
+	Label      string                 `json:"label"`
+	Data       json.RawMessage        `json:"data"`
+	Items      []json.RawMessage      `json:"items"`
+	Path       []json.RawMessage      `json:"path"`
+	Errors     gqlerror.List          `json:"errors"`
+}
+
+// deferredMarkerPrefix is the prefix of the alias of the marker field which
+// genqlient adds to each deferred fragment, to populate its Received field.
+// It must match the prefix in the generate package.
+const deferredMarkerPrefix = "genqlient_deferred_"
+
+// readIncrementalResponse reads a multipart/mixed incremental response from
+// body, merging each part into resp as it arrives.
+func readIncrementalResponse(ctx context.Context, body io.Reader, boundary string, resp *Response) error {
+	handler := incrementalHandler(ctx)
+	data := resp.Data
+	var tree interface{}
+	// Some servers send the markers of deferred fragments (and sometimes
+	// other parts of them) early; so we consider a fragment received only
+	// once its labeled payload arrives, and remove the markers from the
+	// payloads.  But if the server sends a fragment inline (e.g. for
+	// @defer(if: false)), no labeled payload ever arrives; so once the
+	// response is complete, we restore the markers we removed.
+	var markers [][]json.RawMessage
+
+	reader := multipart.NewReader(body, boundary)
+	for first := true; ; first = false {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		var payload incrementalPayload
+		err = json.NewDecoder(part).Decode(&payload)
+		if err != nil {
+			return err
+		}
+
+		results := payload.Incremental
+		if first || payload.Path != nil || payload.Items != nil {
+			results = append(results, payload.incrementalResult)
+		}
+		for _, result := range results {
+			if first {
+				tree, err = decodeTree(result.Data)
+				markers = append(markers, removeDeferredMarkers(tree, nil)...)
+			} else {
+				var removed [][]json.RawMessage
+				tree, removed, err = mergeResult(tree, &result)
+				markers = append(markers, removed...)
+			}
+			if err != nil {
+				return err
+			}
+			resp.Errors = append(resp.Errors, result.Errors...)
+			for k, v := range result.Extensions {
+				if resp.Extensions == nil {
+					resp.Extensions = make(map[string]interface{})
+				}
+				resp.Extensions[k] = v
+			}
+		}
+
+		last := payload.HasNext == nil || !*payload.HasNext
+		if last && len(markers) > 0 {
+			tree = restoreDeferredMarkers(tree, markers)
+		}
+		if len(results) > 0 || (last && len(markers) > 0) {
+			err = refillData(resp, data, tree)
+			if err != nil {
+				return err
+			}
+			if handler != nil {
+				handler(resp)
+			}
+		}
+		if last {
+			break
+		}
+	}
+
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
+// decodeTree decodes the given JSON into a tree of maps and slices, taking
+// care to preserve numbers exactly.
+func decodeTree(data json.RawMessage) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&tree)
+	return tree, err
+}
+
+// refillData replaces resp.Data with a zeroed data (its original value)
+// populated from the given tree.
+func refillData(resp *Response, data, tree interface{}) error {
+	resetResponseData(resp, data)
+	if tree == nil {
+		return nil
+	}
+	b, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, &resp.Data)
+}
+
+// mergeResult merges an incremental result into the data received so far.
+// It returns the updated tree, and the paths of any deferred-fragment
+// markers it removed from the result (see removeDeferredMarkers).
+func mergeResult(tree interface{}, result *incrementalResult) (interface{}, [][]json.RawMessage, error) {
+	path := result.Path
+	if result.Items != nil {
+		// The path is that of the first new item; the 2023 RFC uses the path
+		// of the list itself, in which case we append.
+		start := -1
+		if len(path) > 0 {
+			if i, err := strconv.Atoi(string(path[len(path)-1])); err == nil {
+				start = i
+				path = path[:len(path)-1]
+			}
+		}
+		items := make([]interface{}, len(result.Items))
+		for i, item := range result.Items {
+			var err error
+			items[i], err = decodeTree(item)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		updated, err := updateAt(tree, path, func(node interface{}) (interface{}, error) {
+			list, _ := node.([]interface{})
+			if start < 0 || start > len(list) {
+				start = len(list)
+			}
+			for len(list) < start+len(items) {
+				list = append(list, nil)
+			}
+			copy(list[start:], items)
+			return list, nil
+		})
+		return updated, nil, err
+	}
+
+	data, err := decodeTree(result.Data)
+	if err != nil || data == nil {
+		return tree, nil, err
+	}
+	removed := removeDeferredMarkers(data, path)
+	tree, err = updateAt(tree, path, func(node interface{}) (interface{}, error) {
+		node = deepMerge(node, data)
+		if obj, ok := node.(map[string]interface{}); ok && result.Label != "" {
+			obj[deferredMarkerPrefix+result.Label] = true
+		}
+		return node, nil
+	})
+	return tree, removed, err
+}
+
+// removeDeferredMarkers removes all the deferred-fragment markers from tree,
+// which is at the given path in the response, and returns their paths
+// (ending with the marker's key).
+func removeDeferredMarkers(tree interface{}, path []json.RawMessage) [][]json.RawMessage {
+	var removed [][]json.RawMessage
+	switch node := tree.(type) {
+	case map[string]interface{}:
+		for k, v := range node {
+			key, err := json.Marshal(k)
+			if err != nil {
+				continue
+			}
+			childPath := append(append([]json.RawMessage{}, path...), key)
+			if strings.HasPrefix(k, deferredMarkerPrefix) {
+				delete(node, k)
+				removed = append(removed, childPath)
+			} else {
+				removed = append(removed, removeDeferredMarkers(v, childPath)...)
+			}
+		}
+	case []interface{}:
+		for i, v := range node {
+			childPath := append(append([]json.RawMessage{}, path...), json.RawMessage(strconv.Itoa(i)))
+			removed = append(removed, removeDeferredMarkers(v, childPath)...)
+		}
+	}
+	return removed
+}
+
+// restoreDeferredMarkers sets each of the markers at the given paths (as
+// returned by removeDeferredMarkers) which isn't already set, and returns
+// the updated tree.
+func restoreDeferredMarkers(tree interface{}, markers [][]json.RawMessage) interface{} {
+	for _, marker := range markers {
+		var key string
+		if json.Unmarshal(marker[len(marker)-1], &key) != nil {
+			continue
+		}
+		updated, err := updateAt(tree, marker[:len(marker)-1], func(node interface{}) (interface{}, error) {
+			if obj, ok := node.(map[string]interface{}); ok && obj[key] == nil {
+				obj[key] = true
+			}
+			return node, nil
+		})
+		if err == nil {
+			tree = updated
+		}
+	}
+	return tree
+}
+
+// updateAt replaces the value at the given path in tree with the result of
+// f, and returns the updated tree.
+func updateAt(
+	tree interface{},
+	path []json.RawMessage,
+	f func(node interface{}) (interface{}, error),
+) (interface{}, error) {
+	if len(path) == 0 {
+		return f(tree)
+	}
+
+	var key string
+	if json.Unmarshal(path[0], &key) == nil {
+		obj, ok := tree.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid path in incremental response: %s is not an object", key)
+		}
+		child, err := updateAt(obj[key], path[1:], f)
+		obj[key] = child
+		return obj, err
+	}
+
+	index, err := strconv.Atoi(string(path[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid path in incremental response: %s", path[0])
+	}
+	list, ok := tree.([]interface{})
+	if !ok || index < 0 || index >= len(list) {
+		return nil, fmt.Errorf("invalid path in incremental response: no index %d", index)
+	}
+	list[index], err = updateAt(list[index], path[1:], f)
+	return list, err
+}
+
+// deepMerge merges src into dst, returning the result.  Objects are merged
+// key by key; anything else in src replaces its value in dst.
+func deepMerge(dst, src interface{}) interface{} {
+	dstObj, ok := dst.(map[string]interface{})
+	srcObj, ok2 := src.(map[string]interface{})
+	if !ok || !ok2 {
+		return src
+	}
+	for k, v := range srcObj {
+		dstObj[k] = deepMerge(dstObj[k], v)
+	}
+	return dstObj
+}
@@ -0,0 +1,55 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ResponseMetadata describes the HTTP response to a request; see
+// [WithResponseMetadata].
+type ResponseMetadata struct {
+	// The headers of the response.
+	Header http.Header
+	// When the client sent the request.
+	StartTime time.Time
+	// How long the server took to respond, from sending the request until
+	// receiving the response headers.
+	Duration time.Duration
+	// The HTTP status of the response, or 0 if there was no response (for
+	// example if there was a network error).
+	StatusCode int
+}
+
+type responseMetadataKey struct{}
+
+// WithResponseMetadata returns a context which, when passed to a genqlient
+// function (or [Client.MakeRequest]), causes the client to fill in md with
+// information about the HTTP response, such as its headers.  This is useful
+// for reading headers the server uses for rate-limiting or request IDs:
+//
+//	var md graphql.ResponseMetadata
+//	resp, err := getUser(graphql.WithResponseMetadata(ctx, &md), client, id)
+//	if err != nil {
+//		return fmt.Errorf("request %v failed: %w", md.Header.Get("X-Request-Id"), err)
+//	}
+//
+// md is filled in even if the request returns an error, as long as the
+// client sent it.  If the client makes several HTTP requests for one call,
+// for example due to [WithRetry] or [WithAutomaticPersistedQueries], md
+// describes the last.  Each context should be used for one request at a
+// time.
+//
+// Response metadata is supported only by the clients returned by
+// [NewClient] and [NewClientUsingGet].
+func WithResponseMetadata(ctx context.Context, md *ResponseMetadata) context.Context {
+	return context.WithValue(ctx, responseMetadataKey{}, md)
+}
+
+func responseMetadata(ctx context.Context) *ResponseMetadata {
+	if ctx == nil {
+		return nil
+	}
+	md, _ := ctx.Value(responseMetadataKey{}).(*ResponseMetadata)
+	return md
+}
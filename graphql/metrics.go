@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MetricsRecorder records metrics about the requests a client makes; see
+// [WithMetrics] and [WithWebSocketMetrics].  The package
+// [github.com/Khan/genqlient/graphql/expvarmetrics] provides an
+// implementation which publishes them via [expvar]; to use another metrics
+// library, implement this interface.
+//
+// The client calls the recorder synchronously, so its methods should be
+// fast, and must not make requests with the client.  They may be called
+// concurrently.
+type MetricsRecorder interface {
+	// RecordRequest is called after each query or mutation completes.
+	// ctx is the context passed to MakeRequest, which may be used to find
+	// additional labels.
+	RecordRequest(ctx context.Context, metrics *RequestMetrics)
+	// RecordActiveSubscriptions is called by a WebSocket client with the
+	// number of its active subscriptions, whenever it changes.
+	RecordActiveSubscriptions(n int)
+	// RecordReconnect is called by a WebSocket client after each attempt to
+	// reconnect (see [WithReconnect]), with the error if the attempt
+	// failed.
+	RecordReconnect(err error)
+}
+
+// RequestMetrics describes a single query or mutation; see
+// [MetricsRecorder].
+type RequestMetrics struct {
+	// The error returned by MakeRequest, or nil if it succeeded.
+	Err error
+	// The name of the operation.
+	OpName string
+	// The code (in extensions.code) of each GraphQL error in the response
+	// which has one.
+	ErrorCodes []string
+	// How long MakeRequest took.
+	Duration time.Duration
+	// The size of the request, in bytes: the body of a POST, the query
+	// string of a GET, or the message sent over the WebSocket.  Files
+	// uploaded with [Upload] are not included.  If the client sends
+	// several HTTP requests, for example for [WithAutomaticPersistedQueries],
+	// this is their total.
+	RequestSize int64
+	// The size of the response, in bytes, similarly.
+	ResponseSize int64
+}
+
+type requestMetricsKey struct{}
+
+// WithMetrics configures the client to record metrics about each request it
+// makes with the given recorder.  For a client configured [WithBatching],
+// the sizes are those of the request's own part of the batch.
+func WithMetrics(recorder MetricsRecorder) ClientOption {
+	return func(c *client) { c.metrics = recorder }
+}
+
+// WithWebSocketMetrics configures the WebSocket client to record metrics
+// with the given recorder: the number of active subscriptions, each attempt
+// to reconnect, and each query or mutation made over the WebSocket.
+func WithWebSocketMetrics(recorder MetricsRecorder) WebSocketOption {
+	return func(w *webSocketClient) {
+		w.metrics = recorder
+		w.subscriptions.onActiveChange = recorder.RecordActiveSubscriptions
+	}
+}
+
+// withRequestMetrics returns a context which causes the client to add the
+// sizes of its requests and responses to m.
+func withRequestMetrics(ctx context.Context, m *RequestMetrics) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, requestMetricsKey{}, m)
+}
+
+func requestMetrics(ctx context.Context) *RequestMetrics {
+	if ctx == nil {
+		return nil
+	}
+	m, _ := ctx.Value(requestMetricsKey{}).(*RequestMetrics)
+	return m
+}
+
+// recordRequest fills in the rest of m, for a request which started at
+// start and returned err, and records it.
+func recordRequest(ctx context.Context, recorder MetricsRecorder, m *RequestMetrics, start time.Time, err error) {
+	m.Duration = time.Since(start)
+	m.Err = err
+	for _, gqlErr := range graphQLErrors(err) {
+		if code, ok := gqlErr.Extensions["code"].(string); ok {
+			m.ErrorCodes = append(m.ErrorCodes, code)
+		}
+	}
+	recorder.RecordRequest(ctx, m)
+}
+
+// recordHTTPRequest adds the size of httpReq to m, and arranges for the size
+// of httpResp to be added as its body is read.
+func (m *RequestMetrics) recordHTTPRequest(httpReq *http.Request, httpResp *http.Response) {
+	if httpReq.ContentLength > 0 {
+		m.RequestSize += httpReq.ContentLength
+	}
+	m.RequestSize += int64(len(httpReq.URL.RawQuery))
+	httpResp.Body = &countingReadCloser{ReadCloser: httpResp.Body, n: &m.ResponseSize}
+}
+
+// recordBatchedRequest adds the size of the given request body, and of its
+// raw response, to m, for a request sent as part of a batch.
+func (m *RequestMetrics) recordBatchedRequest(body, data json.RawMessage) {
+	m.RequestSize += int64(len(body))
+	m.ResponseSize += int64(len(data))
+}
+
+// countingReadCloser counts the bytes read from the wrapped ReadCloser.
+type countingReadCloser struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (r *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	*r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,89 @@
+package graphql
+
+import (
+	"context"
+)
+
+// MakeRequestFunc is a function with the signature of [Client.MakeRequest].
+// It's used to write [Middleware].
+type MakeRequestFunc func(ctx context.Context, req *Request, resp *Response) error
+
+// Middleware wraps a [MakeRequestFunc] to add behavior before or after each
+// request, e.g. logging, authentication, metrics, or error mapping.
+//
+// A middleware should typically call next exactly once, and return its error
+// (or a replacement for it).  It has access to the full request (including
+// the operation name and variables) and the decoded response.  For example,
+// a middleware which logs failed operations:
+//
+//	func logErrors(next graphql.MakeRequestFunc) graphql.MakeRequestFunc {
+//		return func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+//			err := next(ctx, req, resp)
+//			if err != nil {
+//				log.Printf("%s failed: %v", req.OpName, err)
+//			}
+//			return err
+//		}
+//	}
+type Middleware func(next MakeRequestFunc) MakeRequestFunc
+
+// chain applies the given middleware to f, such that the first middleware is
+// the outermost, i.e. it sees the request first and the response last.
+func chain(f MakeRequestFunc, middleware []Middleware) MakeRequestFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		f = middleware[i](f)
+	}
+	return f
+}
+
+type middlewareClient struct {
+	makeRequest MakeRequestFunc
+}
+
+func (c *middlewareClient) MakeRequest(ctx context.Context, req *Request, resp *Response) error {
+	return c.makeRequest(ctx, req, resp)
+}
+
+// WithMiddleware returns a [Client] which passes each request through the
+// given middleware before making it with client.
+//
+// The middleware are applied in order, such that the first middleware is the
+// outermost: it sees the request first, and the response last.
+func WithMiddleware(client Client, middleware ...Middleware) Client {
+	return &middlewareClient{makeRequest: chain(client.MakeRequest, middleware)}
+}
+
+type middlewareWebSocketClient struct {
+	WebSocketClient
+	middleware []Middleware
+}
+
+// WithWebSocketMiddleware returns a [WebSocketClient] which passes each
+// subscription request through the given middleware before subscribing with
+// client.  The middleware are applied in the same order as by
+// [WithMiddleware], so the same middleware may be used for both.
+//
+// Because [WebSocketClient.Subscribe] returns as soon as the subscription is
+// sent, the middleware sees each call to Subscribe, not each message which
+// arrives on the subscription.  In particular, it's passed
+// context.Background() as its context (Subscribe takes none), and an empty
+// *Response, which is discarded: the subscription's data arrives later, on
+// its channel.
+func WithWebSocketMiddleware(client WebSocketClient, middleware ...Middleware) WebSocketClient {
+	return &middlewareWebSocketClient{WebSocketClient: client, middleware: middleware}
+}
+
+func (c *middlewareWebSocketClient) Subscribe(
+	req *Request,
+	interfaceChan interface{},
+	forwardDataFunc ForwardDataFunction,
+) (string, error) {
+	var subscriptionID string
+	subscribe := func(_ context.Context, req *Request, _ *Response) error {
+		var err error
+		subscriptionID, err = c.WebSocketClient.Subscribe(req, interfaceChan, forwardDataFunc)
+		return err
+	}
+	err := chain(subscribe, c.middleware)(context.Background(), req, &Response{})
+	return subscriptionID, err
+}
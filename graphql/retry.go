@@ -0,0 +1,302 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Backoff configures how long a retrying client waits between attempts.
+//
+// The wait before the nth retry is InitialInterval * Multiplier^(n-1), capped
+// at MaxInterval, and then randomized by up to ±Jitter (as a fraction of the
+// wait).  Zero fields are replaced by their defaults.
+type Backoff struct {
+	// The wait before the first retry.  Defaults to 100ms.
+	InitialInterval time.Duration
+	// The longest wait between two attempts.  Defaults to 10s.
+	MaxInterval time.Duration
+	// The factor by which the wait increases after each retry.  Defaults
+	// to 2.
+	Multiplier float64
+	// The fraction by which each wait is randomized, between 0 and 1.
+	// Defaults to 0.5, i.e. waits vary between 50% and 150% of the nominal
+	// value.  To disable jitter, set a negative value.
+	Jitter float64
+}
+
+const (
+	defaultInitialInterval = 100 * time.Millisecond
+	defaultMaxInterval     = 10 * time.Second
+	defaultMultiplier      = 2
+	defaultJitter          = 0.5
+	defaultMaxAttempts     = 3
+)
+
+// withDefaults returns a copy of b with zero fields set to their defaults.
+func (b Backoff) withDefaults() Backoff {
+	if b.InitialInterval <= 0 {
+		b.InitialInterval = defaultInitialInterval
+	}
+	if b.MaxInterval <= 0 {
+		b.MaxInterval = defaultMaxInterval
+	}
+	if b.Multiplier <= 0 {
+		b.Multiplier = defaultMultiplier
+	}
+	if b.Jitter == 0 {
+		b.Jitter = defaultJitter
+	} else if b.Jitter < 0 {
+		b.Jitter = 0
+	}
+	return b
+}
+
+// interval returns how long to wait before the given retry (1 for the first
+// retry, and so on).  b must already have its defaults set.
+func (b Backoff) interval(retry int) time.Duration {
+	wait := float64(b.InitialInterval)
+	for i := 1; i < retry && wait < float64(b.MaxInterval); i++ {
+		wait *= b.Multiplier
+	}
+	if wait > float64(b.MaxInterval) {
+		wait = float64(b.MaxInterval)
+	}
+	if b.Jitter > 0 {
+		wait *= 1 + b.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(wait)
+}
+
+// RetryPolicy configures which requests a client returned by [WithRetry]
+// retries, and how.
+//
+// By default, queries are retried on transient network errors (timeouts,
+// and connections refused, reset, or closed early) and on HTTP statuses
+// 429, 502, 503, and 504.  Mutations are retried only if RetryMutations is
+// set, since they may not be idempotent.
+type RetryPolicy struct {
+	// HTTP status codes which should be retried.  Defaults to 429, 502, 503,
+	// and 504.
+	RetryStatusCodes []int
+	// GraphQL error codes (the "code" field of the error's extensions) which
+	// should be retried, for example "SERVICE_UNAVAILABLE".  By default,
+	// responses with GraphQL errors are not retried.
+	RetryErrorCodes []string
+	// How long to wait between attempts.
+	Backoff Backoff
+	// The maximum number of attempts to make, including the first.  Defaults
+	// to 3.
+	MaxAttempts int
+	// If set, retry mutations as well as queries.  Only set this if your
+	// mutations are safe to repeat.
+	RetryMutations bool
+}
+
+var defaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type retryClient struct {
+	client Client
+	policy RetryPolicy
+}
+
+// WithRetry returns a [Client] which makes requests with client, retrying
+// those which fail with a transient error according to policy.  A nil policy
+// uses the defaults described in [RetryPolicy].
+//
+// Between attempts, the client waits according to policy.Backoff, or, if the
+// server responded 429 or 503 with a Retry-After header, for the time the
+// server requested.  It stops early, returning the last error, if ctx is
+// done, or if the server asks it to wait longer than
+// policy.Backoff.MaxInterval or past ctx's deadline.  Only errors returned by
+// the clients from [NewClient] and [NewClientUsingGet] can be classified by
+// HTTP status; for other clients only network errors and GraphQL error codes
+// are retried.
+func WithRetry(client Client, policy *RetryPolicy) Client {
+	var p RetryPolicy
+	if policy != nil {
+		p = *policy
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if p.RetryStatusCodes == nil {
+		p.RetryStatusCodes = defaultRetryStatusCodes
+	}
+	p.Backoff = p.Backoff.withDefaults()
+	return &retryClient{client: client, policy: p}
+}
+
+func (c *retryClient) MakeRequest(ctx context.Context, req *Request, resp *Response) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	maxAttempts := c.policy.MaxAttempts
+	if !c.policy.RetryMutations && req.OperationType() == ast.Mutation {
+		maxAttempts = 1
+	}
+
+	// The response may be partially populated by a failed attempt, so we
+	// reset it before each retry.
+	data := resp.Data
+	for attempt := 1; ; attempt++ {
+		err := c.client.MakeRequest(ctx, req, resp)
+		if err == nil || attempt >= maxAttempts {
+			return err
+		}
+		if attempt == 1 && hasUploads(req.Variables) {
+			// The files can only be read once.  (We check only now, since
+			// finding them walks the variables, and most requests succeed.)
+			return err
+		}
+		wait, ok := c.retryAfter(ctx, err, resp)
+		if !ok {
+			return err
+		}
+		if wait <= 0 {
+			wait = c.policy.Backoff.interval(attempt)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		resetResponse(resp, data)
+	}
+}
+
+// retryAfter returns whether the request which returned err and resp should
+// be retried, and if so how long the server asked us to wait (or 0 if it
+// didn't say).
+func (c *retryClient) retryAfter(ctx context.Context, err error, resp *Response) (time.Duration, bool) {
+	if ctx.Err() != nil {
+		return 0, false
+	}
+
+	var statusErr *HTTPError
+	if errors.As(err, &statusErr) {
+		for _, code := range c.policy.RetryStatusCodes {
+			if code == statusErr.StatusCode {
+				switch code {
+				case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+					return c.retryAfterHeader(ctx, statusErr.Header.Get("Retry-After"))
+				default:
+					return 0, true
+				}
+			}
+		}
+		return 0, false
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return 0, temporaryNetworkError(urlErr)
+	}
+
+	var errList gqlerror.List
+	if errors.As(err, &errList) && len(c.policy.RetryErrorCodes) > 0 {
+		for _, gqlErr := range resp.Errors {
+			code, _ := gqlErr.Extensions["code"].(string)
+			for _, retryCode := range c.policy.RetryErrorCodes {
+				if code == retryCode {
+					return 0, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// retryAfterHeader returns how long to wait per the given Retry-After
+// header, and whether to retry at all: if the server asks us to wait longer
+// than c.policy.Backoff.MaxInterval, or past ctx's deadline, we give up
+// rather than retrying sooner than it asked.
+func (c *retryClient) retryAfterHeader(ctx context.Context, header string) (time.Duration, bool) {
+	wait := parseRetryAfter(header)
+	if wait > c.policy.Backoff.MaxInterval {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && wait > time.Until(deadline) {
+		return 0, false
+	}
+	return wait, true
+}
+
+// temporaryNetworkError returns true if err, from making an HTTP request, is
+// one which may not recur: a timeout, a failure to connect (other than to
+// look up the host), or a connection reset or closed before the response
+// arrived.  Others, such as an invalid URL or TLS certificate, would just
+// fail again.
+func temporaryNetworkError(err *url.Error) bool {
+	if err.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	// We don't check for ECONNREFUSED and ECONNRESET themselves, which not
+	// every platform has; a refused connection fails to dial, and a reset one
+	// fails to read or write.
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		switch opErr.Op {
+		case "dial", "read", "write":
+			return true
+		}
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which may be
+// either a number of seconds or an HTTP date.  It returns 0 if the header is
+// absent or invalid.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// resetResponse prepares resp to be reused for another attempt: it clears
+// the errors and extensions, and restores data (the original value of
+// resp.Data), zeroing what it points to.
+func resetResponse(resp *Response, data interface{}) {
+	resp.Errors = nil
+	resp.Extensions = nil
+	resetResponseData(resp, data)
+}
+
+// resetResponseData restores resp.Data to data, zeroing what it points to.
+func resetResponseData(resp *Response, data interface{}) {
+	resp.Data = data
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+}
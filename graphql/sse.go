@@ -0,0 +1,481 @@
+package graphql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	sseTokenHeader   = "X-GraphQL-Event-Stream-Token"
+	sseEventNext     = "next"
+	sseEventComplete = "complete"
+)
+
+// SSEOption configures optional behavior of the [WebSocketClient] returned by
+// [NewClientUsingSSE].
+type SSEOption func(*sseClient)
+
+// WithSSESingleConnection configures the client to use the "single connection
+// mode" of the graphql-sse protocol, in which all subscriptions share a
+// single event stream, reserved and opened by [WebSocketClient.Start].  This
+// is useful where the number of concurrent connections is limited, as it is
+// for HTTP/1 in browsers, but requires a server which supports it.
+func WithSSESingleConnection() SSEOption {
+	return func(c *sseClient) { c.singleConnection = true }
+}
+
+// NewClientUsingSSE returns a [WebSocketClient] which makes subscription
+// requests to the given endpoint using server-sent events, per the
+// [graphql-sse protocol], for use where WebSockets are unavailable.  Despite
+// the name of the interface, no WebSocket is involved; genqlient's generated
+// subscription functions may use the client as they would any other.
+//
+// By default, the client uses the protocol's "distinct connections mode":
+// each subscription is a POST request whose response is an event stream, and
+// unsubscribing cancels that request.  To share one stream among all
+// subscriptions, pass [WithSSESingleConnection].  The client will use the
+// given [http.Client], or [http.DefaultClient] if a nil client is passed; as
+// with [NewClient], add authentication headers by wrapping its transport.
+//
+// All the client's requests use the context passed to Start, which must not
+// be canceled until the client is closed.  The client does not support
+// queries nor mutations, and will return an error if passed a request that
+// attempts one.
+//
+// [graphql-sse protocol]: https://github.com/enisdenjo/graphql-sse/blob/master/PROTOCOL.md
+func NewClientUsingSSE(endpoint string, httpClient Doer, opts ...SSEOption) WebSocketClient {
+	if httpClient == nil || httpClient == (*http.Client)(nil) {
+		httpClient = http.DefaultClient
+	}
+	c := &sseClient{
+		httpClient:    httpClient,
+		endpoint:      endpoint,
+		errChan:       make(chan error),
+		cancels:       make(map[string]context.CancelFunc),
+		subscriptions: subscriptionMap{map_: make(map[string]subscription)},
+	}
+	c.subscriptions.onForwardError = c.reportError
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type sseClient struct {
+	httpClient Doer
+	// The context passed to Start, and its cancel function.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	errChan chan error
+	// In distinct connections mode, the function to cancel each
+	// subscription's request, by subscription ID, until its stream ends.
+	cancels  map[string]context.CancelFunc
+	endpoint string
+	// In single connection mode, the token identifying our event stream.
+	token         string
+	subscriptions subscriptionMap
+	// Tracks the goroutines reading event streams, and those reporting
+	// errors, which must finish before we close errChan.
+	readers sync.WaitGroup
+	sync.Mutex
+	singleConnection bool
+	isClosing        bool
+}
+
+func (c *sseClient) Start(ctx context.Context) (errChan chan error, err error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.ctx != nil {
+		return nil, errors.New("client was already started")
+	}
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	if c.singleConnection {
+		err = c.connect()
+		if err != nil {
+			c.cancel()
+			return nil, err
+		}
+	}
+	return c.errChan, nil
+}
+
+// connect reserves an event stream, and starts reading it, in single
+// connection mode.
+func (c *sseClient) connect() error {
+	httpReq, err := http.NewRequestWithContext(c.ctx, http.MethodPut, c.endpoint, http.NoBody)
+	if err != nil {
+		return err
+	}
+	httpResp, err := c.do(httpReq)
+	if err != nil {
+		return err
+	}
+	token, err := io.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if err != nil {
+		return err
+	}
+	c.token = string(token)
+
+	httpReq, err = http.NewRequestWithContext(c.ctx, http.MethodGet, c.endpoint, http.NoBody)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpResp, err = c.do(httpReq)
+	if err != nil {
+		return err
+	}
+	err = checkEventStream(httpResp)
+	if err != nil {
+		return err
+	}
+	c.readers.Add(1)
+	go c.readEvents(c.ctx, httpResp.Body, "")
+	return nil
+}
+
+// do makes the given request, returning an error if it doesn't succeed.  The
+// caller must close the response body.
+func (c *sseClient) do(httpReq *http.Request) (*http.Response, error) {
+	if c.token != "" {
+		httpReq.Header.Set(sseTokenHeader, c.token)
+	}
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		defer httpResp.Body.Close()
+		var respBody []byte
+		respBody, err = io.ReadAll(httpResp.Body)
+		if err != nil {
+			respBody = []byte(fmt.Sprintf("<unreadable: %v>", err))
+		}
+		return nil, &HTTPError{
+			Header:     httpResp.Header,
+			Body:       respBody,
+			StatusCode: httpResp.StatusCode,
+		}
+	}
+	return httpResp, nil
+}
+
+// checkEventStream returns an error, and closes the body, if the response is
+// not an event stream.
+func checkEventStream(httpResp *http.Response) error {
+	mediaType, _, _ := mime.ParseMediaType(httpResp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return nil
+	}
+	defer httpResp.Body.Close()
+	respBody, _ := io.ReadAll(httpResp.Body)
+	return fmt.Errorf("expected an event stream, got %s: %s",
+		httpResp.Header.Get("Content-Type"), respBody)
+}
+
+func (c *sseClient) Close() error {
+	c.Lock()
+	if c.ctx == nil || c.isClosing {
+		c.Unlock()
+		return nil
+	}
+	c.isClosing = true
+	c.Unlock()
+
+	err := c.UnsubscribeAll()
+	c.cancel()
+	c.readers.Wait()
+	close(c.errChan)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return nil
+}
+
+func (c *sseClient) Subscribe(req *Request, interfaceChan interface{}, forwardDataFunc ForwardDataFunction) (string, error) {
+	err := checkSubscriptionRequest(req)
+	if err != nil {
+		return "", err
+	}
+	c.Lock()
+	ctx := c.ctx
+	c.Unlock()
+	if ctx == nil {
+		return "", errors.New("client was not started")
+	}
+
+	subscriptionID := uuid.NewString()
+	// We must create the subscription first, since in single connection mode
+	// its events may arrive before the request returns.
+	c.subscriptions.Create(subscriptionID, req, interfaceChan, forwardDataFunc)
+	if c.singleConnection {
+		err = c.subscribeSingleConnection(ctx, subscriptionID, req)
+	} else {
+		err = c.subscribeDistinctConnection(ctx, subscriptionID, req)
+	}
+	if err != nil {
+		c.subscriptions.Delete(subscriptionID)
+		return "", err
+	}
+	return subscriptionID, nil
+}
+
+// subscribeSingleConnection starts the given operation on our event stream.
+func (c *sseClient) subscribeSingleConnection(ctx context.Context, subscriptionID string, req *Request) error {
+	opReq := *req
+	opReq.Extensions = make(map[string]interface{}, len(req.Extensions)+1)
+	for k, v := range req.Extensions {
+		opReq.Extensions[k] = v
+	}
+	opReq.Extensions["operationId"] = subscriptionID
+
+	httpResp, err := c.post(ctx, &opReq)
+	if err != nil {
+		return err
+	}
+	httpResp.Body.Close()
+	return nil
+}
+
+// subscribeDistinctConnection makes a request for the given operation, and
+// starts reading its event stream.
+func (c *sseClient) subscribeDistinctConnection(ctx context.Context, subscriptionID string, req *Request) error {
+	ctx, cancel := context.WithCancel(ctx)
+	httpResp, err := c.post(ctx, req)
+	if err == nil {
+		err = checkEventStream(httpResp)
+	}
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	c.Lock()
+	c.cancels[subscriptionID] = cancel
+	c.Unlock()
+	c.readers.Add(1)
+	go c.readEvents(ctx, httpResp.Body, subscriptionID)
+	return nil
+}
+
+func (c *sseClient) post(ctx context.Context, req *Request) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+	return c.do(httpReq)
+}
+
+func (c *sseClient) Unsubscribe(subscriptionID string) error {
+	if c.singleConnection {
+		u, err := url.Parse(c.endpoint)
+		if err != nil {
+			return err
+		}
+		query := u.Query()
+		query.Set("operationId", subscriptionID)
+		u.RawQuery = query.Encode()
+
+		httpReq, err := http.NewRequestWithContext(c.ctx, http.MethodDelete, u.String(), http.NoBody)
+		if err != nil {
+			return err
+		}
+		httpResp, err := c.do(httpReq)
+		if err != nil {
+			return err
+		}
+		httpResp.Body.Close()
+	}
+
+	err := c.subscriptions.Unsubscribe(subscriptionID)
+	if err != nil {
+		return err
+	}
+	c.cancelStream(subscriptionID)
+	return nil
+}
+
+// cancelStream cancels the request for the given subscription's event
+// stream, in distinct connections mode, and forgets it.
+func (c *sseClient) cancelStream(subscriptionID string) {
+	c.Lock()
+	cancel := c.cancels[subscriptionID]
+	delete(c.cancels, subscriptionID)
+	c.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// UnsubscribeAll unsubscribes from all the active subscriptions.
+func (c *sseClient) UnsubscribeAll() error {
+	for _, subscriptionID := range c.subscriptions.GetAllIDs() {
+		sub, ok := c.subscriptions.Read(subscriptionID)
+		if !ok || sub.hasBeenUnsubscribed {
+			continue
+		}
+		err := c.Unsubscribe(subscriptionID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readEvents reads the given event stream until it ends, forwarding its data
+// to the appropriate subscriptions.  In distinct connections mode, the stream
+// belongs to the given subscription; in single connection mode (when
+// subscriptionID is ""), each event says which subscription it belongs to.
+func (c *sseClient) readEvents(ctx context.Context, body io.ReadCloser, subscriptionID string) {
+	defer c.readers.Done()
+	defer body.Close()
+	if subscriptionID != "" {
+		defer c.cancelStream(subscriptionID)
+	}
+
+	reader := bufio.NewReader(body)
+	for {
+		event, err := readSSEEvent(reader)
+		done := false
+		if err == nil {
+			done, err = c.handleEvent(event, subscriptionID)
+		}
+		if done {
+			return
+		}
+		if err == nil {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return // we closed the stream ourselves
+		}
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("event stream ended unexpectedly: %w", io.ErrUnexpectedEOF)
+		}
+		// The stream is dead, so any subscriptions it served are over.
+		if subscriptionID != "" {
+			_ = c.subscriptions.Unsubscribe(subscriptionID)
+		} else {
+			for _, id := range c.subscriptions.GetAllIDs() {
+				_ = c.subscriptions.Unsubscribe(id)
+			}
+		}
+		select {
+		case c.errChan <- err:
+		case <-c.ctx.Done():
+		}
+		return
+	}
+}
+
+// sseMessage is the data of an event in single connection mode.
+type sseMessage struct {
+	ID      string          `json:"id"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// handleEvent handles a single event from the stream for the given
+// subscription (see readEvents).  It returns done if the stream is complete.
+func (c *sseClient) handleEvent(event sseEvent, subscriptionID string) (done bool, err error) {
+	if event.event != sseEventNext && event.event != sseEventComplete {
+		return false, nil // a keep-alive, or an event we don't know about
+	}
+	payload := json.RawMessage(event.data)
+	distinct := subscriptionID != ""
+	if !distinct {
+		var msg sseMessage
+		err = json.Unmarshal(payload, &msg)
+		if err != nil {
+			return false, err
+		}
+		subscriptionID, payload = msg.ID, msg.Payload
+	}
+
+	// Messages for subscriptions we don't know, or which are already over
+	// (say because we unsubscribed while the message was in flight), are
+	// ignored, as in the WebSocket client; subscriptionMap does so for us.
+	if event.event == sseEventComplete {
+		_ = c.subscriptions.Complete(subscriptionID)
+		return distinct, nil
+	}
+	c.subscriptions.Forward(subscriptionID, payload)
+	return false, nil
+}
+
+// reportError sends an error from forwarding a subscription's data to
+// errChan, unless the client is closing.
+func (c *sseClient) reportError(err error) {
+	c.Lock()
+	if c.isClosing {
+		c.Unlock()
+		return
+	}
+	ctx := c.ctx
+	c.readers.Add(1)
+	c.Unlock()
+	defer c.readers.Done()
+
+	select {
+	case c.errChan <- err:
+	case <-ctx.Done():
+	}
+}
+
+// sseEvent is a single server-sent event.
+type sseEvent struct {
+	event string
+	data  string
+}
+
+// readSSEEvent reads the next event from the given event stream, per the
+// [HTML spec].  Unlike a browser, it returns events with no data.
+//
+// [HTML spec]: https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+func readSSEEvent(reader *bufio.Reader) (sseEvent, error) {
+	var event sseEvent
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return sseEvent{}, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if event.event == "" && data == nil {
+				continue // no event yet
+			}
+			event.data = strings.Join(data, "\n")
+			return event, nil
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.event = value
+		case "data":
+			data = append(data, value)
+		}
+		// Anything else is a comment (field ""), or a field we don't use.
+	}
+}
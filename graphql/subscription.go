@@ -1,6 +1,7 @@
 package graphql
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"sync"
@@ -9,25 +10,55 @@ import (
 // map of subscription ID to subscription
 type subscriptionMap struct {
 	map_ map[string]subscription
+	// If set, called (with the lock held) whenever the number of active
+	// subscriptions changes.  See WithWebSocketMetrics.
+	onActiveChange func(active int)
+	// If set, called with any error from a subscription's
+	// ForwardDataFunction.
+	onForwardError func(err error)
+	// The number of active subscriptions, i.e. those with a request which
+	// haven't been unsubscribed.  (Queries and mutations have no request.)
+	active int
 	sync.RWMutex
 }
 
+// updateActive adds delta to the number of active subscriptions.  The
+// caller must hold the lock.
+func (s *subscriptionMap) updateActive(sub subscription, delta int) {
+	if sub.request == nil || sub.hasBeenUnsubscribed {
+		return
+	}
+	s.active += delta
+	if s.onActiveChange != nil {
+		s.onActiveChange(s.active)
+	}
+}
+
 type subscription struct {
-	interfaceChan       interface{}
-	forwardDataFunc     ForwardDataFunction
+	interfaceChan   interface{}
+	forwardDataFunc ForwardDataFunction
+	// The request which started the subscription, in case we need to
+	// resubscribe.
+	request *Request
+	// Forwards the subscription's messages, and closes its channel.
+	deliverer           *deliverer
 	id                  string
 	hasBeenUnsubscribed bool
 }
 
-func (s *subscriptionMap) Create(subscriptionID string, interfaceChan interface{}, forwardDataFunc ForwardDataFunction) {
+func (s *subscriptionMap) Create(subscriptionID string, request *Request, interfaceChan interface{}, forwardDataFunc ForwardDataFunction) {
 	s.Lock()
 	defer s.Unlock()
-	s.map_[subscriptionID] = subscription{
+	sub := subscription{
 		id:                  subscriptionID,
+		request:             request,
 		interfaceChan:       interfaceChan,
 		forwardDataFunc:     forwardDataFunc,
+		deliverer:           newDeliverer(interfaceChan, forwardDataFunc, s.onForwardError),
 		hasBeenUnsubscribed: false,
 	}
+	s.map_[subscriptionID] = sub
+	s.updateActive(sub, 1)
 }
 
 func (s *subscriptionMap) Read(subscriptionID string) (sub subscription, success bool) {
@@ -37,16 +68,48 @@ func (s *subscriptionMap) Read(subscriptionID string) (sub subscription, success
 	return sub, success
 }
 
+// Forward queues the given message to be passed to the subscription's
+// ForwardDataFunction.  Messages for subscriptions which are unknown or
+// over are dropped.  If the subscription already has maxPendingMessages
+// waiting, Forward blocks until there's room, or the subscription ends.
+func (s *subscriptionMap) Forward(subscriptionID string, payload json.RawMessage) {
+	// We don't hold the lock while we push, which may block, so that the
+	// subscription can still be ended meanwhile.
+	s.RLock()
+	sub, ok := s.map_[subscriptionID]
+	s.RUnlock()
+	if ok && !sub.hasBeenUnsubscribed {
+		sub.deliverer.push(payload)
+	}
+}
+
+// Unsubscribe ends the subscription, dropping any messages not yet
+// forwarded, and closes its channel.
 func (s *subscriptionMap) Unsubscribe(subscriptionID string) error {
+	return s.end(subscriptionID, (*deliverer).cancel)
+}
+
+// Complete ends the subscription, once the messages already received have
+// been forwarded, and then closes its channel.  It's for when the server
+// ends the subscription.
+func (s *subscriptionMap) Complete(subscriptionID string) error {
+	return s.end(subscriptionID, (*deliverer).finish)
+}
+
+func (s *subscriptionMap) end(subscriptionID string, stop func(*deliverer)) error {
 	s.Lock()
 	defer s.Unlock()
 	unsub, success := s.map_[subscriptionID]
 	if !success {
 		return fmt.Errorf("tried to unsubscribe from unknown subscription with ID '%s'", subscriptionID)
 	}
+	if unsub.hasBeenUnsubscribed {
+		return nil // already closed
+	}
+	s.updateActive(unsub, -1)
 	unsub.hasBeenUnsubscribed = true
 	s.map_[subscriptionID] = unsub
-	reflect.ValueOf(s.map_[subscriptionID].interfaceChan).Close()
+	stop(unsub.deliverer)
 	return nil
 }
 
@@ -62,5 +125,167 @@ func (s *subscriptionMap) GetAllIDs() (subscriptionIDs []string) {
 func (s *subscriptionMap) Delete(subscriptionID string) {
 	s.Lock()
 	defer s.Unlock()
+	if sub, ok := s.map_[subscriptionID]; ok {
+		s.updateActive(sub, -1)
+		sub.deliverer.cancel()
+	}
 	delete(s.map_, subscriptionID)
 }
+
+// maxPendingMessages is how many of a subscription's messages may wait to
+// be forwarded, before the deliverer pushes back on whoever's receiving them.
+const maxPendingMessages = 1000
+
+// A deliverer passes a subscription's messages to its ForwardDataFunction,
+// in order, on its own goroutine.  That way a subscriber which is slow to
+// read its channel holds up only its own messages, not the listener (and
+// with it every other operation on the connection), at least until it falls
+// maxPendingMessages behind.  The deliverer is also what closes the channel,
+// so it's never closed during a send.
+//
+// An error from the ForwardDataFunction, such as a message which doesn't
+// match the response type, is passed to onError, and the deliverer moves on
+// to the next message; it doesn't end the subscription.
+type deliverer struct {
+	// Signaled whenever messages or the flags below change.
+	wake chan struct{}
+	// Closed when the subscription is canceled, to abandon any send the
+	// subscriber isn't reading.
+	done chan struct{}
+	// Signaled whenever there's room in messages, or the subscription ends.
+	room     *sync.Cond
+	messages []json.RawMessage
+	mu       sync.Mutex
+	// Set when the subscription ends: finished if the pending messages
+	// should still be forwarded, canceled if they should be dropped.
+	finished bool
+	canceled bool
+}
+
+func newDeliverer(interfaceChan interface{}, forwardDataFunc ForwardDataFunction, onError func(error)) *deliverer {
+	d := &deliverer{wake: make(chan struct{}, 1), done: make(chan struct{})}
+	d.room = sync.NewCond(&d.mu)
+	go d.run(interfaceChan, forwardDataFunc, onError)
+	return d
+}
+
+func (d *deliverer) push(payload json.RawMessage) {
+	d.mu.Lock()
+	for len(d.messages) >= maxPendingMessages && !d.finished && !d.canceled {
+		d.room.Wait()
+	}
+	if !d.finished && !d.canceled {
+		d.messages = append(d.messages, payload)
+	}
+	d.mu.Unlock()
+	d.signal()
+}
+
+func (d *deliverer) finish() {
+	d.mu.Lock()
+	d.finished = true
+	d.room.Broadcast()
+	d.mu.Unlock()
+	d.signal()
+}
+
+func (d *deliverer) cancel() {
+	d.mu.Lock()
+	if !d.canceled {
+		d.canceled = true
+		close(d.done)
+	}
+	d.messages = nil
+	d.room.Broadcast()
+	d.mu.Unlock()
+	d.signal()
+}
+
+func (d *deliverer) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default: // already signaled
+	}
+}
+
+func (d *deliverer) run(interfaceChan interface{}, forwardDataFunc ForwardDataFunction, onError func(error)) {
+	defer reflect.ValueOf(interfaceChan).Close()
+	for {
+		d.mu.Lock()
+		if len(d.messages) == 0 {
+			done := d.finished || d.canceled
+			d.mu.Unlock()
+			if done {
+				return
+			}
+			<-d.wake
+			continue
+		}
+		payload := d.messages[0]
+		d.messages = d.messages[1:]
+		d.room.Signal()
+		d.mu.Unlock()
+
+		err := d.forward(interfaceChan, forwardDataFunc, payload)
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// forward calls forwardDataFunc with the given message.  Rather than
+// interfaceChan, we pass it a relay channel of the same type, and pass on
+// what it sends, so that if the subscription is canceled while the
+// subscriber isn't reading we can stop waiting.
+func (d *deliverer) forward(interfaceChan interface{}, forwardDataFunc ForwardDataFunction, payload json.RawMessage) error {
+	dataChan := reflect.ValueOf(interfaceChan)
+	if dataChan.Kind() != reflect.Chan || dataChan.Type().ChanDir() != reflect.BothDir {
+		// We can't make a relay; forwardDataFunc will report the error, if
+		// it's not the channel it expects.
+		return forwardDataFunc(interfaceChan, payload)
+	}
+
+	relay := reflect.MakeChan(dataChan.Type(), 0)
+	result := make(chan error, 1)
+	go func() { result <- forwardDataFunc(relay.Interface(), payload) }()
+
+	done := reflect.ValueOf(d.done)
+	for {
+		chosen, value, _ := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: relay},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(result)},
+			{Dir: reflect.SelectRecv, Chan: done},
+		})
+		switch chosen {
+		case 0:
+			chosen, _, _ = reflect.Select([]reflect.SelectCase{
+				{Dir: reflect.SelectSend, Chan: dataChan, Send: value},
+				{Dir: reflect.SelectRecv, Chan: done},
+			})
+			if chosen == 1 {
+				go discard(relay, result)
+				return nil
+			}
+		case 1:
+			err, _ := value.Interface().(error)
+			return err
+		default:
+			go discard(relay, result)
+			return nil
+		}
+	}
+}
+
+// discard receives (and drops) whatever a canceled subscription's
+// forwardDataFunc sends on relay, until it returns.
+func discard(relay reflect.Value, result chan error) {
+	for {
+		chosen, _, _ := reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: relay},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(result)},
+		})
+		if chosen == 1 {
+			return
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package tracing instruments genqlient clients to create a span for each
+// GraphQL operation, following the OpenTelemetry semantic conventions for
+// GraphQL, and to propagate the trace to the server via the W3C traceparent
+// header.
+//
+// To avoid depending on any particular tracing library, the package defines
+// small [Tracer] and [Span] interfaces; adapting a library to them takes a
+// few lines.  For example, for OpenTelemetry:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, tracing.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SpanContext() tracing.SpanContext {
+//		sc := s.Span.SpanContext()
+//		return tracing.SpanContext{TraceID: sc.TraceID(), SpanID: sc.SpanID(), Sampled: sc.IsSampled()}
+//	}
+//
+//	func (s otelSpan) SetAttributes(attrs ...tracing.Attribute) {
+//		for _, attr := range attrs {
+//			s.Span.SetAttributes(attribute.String(attr.Key, attr.Value))
+//		}
+//	}
+//
+//	// ... and similarly for AddEvent and RecordError.
+//
+// Then wrap the client:
+//
+//	client := tracing.WithTracing(graphql.NewClient(url, http.DefaultClient), otelTracer{tracer})
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Khan/genqlient/graphql"
+)
+
+// Tracer starts spans; see the package documentation for how to adapt a
+// tracing library to it.
+type Tracer interface {
+	// Start starts a span with the given name, as a child of the span in
+	// ctx (if any), and returns a context containing the new span.  The
+	// span describes a request the client makes to a server (in
+	// OpenTelemetry terms, its kind is "client").
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is a span started by a [Tracer].
+type Span interface {
+	// SpanContext returns the IDs of the span, which are propagated to the
+	// server.
+	SpanContext() SpanContext
+	// SetAttributes sets the given attributes on the span.
+	SetAttributes(attributes ...Attribute)
+	// AddEvent records an event, with the given attributes, on the span.
+	AddEvent(name string, attributes ...Attribute)
+	// RecordError records that the operation failed with the given error,
+	// and marks the span as failed.
+	RecordError(err error)
+	// End completes the span.
+	End()
+}
+
+// Attribute is a key-value pair describing a span or event.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// SpanContext identifies a span, as propagated via the W3C traceparent
+// header.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	// Whether the span is sampled, i.e. the trace will be recorded.
+	Sampled bool
+}
+
+// IsValid returns true if the span context has nonzero trace and span IDs.
+// Invalid span contexts (for example, from a no-op tracer) are not
+// propagated.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceParent returns the value of the W3C traceparent header for the span.
+func (sc SpanContext) TraceParent() string {
+	var flags byte
+	if sc.Sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceID[:], sc.SpanID[:], flags)
+}
+
+// The names of the attributes and events the instrumentation records.
+const (
+	AttributeOperationName = "graphql.operation.name"
+	AttributeOperationType = "graphql.operation.type"
+	AttributeDocumentHash  = "graphql.document.hash"
+	AttributeErrorMessage  = "graphql.error.message"
+	AttributeErrorPath     = "graphql.error.path"
+	AttributeErrorCode     = "graphql.error.code"
+
+	// EventError is the name of the event recorded for each GraphQL error
+	// in the response.
+	EventError = "graphql.error"
+
+	// traceParentKey is the name of the header, and of the connection
+	// parameter, which propagates the trace.
+	traceParentKey = "traceparent"
+)
+
+// Middleware returns a [graphql.Middleware] which creates a span for each
+// request, propagates it to the server via the traceparent header, and
+// records any GraphQL errors in the response as events on the span.
+//
+// The header is added with [graphql.WithHeader], so it's supported only by
+// the clients returned by [graphql.NewClient] and
+// [graphql.NewClientUsingGet]; in particular, such clients configured
+// [graphql.WithBatching] send traced requests on their own.  Most callers
+// should use [WithTracing], which applies this middleware.
+func Middleware(tracer Tracer) graphql.Middleware {
+	return func(next graphql.MakeRequestFunc) graphql.MakeRequestFunc {
+		return func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+			ctx, span := startSpan(ctx, tracer, req)
+			defer span.End()
+			if sc := span.SpanContext(); sc.IsValid() {
+				ctx = graphql.WithHeader(ctx, traceParentKey, sc.TraceParent())
+			}
+			err := next(ctx, req, resp)
+			recordError(span, err)
+			return err
+		}
+	}
+}
+
+// WithTracing returns a [graphql.Client] which traces each request it makes
+// with client; see [Middleware] for details.
+func WithTracing(client graphql.Client, tracer Tracer) graphql.Client {
+	return graphql.WithMiddleware(client, Middleware(tracer))
+}
+
+// WithWebSocketTracing returns a [graphql.WebSocketClient] which traces
+// requests it makes with client.
+//
+// Start creates a span for connecting, which it propagates to the server
+// via the traceparent key of the connection_init payload (see
+// [graphql.WithConnectionParams]).  Subscribe creates a span for each
+// subscription; since Subscribe returns as soon as the subscription is
+// sent, the span covers only that, not the messages which arrive on it.
+// If client supports queries and mutations over the WebSocket (i.e. it's
+// also a [graphql.Client]), so does the returned client, and it creates a
+// span for each.
+//
+// There are two limitations, both because the WebSocketClient interface
+// passes no context where we'd need one.  First, Subscribe takes no
+// context, so each subscription's span starts from context.Background():
+// it's the root of its own trace, not a child of the caller's span.
+// Second, the connection parameters are fixed when Start is called, so if
+// the client reconnects (see [graphql.WithReconnect]), it sends the
+// traceparent of the original connect span again, although that span has
+// long since ended.
+func WithWebSocketTracing(client graphql.WebSocketClient, tracer Tracer) graphql.WebSocketClient {
+	wsClient := &webSocketClient{WebSocketClient: client, tracer: tracer}
+	if queryClient, ok := client.(graphql.Client); ok {
+		return &webSocketQueryClient{webSocketClient: wsClient, client: queryClient}
+	}
+	return wsClient
+}
+
+type webSocketClient struct {
+	graphql.WebSocketClient
+	tracer Tracer
+}
+
+// webSocketQueryClient is a webSocketClient whose client also supports
+// queries and mutations.
+type webSocketQueryClient struct {
+	*webSocketClient
+	client graphql.Client
+}
+
+func (c *webSocketClient) Start(ctx context.Context) (errChan chan error, err error) {
+	ctx, span := c.tracer.Start(ctx, "GraphQL connect")
+	defer span.End()
+	if sc := span.SpanContext(); sc.IsValid() {
+		ctx = graphql.WithConnectionParams(ctx,
+			map[string]interface{}{traceParentKey: sc.TraceParent()})
+	}
+	errChan, err = c.WebSocketClient.Start(ctx)
+	recordError(span, err)
+	return errChan, err
+}
+
+func (c *webSocketClient) Subscribe(
+	req *graphql.Request,
+	interfaceChan interface{},
+	forwardDataFunc graphql.ForwardDataFunction,
+) (string, error) {
+	_, span := startSpan(context.Background(), c.tracer, req)
+	defer span.End()
+	subscriptionID, err := c.WebSocketClient.Subscribe(req, interfaceChan, forwardDataFunc)
+	recordError(span, err)
+	return subscriptionID, err
+}
+
+func (c *webSocketQueryClient) MakeRequest(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+	ctx, span := startSpan(ctx, c.tracer, req)
+	defer span.End()
+	err := c.client.MakeRequest(ctx, req, resp)
+	recordError(span, err)
+	return err
+}
+
+// startSpan starts the span for the given request, and sets its attributes.
+func startSpan(ctx context.Context, tracer Tracer, req *graphql.Request) (context.Context, Span) {
+	opType := string(req.OperationType())
+	// Per the semantic conventions, the span is named for the operation
+	// type and name, if known.
+	spanName := strings.TrimSpace(opType + " " + req.OpName)
+	if spanName == "" {
+		spanName = "GraphQL Operation"
+	}
+	ctx, span := tracer.Start(ctx, spanName)
+
+	var attributes []Attribute
+	if req.OpName != "" {
+		attributes = append(attributes, Attribute{AttributeOperationName, req.OpName})
+	}
+	if opType != "" {
+		attributes = append(attributes, Attribute{AttributeOperationType, opType})
+	}
+	if hash := req.Hash(); hash != "" {
+		attributes = append(attributes, Attribute{AttributeDocumentHash, hash})
+	}
+	span.SetAttributes(attributes...)
+	return ctx, span
+}
+
+// recordError records err, if any, on the span, along with an event for
+// each GraphQL error it contains.
+func recordError(span Span, err error) {
+	if err == nil {
+		return
+	}
+	for _, gqlErr := range graphql.ErrorsAt(err) {
+		attributes := []Attribute{{AttributeErrorMessage, gqlErr.Message}}
+		if len(gqlErr.Path) > 0 {
+			attributes = append(attributes, Attribute{AttributeErrorPath, gqlErr.Path.String()})
+		}
+		if code, ok := gqlErr.Extensions["code"].(string); ok {
+			attributes = append(attributes, Attribute{AttributeErrorCode, code})
+		}
+		span.AddEvent(EventError, attributes...)
+	}
+	span.RecordError(err)
+}
@@ -0,0 +1,213 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Upload is a file to be uploaded as a GraphQL variable, using the [GraphQL
+// multipart request spec].  genqlient uses it for the Upload scalar, unless
+// you bind that scalar to some other type in genqlient.yaml.
+//
+// When the variables of a request contain any Uploads (in any field, list, or
+// map), the client from [NewClient] sends the request as multipart/form-data,
+// with each file in its own part.  An Upload with a nil File, such as the zero
+// value for an optional argument, is sent as null.  Each File is read exactly
+// once; as a result, requests containing uploads are never retried by
+// [WithRetry], sent as automatic persisted queries, or batched by
+// [WithBatching].
+//
+// Some servers require a particular header to accept multipart requests, as
+// protection against cross-site request forgery; for example, Apollo Server
+// requires Apollo-Require-Preflight.  Add it to the client's HTTP transport,
+// as with any other header.
+//
+// [GraphQL multipart request spec]: https://github.com/jaydenseric/graphql-multipart-request-spec
+type Upload struct {
+	// The contents of the file, or nil for no file.  If File is also an [io.Closer], the client
+	// closes it once it has been sent.
+	File io.Reader
+	// The name of the file, as sent to the server.
+	Filename string
+	// The MIME type of the file, or "" to use application/octet-stream.
+	ContentType string
+}
+
+// MarshalJSON marshals the Upload as null, its placeholder in the
+// "operations" part of a multipart request.
+func (Upload) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+var uploadType = reflect.TypeOf(Upload{})
+
+// foundUpload is an Upload found in a request's variables, along with its
+// path in the request, e.g. "variables.input.files.0".
+type foundUpload struct {
+	upload Upload
+	path   string
+}
+
+// findUploads returns all the Uploads with a non-nil File in the given
+// variables, following the same field names as encoding/json.
+func findUploads(variables interface{}) []foundUpload {
+	var uploads []foundUpload
+	walkUploads(reflect.ValueOf(variables), "variables", &uploads)
+	return uploads
+}
+
+func hasUploads(variables interface{}) bool {
+	return len(findUploads(variables)) > 0
+}
+
+func walkUploads(v reflect.Value, path string, uploads *[]foundUpload) {
+	if !v.IsValid() {
+		return
+	}
+	if v.Type() == uploadType && v.CanInterface() {
+		upload, _ := v.Interface().(Upload)
+		if upload.File != nil {
+			*uploads = append(*uploads, foundUpload{upload: upload, path: path})
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkUploads(v.Elem(), path, uploads)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			switch {
+			case name == "-":
+				continue
+			case name == "" && field.Anonymous:
+				// Embedded fields are flattened, as by encoding/json.
+				walkUploads(v.Field(i), path, uploads)
+				continue
+			case name == "":
+				name = field.Name
+			}
+			walkUploads(v.Field(i), path+"."+name, uploads)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			walkUploads(v.MapIndex(key), path+"."+key.String(), uploads)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return // []byte, which can't contain uploads
+		}
+		for i := 0; i < v.Len(); i++ {
+			walkUploads(v.Index(i), path+"."+strconv.Itoa(i), uploads)
+		}
+	}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// createMultipartRequest returns a request which sends payload (the JSON
+// body of the request) and the given uploads, per the multipart request
+// spec.  The files are streamed to the server as the request is sent.
+func (c *client) createMultipartRequest(payload interface{}, uploads []foundUpload) (*http.Request, error) {
+	operations, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	fileMap := make(map[string][]string, len(uploads))
+	for i, upload := range uploads {
+		fileMap[strconv.Itoa(i)] = []string{upload.path}
+	}
+	fileMapJSON, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyReader, bodyWriter := io.Pipe()
+	form := multipart.NewWriter(bodyWriter)
+	go func() {
+		writeErr := writeMultipart(form, operations, fileMapJSON, uploads)
+		if writeErr == nil {
+			writeErr = form.Close()
+		}
+		bodyWriter.CloseWithError(writeErr)
+	}()
+
+	httpReq, err := http.NewRequest(c.method, c.endpoint, bodyReader)
+	if err != nil {
+		bodyReader.Close()
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", form.FormDataContentType())
+	return httpReq, nil
+}
+
+// writeMultipart writes the parts of a multipart request to form: first the
+// operations and the map from file to path, then the files themselves.
+func writeMultipart(form *multipart.Writer, operations, fileMap []byte, uploads []foundUpload) error {
+	defer closeUploads(uploads)
+
+	err := form.WriteField("operations", string(operations))
+	if err != nil {
+		return err
+	}
+	err = form.WriteField("map", string(fileMap))
+	if err != nil {
+		return err
+	}
+
+	for i, upload := range uploads {
+		contentType := upload.upload.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+strconv.Itoa(i)+
+			`"; filename="`+quoteEscaper.Replace(upload.upload.Filename)+`"`)
+		header.Set("Content-Type", contentType)
+
+		part, err := form.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, upload.upload.File)
+		if err != nil {
+			return fmt.Errorf("reading upload at %s: %w", upload.path, err)
+		}
+	}
+	return nil
+}
+
+// closeUploads closes each upload's File, if it's an io.Closer.
+func closeUploads(uploads []foundUpload) {
+	for _, upload := range uploads {
+		if closer, ok := upload.upload.File.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+// errUploadsNotSupported is returned if a request with uploads is made by a
+// client that can't send them.
+var errUploadsNotSupported = errors.New("client does not support uploads")
@@ -40,3 +40,18 @@ type NoMarshalJSON struct{}
 func (NoMarshalJSON) MarshalJSON() ([]byte, error) {
 	panic("NoUnmarshalJSON.MarshalJSON should never be called!")
 }
+
+// Received is the type of the field genqlient generates for each fragment
+// with the @defer directive, which says whether that fragment has been
+// received (and so whether its fields have been populated).  It may be used
+// as an ordinary bool.
+//
+// It is true if the server sent the marker field genqlient adds to each such
+// fragment, which the server sends along with the fragment's other fields.
+type Received bool
+
+// UnmarshalJSON sets the Received to true unless the marker is null.
+func (r *Received) UnmarshalJSON(b []byte) error {
+	*r = string(b) != "null"
+	return nil
+}
@@ -1,17 +1,20 @@
 package graphql
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 const (
@@ -25,6 +28,62 @@ const (
 	websocketConnAckTimeOut = time.Second * 30
 )
 
+// webSocketProtocol describes a GraphQL-over-WebSocket protocol by its
+// message types, where they differ between protocols.  All protocols begin
+// with connection_init and connection_ack.
+type webSocketProtocol struct {
+	// The message types sent by the client: to start and stop an operation,
+	// and (if needed) before closing the connection.
+	subscribe   string
+	unsubscribe string
+	terminate   string
+	// The message types sent by the server: for the end of an operation, an
+	// operation which failed (which also ends it), a rejected
+	// connection_init (if not just a close), and keep-alives (if any).
+	// Other messages about an operation are passed to its
+	// ForwardDataFunction.
+	complete        string
+	operationError  string
+	connectionError string
+	keepAlive       string
+	// The message types sent by either side to check that the other is
+	// alive, and in response, if the protocol supports them.
+	ping string
+	pong string
+}
+
+const (
+	// The protocol of the graphql-ws library, which is the default.
+	protocolGraphQLTransportWS = "graphql-transport-ws"
+	// The older protocol of Apollo's subscriptions-transport-ws library.
+	// Confusingly, its subprotocol name is that of the newer library.
+	protocolGraphQLWS = "graphql-ws"
+)
+
+// webSocketProtocols are the supported protocols, by their subprotocol name
+// (the value of the Sec-WebSocket-Protocol header).
+var webSocketProtocols = map[string]*webSocketProtocol{
+	// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+	protocolGraphQLTransportWS: {
+		subscribe:      webSocketTypeSubscribe,
+		unsubscribe:    webSocketTypeComplete,
+		complete:       webSocketTypeComplete,
+		operationError: webSocketTypeError,
+		ping:           "ping",
+		pong:           "pong",
+	},
+	// https://github.com/apollographql/subscriptions-transport-ws/blob/master/PROTOCOL.md
+	protocolGraphQLWS: {
+		subscribe:       "start",
+		unsubscribe:     "stop",
+		terminate:       "connection_terminate",
+		complete:        webSocketTypeComplete,
+		operationError:  webSocketTypeError,
+		connectionError: "connection_error",
+		keepAlive:       "ka",
+	},
+}
+
 // Close codes defined in RFC 6455, section 11.7.
 const (
 	closeNormalClosure    = 1000
@@ -44,15 +103,120 @@ const (
 )
 
 type webSocketClient struct {
-	Dialer        Dialer
-	Header        http.Header
-	endpoint      string
-	conn          WSConn
-	connParams    map[string]interface{}
-	errChan       chan error
+	Dialer   Dialer
+	Header   http.Header
+	endpoint string
+	conn     WSConn
+	protocol *webSocketProtocol
+	// If set, reconnect when the connection is lost.  See [WithReconnect].
+	reconnectPolicy *ReconnectPolicy
+	// If set, metrics are recorded here.  See [WithWebSocketMetrics].
+	metrics MetricsRecorder
+	// Canceled by Close, to stop any reconnection attempts.
+	stopReconnecting context.CancelFunc
+	reconnectCtx     context.Context
+	connParams       map[string]interface{}
+	// Additional connection params from the context passed to Start; see
+	// WithConnectionParams.
+	contextConnParams map[string]interface{}
+	errChan           chan error
+	// Closed when listenWebSocket returns.
+	listenerDone chan struct{}
+	// If set, the reason keepAlive closed the connection.
+	keepAliveErr  error
 	subscriptions subscriptionMap
-	isClosing     bool
+	// See WithKeepAlive and WithConnectionAckTimeout.
+	pingInterval   time.Duration
+	pongTimeout    time.Duration
+	connAckTimeout time.Duration
+	// When we last received a message from the server, in Unix nanoseconds.
+	lastReceived atomic.Int64
+	// connMu guards conn and protocol, and serializes writes to conn.
+	connMu sync.Mutex
 	sync.Mutex
+	isClosing bool
+}
+
+// WebSocketOption configures optional behavior of the [WebSocketClient]
+// returned by [NewClientUsingWebSocket] or
+// [NewClientUsingWebSocketWithConnectionParams].
+type WebSocketOption func(*webSocketClient)
+
+// ReconnectPolicy configures how a [WebSocketClient] reconnects after its
+// connection is lost; see [WithReconnect].
+type ReconnectPolicy struct {
+	// Called each time the client connects, including the first time (in
+	// Start), once the server has acknowledged the connection and (when
+	// reconnecting) the client has resubscribed.
+	OnConnected func()
+	// Called when the connection is lost, with the error that ended it.
+	OnDisconnected func(err error)
+	// Called before each attempt to reconnect, with the attempt number,
+	// starting from 1.
+	OnReconnecting func(attempt int)
+	// How long to wait before each attempt.
+	Backoff Backoff
+	// The maximum number of consecutive attempts to reconnect, after which
+	// the client gives up and sends the last error on the error channel.
+	// Defaults to 10; set a negative value to try forever.
+	MaxAttempts int
+}
+
+const defaultMaxReconnectAttempts = 10
+
+// WithReconnect configures the client to reconnect, according to policy,
+// when its connection is lost.  A nil policy uses the defaults described in
+// [ReconnectPolicy].
+//
+// After reconnecting (which includes sending connection_init again), the
+// client resubscribes to each active subscription, with the same request
+// and subscription ID, so its data continues to arrive on the same channel.
+// Since the server has no memory of the old subscription, it may repeat or
+// skip data which arrives around the time of the reconnection.  Errors
+// reading from the connection are sent on the error channel only if the
+// client fails to reconnect; other errors, such as malformed messages, are
+// sent immediately, and end the connection, as usual.
+func WithReconnect(policy *ReconnectPolicy) WebSocketOption {
+	var p ReconnectPolicy
+	if policy != nil {
+		p = *policy
+	}
+	p.Backoff = p.Backoff.withDefaults()
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = defaultMaxReconnectAttempts
+	}
+	return func(w *webSocketClient) { w.reconnectPolicy = &p }
+}
+
+// WithKeepAlive configures the client to check that the server is still
+// alive every pingInterval, and to consider the connection lost if the server
+// hasn't responded after pongTimeout (which defaults to pingInterval).  A
+// lost connection is handled as any other: the client reconnects (if
+// configured with [WithReconnect]) or sends an error on its error channel.
+//
+// With the graphql-transport-ws protocol, the client sends a ping, and
+// expects a pong (or any other message) in response.  The legacy graphql-ws
+// protocol has no pings, so instead the client expects the server to send
+// its keep-alive messages (or any other messages) at least that often.
+//
+// Regardless of this option, the client always responds to the server's
+// pings.
+func WithKeepAlive(pingInterval, pongTimeout time.Duration) WebSocketOption {
+	if pongTimeout <= 0 {
+		pongTimeout = pingInterval
+	}
+	return func(w *webSocketClient) {
+		w.pingInterval = pingInterval
+		w.pongTimeout = pongTimeout
+	}
+}
+
+// WithConnectionAckTimeout configures how long the client waits for the
+// server to acknowledge its connection_init message, when it connects (or
+// reconnects).  The default is 30 seconds; a timeout of 0 or less means the
+// client waits as long as it takes.
+func WithConnectionAckTimeout(timeout time.Duration) WebSocketOption {
+	return func(w *webSocketClient) { w.connAckTimeout = timeout }
 }
 
 type webSocketInitMessage struct {
@@ -66,40 +230,74 @@ type webSocketSendMessage struct {
 	ID      string   `json:"id"`
 }
 
+// webSocketTypeMessage is a message with no ID or payload, such as a ping.
+type webSocketTypeMessage struct {
+	Type string `json:"type"`
+}
+
 type webSocketReceiveMessage struct {
 	Type    string          `json:"type"`
 	ID      string          `json:"id"`
 	Payload json.RawMessage `json:"payload"`
 }
 
-func (w *webSocketClient) sendInit() error {
+// sendInit sends the connection_init message on conn, which is not yet in
+// use, so needs no lock.
+func (w *webSocketClient) sendInit(conn WSConn) error {
+	payload := w.connParams
+	if len(w.contextConnParams) > 0 {
+		payload = make(map[string]interface{}, len(w.connParams)+len(w.contextConnParams))
+		for k, v := range w.connParams {
+			payload[k] = v
+		}
+		for k, v := range w.contextConnParams {
+			payload[k] = v
+		}
+	}
 	connInitMsg := webSocketInitMessage{
 		Type:    webSocketTypeConnInit,
-		Payload: w.connParams,
+		Payload: payload,
 	}
-	return w.sendStructAsJSON(connInitMsg)
+	return writeJSON(conn, connInitMsg)
 }
 
+// sendStructAsJSON sends the given message.  The caller must hold w.connMu.
 func (w *webSocketClient) sendStructAsJSON(object any) error {
+	return writeJSON(w.conn, object)
+}
+
+func writeJSON(conn WSConn, object any) error {
 	jsonBytes, err := json.Marshal(object)
 	if err != nil {
 		return err
 	}
-	return w.conn.WriteMessage(textMessage, jsonBytes)
+	return conn.WriteMessage(textMessage, jsonBytes)
 }
 
-func (w *webSocketClient) waitForConnAck() error {
+// waitForConnAck waits for the server to acknowledge conn, which is not yet
+// in use, so needs no lock.
+func (w *webSocketClient) waitForConnAck(conn WSConn, protocol *webSocketProtocol) error {
+	// ReadMessage may block indefinitely, so to time out we must close the
+	// connection.
+	var timedOut atomic.Bool
+	if w.connAckTimeout > 0 {
+		timer := time.AfterFunc(w.connAckTimeout, func() {
+			timedOut.Store(true)
+			conn.Close()
+		})
+		defer timer.Stop()
+	}
+
 	var connAckReceived bool
 	var err error
-	start := time.Now()
 	for !connAckReceived {
-		connAckReceived, err = w.receiveWebSocketConnAck()
+		connAckReceived, err = receiveWebSocketConnAck(conn, protocol)
+		if timedOut.Load() {
+			return fmt.Errorf("timed out while waiting for connAck (> %v)", w.connAckTimeout)
+		}
 		if err != nil {
 			return err
 		}
-		if time.Since(start) > websocketConnAckTimeOut {
-			return fmt.Errorf("timed out while waiting for connAck (> %v)", websocketConnAckTimeOut)
-		}
 	}
 	return nil
 }
@@ -112,16 +310,37 @@ func (w *webSocketClient) handleErr(err error) {
 	}
 }
 
+func (w *webSocketClient) closing() bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.isClosing
+}
+
+func (w *webSocketClient) currentConn() WSConn {
+	w.connMu.Lock()
+	defer w.connMu.Unlock()
+	return w.conn
+}
+
 func (w *webSocketClient) listenWebSocket() {
+	defer close(w.listenerDone)
 	for {
-		if w.isClosing {
+		if w.closing() {
 			return
 		}
-		_, message, err := w.conn.ReadMessage()
+		_, message, err := w.currentConn().ReadMessage()
 		if err != nil {
+			err = w.takeKeepAliveErr(err)
+			if w.reconnectPolicy != nil && !w.closing() {
+				err = w.reconnect(err)
+				if err == nil {
+					continue
+				}
+			}
 			w.handleErr(err)
 			return
 		}
+		w.lastReceived.Store(time.Now().UnixNano())
 		err = w.forwardWebSocketData(message)
 		if err != nil {
 			w.handleErr(err)
@@ -130,96 +349,363 @@ func (w *webSocketClient) listenWebSocket() {
 	}
 }
 
+// takeKeepAliveErr returns the error with which keepAlive closed the
+// connection, if it did, or else err.
+func (w *webSocketClient) takeKeepAliveErr(err error) error {
+	w.Lock()
+	defer w.Unlock()
+	if w.keepAliveErr != nil {
+		err = w.keepAliveErr
+		w.keepAliveErr = nil
+	}
+	return err
+}
+
+// keepAlive checks that the server is alive every w.pingInterval, for as long
+// as conn is the current connection, and closes conn if not.  See
+// WithKeepAlive.
+func (w *webSocketClient) keepAlive(conn WSConn) {
+	ticker := time.NewTicker(w.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.reconnectCtx.Done():
+			return
+		}
+
+		sentAt := time.Now()
+		w.connMu.Lock()
+		current := w.conn == conn
+		var err error
+		if current && w.protocol.ping != "" {
+			err = w.sendStructAsJSON(webSocketTypeMessage{Type: w.protocol.ping})
+		}
+		w.connMu.Unlock()
+		if !current || err != nil {
+			return // if the write failed, the listener will see it too
+		}
+
+		timer := time.NewTimer(w.pongTimeout)
+		select {
+		case <-timer.C:
+		case <-w.reconnectCtx.Done():
+			timer.Stop()
+			return
+		}
+		if w.lastReceived.Load() < sentAt.UnixNano() {
+			w.Lock()
+			w.keepAliveErr = fmt.Errorf("server did not respond within %v", w.pongTimeout)
+			w.Unlock()
+			conn.Close()
+			return
+		}
+	}
+}
+
+// reconnect reconnects, according to w.reconnectPolicy, after the connection
+// was lost with the given error.  It returns an error if it gives up, or if
+// the client is closed in the meantime.
+func (w *webSocketClient) reconnect(cause error) error {
+	policy := w.reconnectPolicy
+	if policy.OnDisconnected != nil {
+		policy.OnDisconnected(cause)
+	}
+	w.currentConn().Close()
+
+	err := cause
+	attempt := 1
+	for ; policy.MaxAttempts < 0 || attempt <= policy.MaxAttempts; attempt++ {
+		if policy.OnReconnecting != nil {
+			policy.OnReconnecting(attempt)
+		}
+		timer := time.NewTimer(policy.Backoff.interval(attempt))
+		select {
+		case <-timer.C:
+		case <-w.reconnectCtx.Done():
+			timer.Stop()
+			return err
+		}
+
+		err = w.connect(w.reconnectCtx, true)
+		if w.metrics != nil {
+			w.metrics.RecordReconnect(err)
+		}
+		if err == nil {
+			if policy.OnConnected != nil {
+				policy.OnConnected()
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to reconnect after %d attempts: %w", attempt-1, err)
+}
+
 func (w *webSocketClient) forwardWebSocketData(message []byte) error {
 	var wsMsg webSocketReceiveMessage
 	err := json.Unmarshal(message, &wsMsg)
 	if err != nil {
 		return err
 	}
-	sub, ok := w.subscriptions.Read(wsMsg.ID)
-	if !ok {
-		return fmt.Errorf("received message for unknown subscription ID '%s'", wsMsg.ID)
+	switch {
+	case w.protocol.keepAlive != "" && wsMsg.Type == w.protocol.keepAlive,
+		w.protocol.pong != "" && wsMsg.Type == w.protocol.pong:
+		return nil // we already noted that we received something
+	case w.protocol.ping != "" && wsMsg.Type == w.protocol.ping:
+		w.connMu.Lock()
+		defer w.connMu.Unlock()
+		return w.sendStructAsJSON(webSocketTypeMessage{Type: w.protocol.pong})
 	}
-	if sub.hasBeenUnsubscribed {
+	sub, ok := w.subscriptions.Read(wsMsg.ID)
+	if !ok || sub.hasBeenUnsubscribed {
+		// Either we've unsubscribed, or it's a query or mutation which has
+		// since returned; either way the server may not know that yet.
 		return nil
 	}
-	if wsMsg.Type == webSocketTypeComplete {
-		reflect.ValueOf(sub.interfaceChan).Close()
-		return nil
+	if wsMsg.Type == w.protocol.complete {
+		// The subscription is over, so mark it as such, lest we resubscribe
+		// to it on reconnecting.
+		return w.subscriptions.Complete(wsMsg.ID)
+	}
+	if wsMsg.Type == w.protocol.operationError {
+		// The payload is the errors (or, in some servers, a single error),
+		// which we forward as a response; the subscription is then over.
+		payload := wsMsg.Payload
+		if !bytes.HasPrefix(bytes.TrimSpace(payload), []byte("[")) {
+			payload = append(append([]byte("["), payload...), ']')
+		}
+		w.subscriptions.Forward(wsMsg.ID,
+			append(append([]byte(`{"errors":`), payload...), '}'))
+		return w.subscriptions.Complete(wsMsg.ID)
 	}
 
-	return sub.forwardDataFunc(sub.interfaceChan, wsMsg.Payload)
+	w.subscriptions.Forward(wsMsg.ID, wsMsg.Payload)
+	return nil
 }
 
-func (w *webSocketClient) receiveWebSocketConnAck() (bool, error) {
-	_, message, err := w.conn.ReadMessage()
+func receiveWebSocketConnAck(conn WSConn, protocol *webSocketProtocol) (bool, error) {
+	_, message, err := conn.ReadMessage()
 	if err != nil {
 		return false, err
 	}
-	return checkConnectionAckReceived(message)
+	return checkConnectionAckReceived(message, protocol)
 }
 
-func checkConnectionAckReceived(message []byte) (bool, error) {
-	wsMessage := &webSocketSendMessage{}
+func checkConnectionAckReceived(message []byte, protocol *webSocketProtocol) (bool, error) {
+	wsMessage := &webSocketReceiveMessage{}
 	err := json.Unmarshal(message, wsMessage)
 	if err != nil {
 		return false, err
 	}
+	if wsMessage.Type == protocol.connectionError {
+		return false, fmt.Errorf("connection rejected: %s", wsMessage.Payload)
+	}
 	return wsMessage.Type == webSocketTypeConnAck, nil
 }
 
-func (w *webSocketClient) Start(ctx context.Context) (errChan chan error, err error) {
-	w.conn, err = w.Dialer.DialContext(ctx, w.endpoint, w.Header)
+// selectProtocol returns the protocol to speak on conn: the one the server
+// chose, if the connection can tell us (as does that of
+// [github.com/gorilla/websocket]), or else the first we asked for.
+func (w *webSocketClient) selectProtocol(conn WSConn) (*webSocketProtocol, error) {
+	var name string
+	if conn, ok := conn.(interface{ Subprotocol() string }); ok {
+		name = conn.Subprotocol()
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(w.Header.Get("Sec-WebSocket-Protocol"), ",")
+		name = strings.TrimSpace(name)
+	}
+	protocol, ok := webSocketProtocols[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported WebSocket subprotocol %q", name)
+	}
+	return protocol, nil
+}
+
+// connect opens a new connection, and waits for the server to acknowledge
+// it.  If resubscribe is set, it then resubscribes to each active
+// subscription.
+func (w *webSocketClient) connect(ctx context.Context, resubscribe bool) error {
+	conn, err := w.Dialer.DialContext(ctx, w.endpoint, w.Header)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	// Nobody else can use the connection until we swap it in, so we do the
+	// handshake without the lock.
+	protocol, err := w.selectProtocol(conn)
+	if err == nil {
+		err = w.sendInit(conn)
+	}
+	if err == nil {
+		err = w.waitForConnAck(conn, protocol)
 	}
-	err = w.sendInit()
 	if err != nil {
-		w.conn.Close()
-		return nil, err
+		conn.Close()
+		return err
+	}
+
+	// We hold the lock while we swap in the connection and resubscribe, so
+	// that a concurrent Subscribe or Unsubscribe happens either before (and
+	// is reflected in what we resubscribe to) or after.
+	w.connMu.Lock()
+	defer w.connMu.Unlock()
+	if w.closing() {
+		// Close ran while we were connecting, so it didn't see this
+		// connection, and won't close it.
+		conn.Close()
+		return errors.New("client was closed")
+	}
+	w.conn, w.protocol = conn, protocol
+	if resubscribe {
+		err = w.resubscribeAll()
+		if err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	w.lastReceived.Store(time.Now().UnixNano())
+	if w.pingInterval > 0 {
+		go w.keepAlive(conn)
+	}
+	return nil
+}
+
+// resubscribeAll resends the subscribe message for each active subscription.
+// The caller must hold w.connMu.
+func (w *webSocketClient) resubscribeAll() error {
+	for _, subscriptionID := range w.subscriptions.GetAllIDs() {
+		sub, ok := w.subscriptions.Read(subscriptionID)
+		if !ok || sub.hasBeenUnsubscribed {
+			continue
+		}
+		if sub.request == nil {
+			// A query or mutation from MakeRequest, which we can't safely
+			// repeat; instead, we tell MakeRequest it failed.
+			err := w.subscriptions.Unsubscribe(subscriptionID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err := w.sendStructAsJSON(webSocketSendMessage{
+			Type:    w.protocol.subscribe,
+			Payload: sub.request,
+			ID:      subscriptionID,
+		})
+		if err != nil {
+			return err
+		}
 	}
-	err = w.waitForConnAck()
+	return nil
+}
+
+func (w *webSocketClient) Start(ctx context.Context) (errChan chan error, err error) {
+	w.reconnectCtx, w.stopReconnecting = context.WithCancel(context.Background())
+	w.listenerDone = make(chan struct{})
+	w.contextConnParams = contextConnectionParams(ctx)
+	err = w.connect(ctx, false)
 	if err != nil {
-		w.conn.Close()
+		w.stopReconnecting()
 		return nil, err
 	}
+	if w.reconnectPolicy != nil && w.reconnectPolicy.OnConnected != nil {
+		w.reconnectPolicy.OnConnected()
+	}
 	go w.listenWebSocket()
 	return w.errChan, err
 }
 
 func (w *webSocketClient) Close() error {
-	if w.conn == nil {
+	if w.currentConn() == nil {
 		return nil
 	}
-	err := w.conn.WriteMessage(closeMessage, formatCloseMessage(closeNormalClosure, ""))
-	if err != nil {
-		return fmt.Errorf("failed to send closure message: %w", err)
+	w.Lock()
+	if w.isClosing {
+		w.Unlock()
+		return nil
 	}
-	err = w.UnsubscribeAll()
+	// We're closing, so the listener should no longer report errors (or
+	// reconnect) once the connection closes.
+	w.isClosing = true
+	w.Unlock()
+	w.stopReconnecting()
+
+	err := w.UnsubscribeAll()
 	if err != nil {
-		return fmt.Errorf("failed to unsubscribe: %w", err)
+		err = fmt.Errorf("failed to unsubscribe: %w", err)
 	}
+
+	w.connMu.Lock()
+	defer w.connMu.Unlock()
+	if w.protocol.terminate != "" && err == nil {
+		err = w.sendStructAsJSON(webSocketSendMessage{Type: w.protocol.terminate})
+		if err != nil {
+			err = fmt.Errorf("failed to send termination message: %w", err)
+		}
+	}
+	if err == nil {
+		err = w.conn.WriteMessage(closeMessage, formatCloseMessage(closeNormalClosure, ""))
+		if err != nil {
+			err = fmt.Errorf("failed to send closure message: %w", err)
+		}
+	}
+
 	w.Lock()
-	defer w.Unlock()
-	w.isClosing = true
 	close(w.errChan)
-	return w.conn.Close()
+	w.Unlock()
+	closeErr := w.conn.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// checkSubscriptionRequest returns an error if req is a query or mutation,
+// which subscription-only clients don't support.
+func checkSubscriptionRequest(req *Request) error {
+	switch req.OperationType() {
+	case ast.Query:
+		return fmt.Errorf("client does not support queries")
+	case ast.Mutation:
+		return fmt.Errorf("client does not support mutations")
+	}
+	return nil
 }
 
 func (w *webSocketClient) Subscribe(req *Request, interfaceChan interface{}, forwardDataFunc ForwardDataFunction) (string, error) {
-	if req.Query != "" {
-		if strings.HasPrefix(strings.TrimSpace(req.Query), "query") {
-			return "", fmt.Errorf("client does not support queries")
-		}
-		if strings.HasPrefix(strings.TrimSpace(req.Query), "mutation") {
-			return "", fmt.Errorf("client does not support mutations")
-		}
+	err := checkSubscriptionRequest(req)
+	if err != nil {
+		return "", err
 	}
 
+	return w.startOperation(req, true, interfaceChan, forwardDataFunc)
+}
+
+// startOperation starts the given operation, as for Subscribe.  If
+// resubscribe is set, the operation is restarted if the client reconnects;
+// otherwise it just ends.
+func (w *webSocketClient) startOperation(
+	req *Request,
+	resubscribe bool,
+	interfaceChan interface{},
+	forwardDataFunc ForwardDataFunction,
+) (string, error) {
+	// We hold the lock while we create the subscription and send it, so that
+	// if we're reconnecting, we send it exactly once.
+	w.connMu.Lock()
+	defer w.connMu.Unlock()
+	if w.conn == nil {
+		return "", errors.New("client was not started")
+	}
 	subscriptionID := uuid.NewString()
-	w.subscriptions.Create(subscriptionID, interfaceChan, forwardDataFunc)
+	resubscribeReq := req
+	if !resubscribe {
+		resubscribeReq = nil
+	}
+	w.subscriptions.Create(subscriptionID, resubscribeReq, interfaceChan, forwardDataFunc)
 	subscriptionMsg := webSocketSendMessage{
-		Type:    webSocketTypeSubscribe,
+		Type:    w.protocol.subscribe,
 		Payload: req,
 		ID:      subscriptionID,
 	}
@@ -231,9 +717,99 @@ func (w *webSocketClient) Subscribe(req *Request, interfaceChan interface{}, for
 	return subscriptionID, nil
 }
 
+// MakeRequest implements [Client], making a query or mutation over the
+// WebSocket connection, which must already have been started.  It returns
+// once the server completes the operation.
+//
+// Each subscription's data is forwarded to its channel separately, so a
+// subscription whose channel isn't being read doesn't hold up the results
+// of queries and mutations, or the data of other subscriptions.
+//
+// If the connection is lost while the operation is in progress, MakeRequest
+// returns an error; even if the client reconnects, the operation is not
+// retried, since it may not be safe to repeat.
+func (w *webSocketClient) MakeRequest(ctx context.Context, req *Request, resp *Response) error {
+	if w.metrics == nil {
+		return w.makeOperation(ctx, req, resp)
+	}
+	m, start := &RequestMetrics{OpName: req.OpName}, time.Now()
+	if body, err := json.Marshal(req); err == nil {
+		m.RequestSize = int64(len(body))
+	}
+	err := w.makeOperation(withRequestMetrics(ctx, m), req, resp)
+	recordRequest(ctx, w.metrics, m, start, err)
+	return err
+}
+
+// makeOperation implements MakeRequest, apart from metrics.
+func (w *webSocketClient) makeOperation(ctx context.Context, req *Request, resp *Response) error {
+	if req.OperationType() == ast.Subscription {
+		return errors.New("client does not support subscriptions via MakeRequest; use Subscribe")
+	}
+
+	// We want only the last result, so forward replaces any we haven't yet
+	// read.  (It's only ever called from the one goroutine, which also closes
+	// results once the operation is over.)
+	results := make(chan json.RawMessage, 1)
+	forward := func(_ interface{}, payload json.RawMessage) error {
+		select {
+		case <-results:
+		default:
+		}
+		results <- payload
+		return nil
+	}
+	operationID, err := w.startOperation(req, false, results, forward)
+	if err != nil {
+		return err
+	}
+	defer w.subscriptions.Delete(operationID)
+
+	var result json.RawMessage
+	listenerDone := w.listenerDone
+	for done := false; !done; {
+		select {
+		case payload, more := <-results:
+			if more {
+				result = payload
+			} else {
+				done = true
+			}
+		case <-ctx.Done():
+			_ = w.Unsubscribe(operationID)
+			return ctx.Err()
+		case <-listenerDone:
+			// The listener may have received the result just before it
+			// returned, but if not it never will; so we end the operation
+			// once whatever it received has been forwarded.
+			_ = w.subscriptions.Complete(operationID)
+			listenerDone = nil
+		}
+	}
+	if result == nil {
+		return errors.New("connection was lost before the operation completed")
+	}
+	if m := requestMetrics(ctx); m != nil {
+		m.ResponseSize = int64(len(result))
+	}
+
+	err = json.Unmarshal(result, resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
 func (w *webSocketClient) Unsubscribe(subscriptionID string) error {
+	// As in Subscribe, we hold the lock so we don't resubscribe after
+	// unsubscribing.
+	w.connMu.Lock()
+	defer w.connMu.Unlock()
 	completeMsg := webSocketSendMessage{
-		Type: webSocketTypeComplete,
+		Type: w.protocol.unsubscribe,
 		ID:   subscriptionID,
 	}
 	err := w.sendStructAsJSON(completeMsg)
@@ -250,6 +826,10 @@ func (w *webSocketClient) Unsubscribe(subscriptionID string) error {
 func (w *webSocketClient) UnsubscribeAll() error {
 	subscriptionIDs := w.subscriptions.GetAllIDs()
 	for _, subscriptionID := range subscriptionIDs {
+		sub, ok := w.subscriptions.Read(subscriptionID)
+		if !ok || sub.hasBeenUnsubscribed {
+			continue
+		}
 		err := w.Unsubscribe(subscriptionID)
 		if err != nil {
 			return err
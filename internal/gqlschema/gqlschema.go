@@ -0,0 +1,55 @@
+// Package gqlschema loads GraphQL schemas, for use by both genqlient's code
+// generator and its test utilities.
+package gqlschema
+
+import (
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+	_ "github.com/vektah/gqlparser/v2/validator/rules"
+)
+
+// Load parses and validates the schema in the given sources.
+//
+// If the schema doesn't parse or isn't valid, the error is a
+// [*gqlerror.Error], which includes the position of the problem.
+func Load(sources ...*ast.Source) (*ast.Schema, error) {
+	// Ideally here we'd just call gqlparser.LoadSchema. But the schema we are
+	// given may or may not contain the builtin types String, Int, etc. (The
+	// spec says it shouldn't, but introspection will return those types, and
+	// some introspection-to-SDL tools aren't smart enough to remove them.) So
+	// we inline LoadSchema and insert some checks.
+	document, graphqlError := parser.ParseSchemas(sources...)
+	if graphqlError != nil {
+		// Schema doesn't even parse.
+		return nil, graphqlError
+	}
+
+	// Check if we have a builtin type. (String is an arbitrary choice.)
+	hasBuiltins := false
+	for _, def := range document.Definitions {
+		if def.Name == "String" {
+			hasBuiltins = true
+			break
+		}
+	}
+
+	if !hasBuiltins {
+		// modified from parser.ParseSchemas
+		var preludeAST *ast.SchemaDocument
+		preludeAST, graphqlError = parser.ParseSchema(validator.Prelude)
+		if graphqlError != nil {
+			return nil, fmt.Errorf("invalid prelude (probably a gqlparser bug): %w", graphqlError)
+		}
+		document.Merge(preludeAST)
+	}
+
+	schema, graphqlError := validator.ValidateSchemaDocument(document)
+	if graphqlError != nil {
+		return nil, graphqlError
+	}
+
+	return schema, nil
+}
@@ -273,6 +273,22 @@ func (v *AnimalFieldsOwnerUser) __premarshalJSON() (*__premarshalAnimalFieldsOwn
 	return &retval, nil
 }
 
+// DeferredUserFields includes the GraphQL fields of User requested by the fragment DeferredUserFields.
+type DeferredUserFields struct {
+	Hair DeferredUserFieldsHair `json:"hair"`
+}
+
+// GetHair returns DeferredUserFields.Hair, and is useful for accessing the field via an interface.
+func (v *DeferredUserFields) GetHair() DeferredUserFieldsHair { return v.Hair }
+
+// DeferredUserFieldsHair includes the requested fields of the GraphQL type Hair.
+type DeferredUserFieldsHair struct {
+	Color string `json:"color"`
+}
+
+// GetColor returns DeferredUserFieldsHair.Color, and is useful for accessing the field via an interface.
+func (v *DeferredUserFieldsHair) GetColor() string { return v.Color }
+
 // FriendsFields includes the GraphQL fields of User requested by the fragment FriendsFields.
 type FriendsFields struct {
 	Id   string `json:"id"`
@@ -1247,6 +1263,14 @@ func (v *__queryWithCustomMarshalSliceInput) __premarshalJSON() (*__premarshal__
 	return &retval, nil
 }
 
+// __queryWithDeferInput is used internally by genqlient
+type __queryWithDeferInput struct {
+	Id string `json:"id"`
+}
+
+// GetId returns __queryWithDeferInput.Id, and is useful for accessing the field via an interface.
+func (v *__queryWithDeferInput) GetId() string { return v.Id }
+
 // __queryWithFlattenInput is used internally by genqlient
 type __queryWithFlattenInput struct {
 	Ids []string `json:"ids"`
@@ -1311,6 +1335,18 @@ type __queryWithVariablesInput struct {
 // GetId returns __queryWithVariablesInput.Id, and is useful for accessing the field via an interface.
 func (v *__queryWithVariablesInput) GetId() string { return v.Id }
 
+// __uploadFilesInput is used internally by genqlient
+type __uploadFilesInput struct {
+	Files  []graphql.Upload `json:"files"`
+	Avatar graphql.Upload   `json:"avatar"`
+}
+
+// GetFiles returns __uploadFilesInput.Files, and is useful for accessing the field via an interface.
+func (v *__uploadFilesInput) GetFiles() []graphql.Upload { return v.Files }
+
+// GetAvatar returns __uploadFilesInput.Avatar, and is useful for accessing the field via an interface.
+func (v *__uploadFilesInput) GetAvatar() graphql.Upload { return v.Avatar }
+
 // countAuthorizedResponse is returned by countAuthorized on success.
 type countAuthorizedResponse struct {
 	CountAuthorized int `json:"countAuthorized"`
@@ -1319,6 +1355,11 @@ type countAuthorizedResponse struct {
 // GetCountAuthorized returns countAuthorizedResponse.CountAuthorized, and is useful for accessing the field via an interface.
 func (v *countAuthorizedResponse) GetCountAuthorized() int { return v.CountAuthorized }
 
+// CountAuthorizedFailed returns true if countAuthorizedResponse.CountAuthorized was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *countAuthorizedResponse) CountAuthorizedFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "countAuthorized")) > 0
+}
+
 // countResponse is returned by count on success.
 type countResponse struct {
 	Count int `json:"count"`
@@ -1327,6 +1368,11 @@ type countResponse struct {
 // GetCount returns countResponse.Count, and is useful for accessing the field via an interface.
 func (v *countResponse) GetCount() int { return v.Count }
 
+// CountFailed returns true if countResponse.Count was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *countResponse) CountFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "count")) > 0
+}
+
 // createUserCreateUser includes the requested fields of the GraphQL type User.
 type createUserCreateUser struct {
 	Id   string `json:"id"`
@@ -1347,6 +1393,11 @@ type createUserResponse struct {
 // GetCreateUser returns createUserResponse.CreateUser, and is useful for accessing the field via an interface.
 func (v *createUserResponse) GetCreateUser() createUserCreateUser { return v.CreateUser }
 
+// CreateUserFailed returns true if createUserResponse.CreateUser was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *createUserResponse) CreateUserFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "createUser")) > 0
+}
+
 // failingQueryMeUser includes the requested fields of the GraphQL type User.
 type failingQueryMeUser struct {
 	Id string `json:"id"`
@@ -1367,6 +1418,16 @@ func (v *failingQueryResponse) GetFail() bool { return v.Fail }
 // GetMe returns failingQueryResponse.Me, and is useful for accessing the field via an interface.
 func (v *failingQueryResponse) GetMe() failingQueryMeUser { return v.Me }
 
+// FailFailed returns true if failingQueryResponse.Fail was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *failingQueryResponse) FailFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "fail")) > 0
+}
+
+// MeFailed returns true if failingQueryResponse.Me was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *failingQueryResponse) MeFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "me")) > 0
+}
+
 // queryWithCustomMarshalOptionalResponse is returned by queryWithCustomMarshalOptional on success.
 type queryWithCustomMarshalOptionalResponse struct {
 	UserSearch []queryWithCustomMarshalOptionalUserSearchUser `json:"userSearch"`
@@ -1377,6 +1438,11 @@ func (v *queryWithCustomMarshalOptionalResponse) GetUserSearch() []queryWithCust
 	return v.UserSearch
 }
 
+// UserSearchFailed returns true if queryWithCustomMarshalOptionalResponse.UserSearch was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithCustomMarshalOptionalResponse) UserSearchFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "userSearch")) > 0
+}
+
 // queryWithCustomMarshalOptionalUserSearchUser includes the requested fields of the GraphQL type User.
 type queryWithCustomMarshalOptionalUserSearchUser struct {
 	Id        string    `json:"id"`
@@ -1472,6 +1538,11 @@ func (v *queryWithCustomMarshalResponse) GetUsersBornOn() []queryWithCustomMarsh
 	return v.UsersBornOn
 }
 
+// UsersBornOnFailed returns true if queryWithCustomMarshalResponse.UsersBornOn was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithCustomMarshalResponse) UsersBornOnFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "usersBornOn")) > 0
+}
+
 // queryWithCustomMarshalSliceResponse is returned by queryWithCustomMarshalSlice on success.
 type queryWithCustomMarshalSliceResponse struct {
 	UsersBornOnDates []queryWithCustomMarshalSliceUsersBornOnDatesUser `json:"usersBornOnDates"`
@@ -1482,6 +1553,11 @@ func (v *queryWithCustomMarshalSliceResponse) GetUsersBornOnDates() []queryWithC
 	return v.UsersBornOnDates
 }
 
+// UsersBornOnDatesFailed returns true if queryWithCustomMarshalSliceResponse.UsersBornOnDates was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithCustomMarshalSliceResponse) UsersBornOnDatesFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "usersBornOnDates")) > 0
+}
+
 // queryWithCustomMarshalSliceUsersBornOnDatesUser includes the requested fields of the GraphQL type User.
 type queryWithCustomMarshalSliceUsersBornOnDatesUser struct {
 	Id        string    `json:"id"`
@@ -1654,6 +1730,110 @@ func (v *queryWithCustomMarshalUsersBornOnUser) __premarshalJSON() (*__premarsha
 	return &retval, nil
 }
 
+// queryWithDeferResponse is returned by queryWithDefer on success.
+type queryWithDeferResponse struct {
+	User queryWithDeferUser `json:"user"`
+}
+
+// GetUser returns queryWithDeferResponse.User, and is useful for accessing the field via an interface.
+func (v *queryWithDeferResponse) GetUser() queryWithDeferUser { return v.User }
+
+// UserFailed returns true if queryWithDeferResponse.User was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithDeferResponse) UserFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "user")) > 0
+}
+
+// queryWithDeferUser includes the requested fields of the GraphQL type User.
+type queryWithDeferUser struct {
+	Id                 string `json:"id"`
+	Name               string `json:"name"`
+	DeferredUserFields `json:"-"`
+	// Whether the deferred fragment DeferredUserFields has been received.
+	DeferredUserFieldsReceived graphql.Received `json:"genqlient_deferred_DeferredUserFields"`
+	LuckyNumber                int              `json:"luckyNumber"`
+	// Whether the deferred fragment lucky has been received.
+	LuckyReceived graphql.Received `json:"genqlient_deferred_lucky"`
+}
+
+// GetId returns queryWithDeferUser.Id, and is useful for accessing the field via an interface.
+func (v *queryWithDeferUser) GetId() string { return v.Id }
+
+// GetName returns queryWithDeferUser.Name, and is useful for accessing the field via an interface.
+func (v *queryWithDeferUser) GetName() string { return v.Name }
+
+// GetDeferredUserFieldsReceived returns queryWithDeferUser.DeferredUserFieldsReceived, and is useful for accessing the field via an interface.
+func (v *queryWithDeferUser) GetDeferredUserFieldsReceived() graphql.Received {
+	return v.DeferredUserFieldsReceived
+}
+
+// GetLuckyNumber returns queryWithDeferUser.LuckyNumber, and is useful for accessing the field via an interface.
+func (v *queryWithDeferUser) GetLuckyNumber() int { return v.LuckyNumber }
+
+// GetLuckyReceived returns queryWithDeferUser.LuckyReceived, and is useful for accessing the field via an interface.
+func (v *queryWithDeferUser) GetLuckyReceived() graphql.Received { return v.LuckyReceived }
+
+// GetHair returns queryWithDeferUser.Hair, and is useful for accessing the field via an interface.
+func (v *queryWithDeferUser) GetHair() DeferredUserFieldsHair { return v.DeferredUserFields.Hair }
+
+func (v *queryWithDeferUser) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*queryWithDeferUser
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.queryWithDeferUser = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(
+		b, &v.DeferredUserFields)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type __premarshalqueryWithDeferUser struct {
+	Id string `json:"id"`
+
+	Name string `json:"name"`
+
+	DeferredUserFieldsReceived graphql.Received `json:"genqlient_deferred_DeferredUserFields"`
+
+	LuckyNumber int `json:"luckyNumber"`
+
+	LuckyReceived graphql.Received `json:"genqlient_deferred_lucky"`
+
+	Hair DeferredUserFieldsHair `json:"hair"`
+}
+
+func (v *queryWithDeferUser) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *queryWithDeferUser) __premarshalJSON() (*__premarshalqueryWithDeferUser, error) {
+	var retval __premarshalqueryWithDeferUser
+
+	retval.Id = v.Id
+	retval.Name = v.Name
+	retval.DeferredUserFieldsReceived = v.DeferredUserFieldsReceived
+	retval.LuckyNumber = v.LuckyNumber
+	retval.LuckyReceived = v.LuckyReceived
+	retval.Hair = v.DeferredUserFields.Hair
+	return &retval, nil
+}
+
 // queryWithFragmentsBeingsAnimal includes the requested fields of the GraphQL type Animal.
 type queryWithFragmentsBeingsAnimal struct {
 	Typename string                                       `json:"__typename"`
@@ -2004,6 +2184,11 @@ type queryWithFragmentsResponse struct {
 // GetBeings returns queryWithFragmentsResponse.Beings, and is useful for accessing the field via an interface.
 func (v *queryWithFragmentsResponse) GetBeings() []queryWithFragmentsBeingsBeing { return v.Beings }
 
+// BeingsFailed returns true if queryWithFragmentsResponse.Beings was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithFragmentsResponse) BeingsFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "beings")) > 0
+}
+
 func (v *queryWithFragmentsResponse) UnmarshalJSON(b []byte) error {
 
 	if string(b) == "null" {
@@ -2198,6 +2383,11 @@ func (v *queryWithInterfaceListFieldResponse) GetBeings() []queryWithInterfaceLi
 	return v.Beings
 }
 
+// BeingsFailed returns true if queryWithInterfaceListFieldResponse.Beings was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithInterfaceListFieldResponse) BeingsFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "beings")) > 0
+}
+
 func (v *queryWithInterfaceListFieldResponse) UnmarshalJSON(b []byte) error {
 
 	if string(b) == "null" {
@@ -2392,6 +2582,11 @@ func (v *queryWithInterfaceListPointerFieldResponse) GetBeings() []*queryWithInt
 	return v.Beings
 }
 
+// BeingsFailed returns true if queryWithInterfaceListPointerFieldResponse.Beings was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithInterfaceListPointerFieldResponse) BeingsFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "beings")) > 0
+}
+
 func (v *queryWithInterfaceListPointerFieldResponse) UnmarshalJSON(b []byte) error {
 
 	if string(b) == "null" {
@@ -2607,6 +2802,16 @@ func (v *queryWithInterfaceNoFragmentsResponse) GetMe() queryWithInterfaceNoFrag
 	return v.Me
 }
 
+// BeingFailed returns true if queryWithInterfaceNoFragmentsResponse.Being was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithInterfaceNoFragmentsResponse) BeingFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "being")) > 0
+}
+
+// MeFailed returns true if queryWithInterfaceNoFragmentsResponse.Me was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithInterfaceNoFragmentsResponse) MeFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "me")) > 0
+}
+
 func (v *queryWithInterfaceNoFragmentsResponse) UnmarshalJSON(b []byte) error {
 
 	if string(b) == "null" {
@@ -2929,6 +3134,11 @@ func (v *queryWithNamedFragmentsResponse) GetBeings() []queryWithNamedFragmentsB
 	return v.Beings
 }
 
+// BeingsFailed returns true if queryWithNamedFragmentsResponse.Beings was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithNamedFragmentsResponse) BeingsFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "beings")) > 0
+}
+
 func (v *queryWithNamedFragmentsResponse) UnmarshalJSON(b []byte) error {
 
 	if string(b) == "null" {
@@ -3012,6 +3222,11 @@ type queryWithOmitemptyResponse struct {
 // GetUser returns queryWithOmitemptyResponse.User, and is useful for accessing the field via an interface.
 func (v *queryWithOmitemptyResponse) GetUser() queryWithOmitemptyUser { return v.User }
 
+// UserFailed returns true if queryWithOmitemptyResponse.User was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithOmitemptyResponse) UserFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "user")) > 0
+}
+
 // queryWithOmitemptyUser includes the requested fields of the GraphQL type User.
 type queryWithOmitemptyUser struct {
 	Id          string `json:"id"`
@@ -3036,6 +3251,11 @@ type queryWithVariablesResponse struct {
 // GetUser returns queryWithVariablesResponse.User, and is useful for accessing the field via an interface.
 func (v *queryWithVariablesResponse) GetUser() queryWithVariablesUser { return v.User }
 
+// UserFailed returns true if queryWithVariablesResponse.User was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *queryWithVariablesResponse) UserFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "user")) > 0
+}
+
 // queryWithVariablesUser includes the requested fields of the GraphQL type User.
 type queryWithVariablesUser struct {
 	Id          string `json:"id"`
@@ -3076,6 +3296,11 @@ type simpleQueryExtResponse struct {
 // GetMe returns simpleQueryExtResponse.Me, and is useful for accessing the field via an interface.
 func (v *simpleQueryExtResponse) GetMe() simpleQueryExtMeUser { return v.Me }
 
+// MeFailed returns true if simpleQueryExtResponse.Me was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *simpleQueryExtResponse) MeFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "me")) > 0
+}
+
 // simpleQueryMeUser includes the requested fields of the GraphQL type User.
 type simpleQueryMeUser struct {
 	Id          string        `json:"id"`
@@ -3104,6 +3329,42 @@ type simpleQueryResponse struct {
 // GetMe returns simpleQueryResponse.Me, and is useful for accessing the field via an interface.
 func (v *simpleQueryResponse) GetMe() simpleQueryMeUser { return v.Me }
 
+// MeFailed returns true if simpleQueryResponse.Me was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *simpleQueryResponse) MeFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "me")) > 0
+}
+
+// uploadFilesResponse is returned by uploadFiles on success.
+type uploadFilesResponse struct {
+	UploadFiles []uploadFilesUploadFilesUploadedFile `json:"uploadFiles"`
+}
+
+// GetUploadFiles returns uploadFilesResponse.UploadFiles, and is useful for accessing the field via an interface.
+func (v *uploadFilesResponse) GetUploadFiles() []uploadFilesUploadFilesUploadedFile {
+	return v.UploadFiles
+}
+
+// UploadFilesFailed returns true if uploadFilesResponse.UploadFiles was nulled, in whole or in part, by an error in err, which should be the error returned with the response, or if v is nil, since then there's no data at all.
+func (v *uploadFilesResponse) UploadFilesFailed(err error) bool {
+	return v == nil || len(graphql.ErrorsAt(err, "uploadFiles")) > 0
+}
+
+// uploadFilesUploadFilesUploadedFile includes the requested fields of the GraphQL type UploadedFile.
+type uploadFilesUploadFilesUploadedFile struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Contents    string `json:"contents"`
+}
+
+// GetFilename returns uploadFilesUploadFilesUploadedFile.Filename, and is useful for accessing the field via an interface.
+func (v *uploadFilesUploadFilesUploadedFile) GetFilename() string { return v.Filename }
+
+// GetContentType returns uploadFilesUploadFilesUploadedFile.ContentType, and is useful for accessing the field via an interface.
+func (v *uploadFilesUploadFilesUploadedFile) GetContentType() string { return v.ContentType }
+
+// GetContents returns uploadFilesUploadFilesUploadedFile.Contents, and is useful for accessing the field via an interface.
+func (v *uploadFilesUploadFilesUploadedFile) GetContents() string { return v.Contents }
+
 // The subscription executed by count.
 const count_Operation = `
 subscription count {
@@ -3111,14 +3372,19 @@ subscription count {
 }
 `
 
+// The SHA-256 hash of count_Operation, used for automatic persisted queries.
+const count_OperationHash = "135c353beb00950297262a3dad0920fc50fe6967e46ef47c189e1c51b61043aa"
+
 // To unsubscribe, use [graphql.WebSocketClient.Unsubscribe]
 func count(
 	ctx_ context.Context,
 	client_ graphql.WebSocketClient,
 ) (dataChan_ chan countWsResponse, subscriptionID_ string, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "count",
-		Query:  count_Operation,
+		OpName:     "count",
+		Query:      count_Operation,
+		QueryHash:  count_OperationHash,
+		DocumentID: count_OperationHash,
 	}
 
 	dataChan_ = make(chan countWsResponse)
@@ -3163,14 +3429,19 @@ subscription countAuthorized {
 }
 `
 
+// The SHA-256 hash of countAuthorized_Operation, used for automatic persisted queries.
+const countAuthorized_OperationHash = "0d06000c48138ee0c54464f89c00fe668cb16c670d33b69709f45aee8f494793"
+
 // To unsubscribe, use [graphql.WebSocketClient.Unsubscribe]
 func countAuthorized(
 	ctx_ context.Context,
 	client_ graphql.WebSocketClient,
 ) (dataChan_ chan countAuthorizedWsResponse, subscriptionID_ string, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "countAuthorized",
-		Query:  countAuthorized_Operation,
+		OpName:     "countAuthorized",
+		Query:      countAuthorized_Operation,
+		QueryHash:  countAuthorized_OperationHash,
+		DocumentID: countAuthorized_OperationHash,
 	}
 
 	dataChan_ = make(chan countAuthorizedWsResponse)
@@ -3218,14 +3489,19 @@ mutation createUser ($user: NewUser!) {
 }
 `
 
+// The SHA-256 hash of createUser_Operation, used for automatic persisted queries.
+const createUser_OperationHash = "d0b498bbb68833246cbc87183ca90fca732ee34b9754641d2c8b5f64a4eb870e"
+
 func createUser(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	user NewUser,
 ) (data_ *createUserResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "createUser",
-		Query:  createUser_Operation,
+		OpName:     "createUser",
+		Query:      createUser_Operation,
+		QueryHash:  createUser_OperationHash,
+		DocumentID: createUser_OperationHash,
 		Variables: &__createUserInput{
 			User: user,
 		},
@@ -3253,13 +3529,18 @@ query failingQuery {
 }
 `
 
+// The SHA-256 hash of failingQuery_Operation, used for automatic persisted queries.
+const failingQuery_OperationHash = "677a2f3124249b91399f40eb74f0ff420686619d9c1009575ac5cd3931f39f49"
+
 func failingQuery(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *failingQueryResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "failingQuery",
-		Query:  failingQuery_Operation,
+		OpName:     "failingQuery",
+		Query:      failingQuery_Operation,
+		QueryHash:  failingQuery_OperationHash,
+		DocumentID: failingQuery_OperationHash,
 	}
 
 	data_ = &failingQueryResponse{}
@@ -3285,14 +3566,19 @@ query queryWithCustomMarshal ($date: Date!) {
 }
 `
 
+// The SHA-256 hash of queryWithCustomMarshal_Operation, used for automatic persisted queries.
+const queryWithCustomMarshal_OperationHash = "e5f8b30b3d68d57121d7478381d85bcf1a6305d45eb66d3ea3d21fc044a8c4c7"
+
 func queryWithCustomMarshal(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	date time.Time,
 ) (data_ *queryWithCustomMarshalResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithCustomMarshal",
-		Query:  queryWithCustomMarshal_Operation,
+		OpName:     "queryWithCustomMarshal",
+		Query:      queryWithCustomMarshal_Operation,
+		QueryHash:  queryWithCustomMarshal_OperationHash,
+		DocumentID: queryWithCustomMarshal_OperationHash,
 		Variables: &__queryWithCustomMarshalInput{
 			Date: date,
 		},
@@ -3321,6 +3607,9 @@ query queryWithCustomMarshalOptional ($date: Date, $id: ID) {
 }
 `
 
+// The SHA-256 hash of queryWithCustomMarshalOptional_Operation, used for automatic persisted queries.
+const queryWithCustomMarshalOptional_OperationHash = "6a6c56e3e3ed25e821621f75aac8d607b71cda40e42a3f0548d9b72d730737d3"
+
 func queryWithCustomMarshalOptional(
 	ctx_ context.Context,
 	client_ graphql.Client,
@@ -3328,8 +3617,10 @@ func queryWithCustomMarshalOptional(
 	id *string,
 ) (data_ *queryWithCustomMarshalOptionalResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithCustomMarshalOptional",
-		Query:  queryWithCustomMarshalOptional_Operation,
+		OpName:     "queryWithCustomMarshalOptional",
+		Query:      queryWithCustomMarshalOptional_Operation,
+		QueryHash:  queryWithCustomMarshalOptional_OperationHash,
+		DocumentID: queryWithCustomMarshalOptional_OperationHash,
 		Variables: &__queryWithCustomMarshalOptionalInput{
 			Date: date,
 			Id:   id,
@@ -3359,14 +3650,19 @@ query queryWithCustomMarshalSlice ($dates: [Date!]!) {
 }
 `
 
+// The SHA-256 hash of queryWithCustomMarshalSlice_Operation, used for automatic persisted queries.
+const queryWithCustomMarshalSlice_OperationHash = "7835c46bb7f3f798972928efbea9e82fbd365b9e138e7d0c09faae7f61214f61"
+
 func queryWithCustomMarshalSlice(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	dates []time.Time,
 ) (data_ *queryWithCustomMarshalSliceResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithCustomMarshalSlice",
-		Query:  queryWithCustomMarshalSlice_Operation,
+		OpName:     "queryWithCustomMarshalSlice",
+		Query:      queryWithCustomMarshalSlice_Operation,
+		QueryHash:  queryWithCustomMarshalSlice_OperationHash,
+		DocumentID: queryWithCustomMarshalSlice_OperationHash,
 		Variables: &__queryWithCustomMarshalSliceInput{
 			Dates: dates,
 		},
@@ -3384,6 +3680,59 @@ func queryWithCustomMarshalSlice(
 	return data_, resp_.Extensions, err_
 }
 
+// The query executed by queryWithDefer.
+const queryWithDefer_Operation = `
+query queryWithDefer ($id: ID!) {
+	user(id: $id) {
+		id
+		name
+		... on User @defer(label: "DeferredUserFields") {
+			... DeferredUserFields
+			genqlient_deferred_DeferredUserFields: __typename
+		}
+		... on User @defer(label: "lucky") {
+			luckyNumber
+			genqlient_deferred_lucky: __typename
+		}
+	}
+}
+fragment DeferredUserFields on User {
+	hair {
+		color
+	}
+}
+`
+
+// The SHA-256 hash of queryWithDefer_Operation, used for automatic persisted queries.
+const queryWithDefer_OperationHash = "37304e386b829a32f769ee5a52100c514f5384b37d106af1c76acd79e349905f"
+
+func queryWithDefer(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	id string,
+) (data_ *queryWithDeferResponse, ext_ map[string]interface{}, err_ error) {
+	req_ := &graphql.Request{
+		OpName:     "queryWithDefer",
+		Query:      queryWithDefer_Operation,
+		QueryHash:  queryWithDefer_OperationHash,
+		DocumentID: queryWithDefer_OperationHash,
+		Variables: &__queryWithDeferInput{
+			Id: id,
+		},
+	}
+
+	data_ = &queryWithDeferResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, resp_.Extensions, err_
+}
+
 // The query executed by queryWithFlatten.
 const queryWithFlatten_Operation = `
 query queryWithFlatten ($ids: [ID!]!) {
@@ -3429,14 +3778,19 @@ fragment FriendsFields on User {
 }
 `
 
+// The SHA-256 hash of queryWithFlatten_Operation, used for automatic persisted queries.
+const queryWithFlatten_OperationHash = "12c67d5c437a31c50ba4fe38a93c7d1fd6d399b4b9fc4d93c3d1400ce0933b31"
+
 func queryWithFlatten(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	ids []string,
 ) (data_ *QueryFragment, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithFlatten",
-		Query:  queryWithFlatten_Operation,
+		OpName:     "queryWithFlatten",
+		Query:      queryWithFlatten_Operation,
+		QueryHash:  queryWithFlatten_OperationHash,
+		DocumentID: queryWithFlatten_OperationHash,
 		Variables: &__queryWithFlattenInput{
 			Ids: ids,
 		},
@@ -3493,14 +3847,19 @@ query queryWithFragments ($ids: [ID!]!) {
 }
 `
 
+// The SHA-256 hash of queryWithFragments_Operation, used for automatic persisted queries.
+const queryWithFragments_OperationHash = "3a1dafc6818a11c96a84ae3833deca81c7cb9ed7c56792ec89a43531129084c3"
+
 func queryWithFragments(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	ids []string,
 ) (data_ *queryWithFragmentsResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithFragments",
-		Query:  queryWithFragments_Operation,
+		OpName:     "queryWithFragments",
+		Query:      queryWithFragments_Operation,
+		QueryHash:  queryWithFragments_OperationHash,
+		DocumentID: queryWithFragments_OperationHash,
 		Variables: &__queryWithFragmentsInput{
 			Ids: ids,
 		},
@@ -3529,14 +3888,19 @@ query queryWithInterfaceListField ($ids: [ID!]!) {
 }
 `
 
+// The SHA-256 hash of queryWithInterfaceListField_Operation, used for automatic persisted queries.
+const queryWithInterfaceListField_OperationHash = "c0fca2eaea7c8afd42281e7ba818ed8438a6c6fd0462f566df840f329add6304"
+
 func queryWithInterfaceListField(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	ids []string,
 ) (data_ *queryWithInterfaceListFieldResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithInterfaceListField",
-		Query:  queryWithInterfaceListField_Operation,
+		OpName:     "queryWithInterfaceListField",
+		Query:      queryWithInterfaceListField_Operation,
+		QueryHash:  queryWithInterfaceListField_OperationHash,
+		DocumentID: queryWithInterfaceListField_OperationHash,
 		Variables: &__queryWithInterfaceListFieldInput{
 			Ids: ids,
 		},
@@ -3565,14 +3929,19 @@ query queryWithInterfaceListPointerField ($ids: [ID!]!) {
 }
 `
 
+// The SHA-256 hash of queryWithInterfaceListPointerField_Operation, used for automatic persisted queries.
+const queryWithInterfaceListPointerField_OperationHash = "08076e1dea044877f31e467923817f03cb7fe36263d7f9b9ed58476a06591623"
+
 func queryWithInterfaceListPointerField(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	ids []string,
 ) (data_ *queryWithInterfaceListPointerFieldResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithInterfaceListPointerField",
-		Query:  queryWithInterfaceListPointerField_Operation,
+		OpName:     "queryWithInterfaceListPointerField",
+		Query:      queryWithInterfaceListPointerField_Operation,
+		QueryHash:  queryWithInterfaceListPointerField_OperationHash,
+		DocumentID: queryWithInterfaceListPointerField_OperationHash,
 		Variables: &__queryWithInterfaceListPointerFieldInput{
 			Ids: ids,
 		},
@@ -3605,14 +3974,19 @@ query queryWithInterfaceNoFragments ($id: ID!) {
 }
 `
 
+// The SHA-256 hash of queryWithInterfaceNoFragments_Operation, used for automatic persisted queries.
+const queryWithInterfaceNoFragments_OperationHash = "2681bc9fdb0c73ca381686c92ae63264a616d55f015d9e48c88a9d7b4c232c36"
+
 func queryWithInterfaceNoFragments(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	id string,
 ) (data_ *queryWithInterfaceNoFragmentsResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithInterfaceNoFragments",
-		Query:  queryWithInterfaceNoFragments_Operation,
+		OpName:     "queryWithInterfaceNoFragments",
+		Query:      queryWithInterfaceNoFragments_Operation,
+		QueryHash:  queryWithInterfaceNoFragments_OperationHash,
+		DocumentID: queryWithInterfaceNoFragments_OperationHash,
 		Variables: &__queryWithInterfaceNoFragmentsInput{
 			Id: id,
 		},
@@ -3669,14 +4043,19 @@ fragment MoreUserFields on User {
 }
 `
 
+// The SHA-256 hash of queryWithNamedFragments_Operation, used for automatic persisted queries.
+const queryWithNamedFragments_OperationHash = "21c3e4bc335f543744c90c2bc9d10bb01b5721a0d1d2ea0eb275a84b1cfa2279"
+
 func queryWithNamedFragments(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	ids []string,
 ) (data_ *queryWithNamedFragmentsResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithNamedFragments",
-		Query:  queryWithNamedFragments_Operation,
+		OpName:     "queryWithNamedFragments",
+		Query:      queryWithNamedFragments_Operation,
+		QueryHash:  queryWithNamedFragments_OperationHash,
+		DocumentID: queryWithNamedFragments_OperationHash,
 		Variables: &__queryWithNamedFragmentsInput{
 			Ids: ids,
 		},
@@ -3705,14 +4084,19 @@ query queryWithOmitempty ($id: ID) {
 }
 `
 
+// The SHA-256 hash of queryWithOmitempty_Operation, used for automatic persisted queries.
+const queryWithOmitempty_OperationHash = "e4542fb626038502269f378063fba32b3f9eb9a125cc4e677368e0e5c679396f"
+
 func queryWithOmitempty(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	id string,
 ) (data_ *queryWithOmitemptyResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithOmitempty",
-		Query:  queryWithOmitempty_Operation,
+		OpName:     "queryWithOmitempty",
+		Query:      queryWithOmitempty_Operation,
+		QueryHash:  queryWithOmitempty_OperationHash,
+		DocumentID: queryWithOmitempty_OperationHash,
 		Variables: &__queryWithOmitemptyInput{
 			Id: id,
 		},
@@ -3741,14 +4125,19 @@ query queryWithVariables ($id: ID!) {
 }
 `
 
+// The SHA-256 hash of queryWithVariables_Operation, used for automatic persisted queries.
+const queryWithVariables_OperationHash = "f255964a2f7c75e832c3203518f265df56c647ee15553747727d4a5d46039ba7"
+
 func queryWithVariables(
 	ctx_ context.Context,
 	client_ graphql.Client,
 	id string,
 ) (data_ *queryWithVariablesResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "queryWithVariables",
-		Query:  queryWithVariables_Operation,
+		OpName:     "queryWithVariables",
+		Query:      queryWithVariables_Operation,
+		QueryHash:  queryWithVariables_OperationHash,
+		DocumentID: queryWithVariables_OperationHash,
 		Variables: &__queryWithVariablesInput{
 			Id: id,
 		},
@@ -3778,13 +4167,18 @@ query simpleQuery {
 }
 `
 
+// The SHA-256 hash of simpleQuery_Operation, used for automatic persisted queries.
+const simpleQuery_OperationHash = "00cb74983a818d0619d336793cd33b8ad5137b91c34416d111febeeda987ded0"
+
 func simpleQuery(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *simpleQueryResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "simpleQuery",
-		Query:  simpleQuery_Operation,
+		OpName:     "simpleQuery",
+		Query:      simpleQuery_Operation,
+		QueryHash:  simpleQuery_OperationHash,
+		DocumentID: simpleQuery_OperationHash,
 	}
 
 	data_ = &simpleQueryResponse{}
@@ -3810,13 +4204,18 @@ query simpleQueryExt {
 }
 `
 
+// The SHA-256 hash of simpleQueryExt_Operation, used for automatic persisted queries.
+const simpleQueryExt_OperationHash = "883f1002900c92f1590f1b5b735bf032c11f64a42371ac50c3e310b4da2c7ba1"
+
 func simpleQueryExt(
 	ctx_ context.Context,
 	client_ graphql.Client,
 ) (data_ *simpleQueryExtResponse, ext_ map[string]interface{}, err_ error) {
 	req_ := &graphql.Request{
-		OpName: "simpleQueryExt",
-		Query:  simpleQueryExt_Operation,
+		OpName:     "simpleQueryExt",
+		Query:      simpleQueryExt_Operation,
+		QueryHash:  simpleQueryExt_OperationHash,
+		DocumentID: simpleQueryExt_OperationHash,
 	}
 
 	data_ = &simpleQueryExtResponse{}
@@ -3830,3 +4229,46 @@ func simpleQueryExt(
 
 	return data_, resp_.Extensions, err_
 }
+
+// The mutation executed by uploadFiles.
+const uploadFiles_Operation = `
+mutation uploadFiles ($files: [Upload!]!, $avatar: Upload) {
+	uploadFiles(files: $files, avatar: $avatar) {
+		filename
+		contentType
+		contents
+	}
+}
+`
+
+// The SHA-256 hash of uploadFiles_Operation, used for automatic persisted queries.
+const uploadFiles_OperationHash = "3911b4be515291fc12f7f5f86d7d8abc90a3ce12629e29a15a2a49eebd88fdf8"
+
+func uploadFiles(
+	ctx_ context.Context,
+	client_ graphql.Client,
+	files []graphql.Upload,
+	avatar graphql.Upload,
+) (data_ *uploadFilesResponse, ext_ map[string]interface{}, err_ error) {
+	req_ := &graphql.Request{
+		OpName:     "uploadFiles",
+		Query:      uploadFiles_Operation,
+		QueryHash:  uploadFiles_OperationHash,
+		DocumentID: uploadFiles_OperationHash,
+		Variables: &__uploadFilesInput{
+			Files:  files,
+			Avatar: avatar,
+		},
+	}
+
+	data_ = &uploadFilesResponse{}
+	resp_ := &graphql.Response{Data: data_}
+
+	err_ = client_.MakeRequest(
+		ctx_,
+		req_,
+		resp_,
+	)
+
+	return data_, resp_.Extensions, err_
+}
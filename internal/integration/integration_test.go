@@ -6,16 +6,34 @@
 package integration
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 
 	"github.com/Khan/genqlient/graphql"
+	"github.com/Khan/genqlient/graphql/expvarmetrics"
+	"github.com/Khan/genqlient/graphql/graphqltest"
+	"github.com/Khan/genqlient/graphql/tracing"
 	"github.com/Khan/genqlient/internal/integration/server"
 )
 
@@ -145,84 +163,1733 @@ func TestSubscription(t *testing.T) {
 	}
 }
 
+func TestSubscriptionDelivery(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+	req := &graphql.Request{Query: count_Operation, OpName: "count"}
+
+	t.Run("ForwardError", func(t *testing.T) {
+		wsClient := graphql.NewClientUsingWebSocket(
+			endpoint, &MyDialer{Dialer: websocket.DefaultDialer}, nil)
+		errChan, err := wsClient.Start(ctx)
+		require.NoError(t, err)
+		defer wsClient.Close()
+
+		// The first message fails to forward: the error is reported, and
+		// the rest still arrive.
+		forwardErr := errors.New("can't forward")
+		calls := 0
+		dataChan := make(chan countWsResponse)
+		_, err = wsClient.Subscribe(req, dataChan,
+			func(interfaceChan interface{}, jsonRawMsg json.RawMessage) error {
+				calls++
+				if calls == 1 {
+					return forwardErr
+				}
+				return countForwardData(interfaceChan, jsonRawMsg)
+			})
+		require.NoError(t, err)
+
+		select {
+		case err = <-errChan:
+			require.ErrorIs(t, err, forwardErr)
+		case <-time.After(10 * time.Second):
+			require.NoError(t, fmt.Errorf("subscription timed out"))
+		}
+		var counts []int
+		for resp := range dataChan {
+			counts = append(counts, resp.Data.Count)
+		}
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, counts)
+	})
+
+	t.Run("UnsubscribeUnread", func(t *testing.T) {
+		wsClient := graphql.NewClientUsingWebSocket(
+			endpoint, &MyDialer{Dialer: websocket.DefaultDialer}, nil)
+		_, err := wsClient.Start(ctx)
+		require.NoError(t, err)
+		defer wsClient.Close()
+
+		// Nothing reads the channel, so the first message is stuck; yet
+		// unsubscribing still stops forwarding it, and closes the channel.
+		forwarding := make(chan struct{}, 10)
+		forwarded := make(chan error, 10)
+		dataChan := make(chan countWsResponse)
+		subscriptionID, err := wsClient.Subscribe(req, dataChan,
+			func(interfaceChan interface{}, jsonRawMsg json.RawMessage) error {
+				forwarding <- struct{}{}
+				sendErr := countForwardData(interfaceChan, jsonRawMsg)
+				forwarded <- sendErr
+				return sendErr
+			})
+		require.NoError(t, err)
+
+		select {
+		case <-forwarding:
+		case <-time.After(10 * time.Second):
+			require.NoError(t, fmt.Errorf("subscription timed out"))
+		}
+		require.NoError(t, wsClient.Unsubscribe(subscriptionID))
+		select {
+		case err = <-forwarded:
+			require.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			require.NoError(t, fmt.Errorf("forwarding wasn't canceled"))
+		}
+		// At most the message already under way is still sent.
+		received := 0
+		for range dataChan {
+			received++
+		}
+		assert.LessOrEqual(t, received, 1)
+	})
+}
+
 func TestSubscriptionConnectionParams(t *testing.T) {
 	_ = `# @genqlient
 	subscription countAuthorized { countAuthorized }`
 
-	authKey := server.AuthKey
+	authKey := server.AuthKey
+
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+
+	cases := []struct {
+		connParams    map[string]interface{}
+		name          string
+		expectedError string
+	}{
+		{
+			name: "authorized_user_gets_counter",
+			connParams: map[string]interface{}{
+				authKey: "authorized-user-token",
+			},
+		},
+		{
+			name:          "unauthorized_user_gets_error",
+			expectedError: "input: countAuthorized unauthorized\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			wsClient := newRoundtripWebSocketClient(t, server.URL, tc.connParams)
+
+			errChan, err := wsClient.Start(ctx)
+			require.NoError(t, err)
+
+			dataChan, subscriptionID, err := countAuthorized(ctx, wsClient)
+			require.NoError(t, err)
+			defer wsClient.Close()
+
+			var (
+				counter = 0
+				start   = time.Now()
+			)
+
+			for loop := true; loop; {
+				select {
+				case resp, more := <-dataChan:
+					if !more {
+						loop = false
+						break
+					}
+
+					if tc.expectedError != "" {
+						require.Error(t, resp.Errors)
+						assert.Equal(t, tc.expectedError, resp.Errors.Error())
+						continue
+					}
+
+					require.NotNil(t, resp.Data)
+					assert.Equal(t, counter, resp.Data.CountAuthorized)
+					require.Nil(t, resp.Errors)
+
+					if time.Since(start) > 5*time.Second {
+						err := wsClient.Unsubscribe(subscriptionID)
+						require.NoError(t, err)
+						loop = false
+					}
+
+					counter++
+
+				case err := <-errChan:
+					require.NoError(t, err)
+
+				case <-time.After(10 * time.Second):
+					require.NoError(t, fmt.Errorf("subscription timed out"))
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriptionProtocols(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	cases := []struct {
+		name      string
+		protocols string
+	}{
+		{name: "default"},
+		{name: "graphql_transport_ws", protocols: "graphql-transport-ws"},
+		{name: "legacy_graphql_ws", protocols: "graphql-ws"},
+		// gqlgen prefers graphql-ws, so we must follow its choice.
+		{name: "negotiated", protocols: "graphql-transport-ws, graphql-ws"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			headers := http.Header{}
+			if tc.protocols != "" {
+				headers.Set("Sec-WebSocket-Protocol", tc.protocols)
+			}
+			wsClient := graphql.NewClientUsingWebSocket(
+				endpoint, &MyDialer{Dialer: websocket.DefaultDialer}, headers)
+
+			errChan, err := wsClient.Start(ctx)
+			require.NoError(t, err)
+			defer wsClient.Close()
+
+			dataChan, subscriptionID, err := count(ctx, wsClient)
+			require.NoError(t, err)
+
+			counter := 0
+			for counter < 3 {
+				select {
+				case resp, more := <-dataChan:
+					require.True(t, more)
+					require.Nil(t, resp.Errors)
+					require.NotNil(t, resp.Data)
+					assert.Equal(t, counter, resp.Data.Count)
+					counter++
+
+				case wsErr := <-errChan:
+					require.NoError(t, wsErr)
+
+				case <-time.After(10 * time.Second):
+					require.NoError(t, fmt.Errorf("subscription timed out"))
+				}
+			}
+
+			err = wsClient.Unsubscribe(subscriptionID)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWebSocketQuery(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	for _, protocol := range []string{"graphql-transport-ws", "graphql-ws"} {
+		t.Run(protocol, func(t *testing.T) {
+			headers := http.Header{}
+			headers.Set("Sec-WebSocket-Protocol", protocol)
+			wsClient := graphql.NewClientUsingWebSocket(
+				endpoint, &MyDialer{Dialer: websocket.DefaultDialer}, headers)
+			client, ok := wsClient.(graphql.Client)
+			require.True(t, ok)
+
+			_, _, err := simpleQuery(ctx, client)
+			require.EqualError(t, err, "client was not started")
+
+			_, err = wsClient.Start(ctx)
+			require.NoError(t, err)
+			defer wsClient.Close()
+
+			resp, _, err := simpleQuery(ctx, client)
+			require.NoError(t, err)
+			assert.Equal(t, "1", resp.Me.Id)
+			assert.Equal(t, "Yours Truly", resp.Me.Name)
+
+			createResp, _, err := createUser(ctx, client, NewUser{Name: "Jack"})
+			require.NoError(t, err)
+			assert.Equal(t, "Jack", createResp.CreateUser.Name)
+
+			failResp, _, err := failingQuery(ctx, client)
+			assert.Error(t, err)
+			require.NotNil(t, failResp)
+			assert.Equal(t, "1", failResp.Me.Id)
+
+			// Subscriptions and queries may share the connection.
+			dataChan, subscriptionID, err := count(ctx, wsClient)
+			require.NoError(t, err)
+			resp, _, err = simpleQuery(ctx, client)
+			require.NoError(t, err)
+			assert.Equal(t, "1", resp.Me.Id)
+			select {
+			case data := <-dataChan:
+				require.Nil(t, data.Errors)
+			case <-time.After(10 * time.Second):
+				require.NoError(t, fmt.Errorf("subscription timed out"))
+			}
+			require.NoError(t, wsClient.Unsubscribe(subscriptionID))
+
+			err = client.MakeRequest(ctx,
+				&graphql.Request{Query: count_Operation, OpName: "count"},
+				&graphql.Response{})
+			assert.Error(t, err)
+		})
+	}
+}
+
+// droppingDialer is a WebSocket dialer which can drop its connection, and
+// refuse to reconnect, to simulate an unreliable server.
+type droppingDialer struct {
+	conn     *websocket.Conn
+	mu       sync.Mutex
+	refusing bool
+}
+
+func (d *droppingDialer) DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (graphql.WSConn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.refusing {
+		return nil, fmt.Errorf("connection refused")
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, urlStr, requestHeader)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	d.conn = conn
+	return conn, nil
+}
+
+// drop closes the current connection; if refuse is set, it also refuses to
+// make another.
+func (d *droppingDialer) drop(refuse bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.refusing = refuse
+	d.conn.Close()
+}
+
+func TestSubscriptionReconnect(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dialer := &droppingDialer{}
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	wsClient := graphql.NewClientUsingWebSocket(endpoint, dialer, nil,
+		graphql.WithReconnect(&graphql.ReconnectPolicy{
+			OnConnected:    func() { record("connected") },
+			OnDisconnected: func(err error) { record("disconnected") },
+			OnReconnecting: func(attempt int) { record(fmt.Sprintf("reconnecting %d", attempt)) },
+			Backoff:        graphql.Backoff{InitialInterval: 10 * time.Millisecond},
+			MaxAttempts:    2,
+		}))
+
+	errChan, err := wsClient.Start(ctx)
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	dataChan, _, err := count(ctx, wsClient)
+	require.NoError(t, err)
+
+	next := func() (int, error) {
+		select {
+		case resp, more := <-dataChan:
+			if !more {
+				return 0, fmt.Errorf("subscription closed")
+			}
+			return resp.Data.Count, resp.Errors
+		case wsErr := <-errChan:
+			return 0, wsErr
+		case <-time.After(10 * time.Second):
+			return 0, fmt.Errorf("subscription timed out")
+		}
+	}
+
+	val, err := next()
+	require.NoError(t, err)
+	assert.Equal(t, 0, val)
+
+	// When the connection drops, we should resubscribe on a new one, which
+	// (since the server knows nothing of the old one) starts counting anew.
+	dialer.drop(false)
+	val, err = next()
+	require.NoError(t, err)
+	assert.Equal(t, 0, val)
+	val, err = next()
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	// If we can't reconnect, we eventually give up.
+	dialer.drop(true)
+	_, err = next()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to reconnect after 2 attempts")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"connected",
+		"disconnected", "reconnecting 1", "connected",
+		"disconnected", "reconnecting 1", "reconnecting 2",
+	}, events)
+}
+
+// unresponsiveServer returns a WebSocket server which acknowledges the
+// connection if ack is set, and otherwise ignores the client entirely.
+func unresponsiveServer(t *testing.T, ack bool) *httptest.Server {
+	upgrader := websocket.Upgrader{Subprotocols: []string{"graphql-transport-ws"}}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if ack && strings.Contains(string(message), `"connection_init"`) {
+				_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"connection_ack"}`))
+			}
+		}
+	}))
+}
+
+func TestSubscriptionKeepAlive(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ack_timeout", func(t *testing.T) {
+		server := unresponsiveServer(t, false)
+		defer server.Close()
+		wsClient := graphql.NewClientUsingWebSocket(
+			"ws"+strings.TrimPrefix(server.URL, "http"),
+			&MyDialer{Dialer: websocket.DefaultDialer}, nil,
+			graphql.WithConnectionAckTimeout(100*time.Millisecond))
+
+		start := time.Now()
+		_, err := wsClient.Start(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out while waiting for connAck")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("no_ack_timeout", func(t *testing.T) {
+		server := unresponsiveServer(t, true)
+		defer server.Close()
+		wsClient := graphql.NewClientUsingWebSocket(
+			"ws"+strings.TrimPrefix(server.URL, "http"),
+			&MyDialer{Dialer: websocket.DefaultDialer}, nil,
+			graphql.WithConnectionAckTimeout(0))
+
+		_, err := wsClient.Start(ctx)
+		require.NoError(t, err)
+		wsClient.Close()
+	})
+
+	t.Run("no_pong", func(t *testing.T) {
+		server := unresponsiveServer(t, true)
+		defer server.Close()
+		wsClient := graphql.NewClientUsingWebSocket(
+			"ws"+strings.TrimPrefix(server.URL, "http"),
+			&MyDialer{Dialer: websocket.DefaultDialer}, nil,
+			graphql.WithKeepAlive(50*time.Millisecond, 50*time.Millisecond))
+
+		errChan, err := wsClient.Start(ctx)
+		require.NoError(t, err)
+		defer wsClient.Close()
+
+		select {
+		case err := <-errChan:
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "server did not respond within 50ms")
+		case <-time.After(5 * time.Second):
+			t.Fatal("dead connection was not detected")
+		}
+	})
+
+	t.Run("pong", func(t *testing.T) {
+		// The real server responds to pings (and sends its own, to which the
+		// client must respond), so the connection should stay up.
+		server := server.RunServer()
+		defer server.Close()
+		wsClient := graphql.NewClientUsingWebSocket(
+			"ws"+strings.TrimPrefix(server.URL, "http"),
+			&MyDialer{Dialer: websocket.DefaultDialer}, nil,
+			graphql.WithKeepAlive(50*time.Millisecond, 50*time.Millisecond))
+
+		errChan, err := wsClient.Start(ctx)
+		require.NoError(t, err)
+		defer wsClient.Close()
+
+		select {
+		case err := <-errChan:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+		}
+	})
+}
+
+func TestSSESubscription(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+
+	cases := []struct {
+		name string
+		opts []graphql.SSEOption
+	}{
+		{name: "distinct_connections"},
+		{name: "single_connection", opts: []graphql.SSEOption{graphql.WithSSESingleConnection()}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sseClient := graphql.NewClientUsingSSE(server.URL, http.DefaultClient, tc.opts...)
+			errChan, err := sseClient.Start(ctx)
+			require.NoError(t, err)
+			defer sseClient.Close()
+
+			// The server sends 10 values, then closes the subscription.
+			dataChan, _, err := count(ctx, sseClient)
+			require.NoError(t, err)
+			// Meanwhile, we unsubscribe from another after one value.
+			otherDataChan, otherSubscriptionID, err := count(ctx, sseClient)
+			require.NoError(t, err)
+
+			counter := 0
+			for loop := true; loop; {
+				select {
+				case resp, more := <-dataChan:
+					if !more {
+						loop = false
+						break
+					}
+					require.Nil(t, resp.Errors)
+					require.NotNil(t, resp.Data)
+					assert.Equal(t, counter, resp.Data.Count)
+					counter++
+
+				case resp, more := <-otherDataChan:
+					require.True(t, more)
+					require.NotNil(t, resp.Data)
+					assert.Equal(t, 0, resp.Data.Count)
+					err := sseClient.Unsubscribe(otherSubscriptionID)
+					require.NoError(t, err)
+					otherDataChan = nil
+
+				case err := <-errChan:
+					require.NoError(t, err)
+
+				case <-time.After(10 * time.Second):
+					require.NoError(t, fmt.Errorf("subscription timed out"))
+				}
+			}
+			assert.Equal(t, 10, counter)
+			assert.Nil(t, otherDataChan)
+		})
+	}
+}
+
+// countingTransport is an HTTP transport that counts the requests that pass
+// through it.
+type countingTransport struct {
+	wrapped  http.RoundTripper
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return t.wrapped.RoundTrip(req)
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+
+	transport := &countingTransport{wrapped: http.DefaultTransport}
+	client := graphql.WithCache(
+		graphql.NewClient(server.URL, &http.Client{Transport: transport}),
+		graphql.NewCache(), graphql.CacheFirst)
+
+	// Repeated queries come from the cache, even with interfaces and
+	// fragments.
+	ids := []string{"1", "3", "12847394823"}
+	resp, _, err := queryWithFragments(ctx, client, ids)
+	require.NoError(t, err)
+	assert.Equal(t, 1, transport.requests)
+	cachedResp, _, err := queryWithFragments(ctx, client, ids)
+	require.NoError(t, err)
+	assert.Equal(t, resp, cachedResp)
+	assert.Equal(t, 1, transport.requests)
+
+	// Fields with different arguments are cached separately.
+	userResp, _, err := queryWithVariables(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", userResp.User.Name)
+	userResp, _, err = queryWithVariables(ctx, client, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Raven", userResp.User.Name)
+	userResp, _, err = queryWithVariables(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", userResp.User.Name)
+	assert.Equal(t, 3, transport.requests)
+
+	// NetworkOnly skips the cache.
+	_, _, err = queryWithVariables(graphql.WithCachePolicy(ctx, graphql.NetworkOnly), client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, transport.requests)
+}
+
+func TestCacheUpdates(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	name := "Yours Truly"
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			OpName    string `json:"operationName"`
+			Variables struct {
+				Name string `json:"name"`
+			} `json:"variables"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		require.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		switch req.OpName {
+		case "GetMe":
+			fmt.Fprintf(w, `{"data": {"me": {"__typename": "User", "id": "1", "name": %q}}}`, name)
+		case "Rename":
+			name = req.Variables.Name
+			fmt.Fprintf(w, `{"data": {"rename": {"__typename": "User", "id": "1", "name": %q}}}`, name)
+		}
+	}))
+	defer server.Close()
+	getRequests := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	cache := graphql.NewCache()
+	client := graphql.WithCache(
+		graphql.NewClient(server.URL, http.DefaultClient), cache, graphql.CacheFirst)
+	getMe := func(ctx context.Context) string {
+		var data struct {
+			Me struct{ Name string } `json:"me"`
+		}
+		err := client.MakeRequest(ctx, &graphql.Request{
+			OpName: "GetMe",
+			Query:  "query GetMe { me { __typename id name } }",
+		}, &graphql.Response{Data: &data})
+		require.NoError(t, err)
+		return data.Me.Name
+	}
+
+	assert.Equal(t, "Yours Truly", getMe(ctx))
+	assert.Equal(t, "Yours Truly", getMe(ctx))
+	assert.Equal(t, 1, getRequests())
+
+	// Mutations update the entities they return.
+	err := client.MakeRequest(ctx, &graphql.Request{
+		OpName:    "Rename",
+		Query:     "mutation Rename($name: String!) { rename(name: $name) { __typename id name } }",
+		Variables: map[string]string{"name": "Me"},
+	}, &graphql.Response{Data: &struct{}{}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, getRequests())
+	assert.Equal(t, "Me", getMe(ctx))
+	assert.Equal(t, 2, getRequests())
+
+	// CacheAndNetwork returns the cached data, but updates the cache.
+	mu.Lock()
+	name = "Someone Else"
+	mu.Unlock()
+	assert.Equal(t, "Me", getMe(graphql.WithCachePolicy(ctx, graphql.CacheAndNetwork)))
+	assert.Eventually(t, func() bool { return getRequests() == 3 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return getMe(ctx) == "Someone Else" }, time.Second, time.Millisecond)
+
+	// Evicted entities must be refetched.
+	cache.Evict("User", "1")
+	assert.Equal(t, "Someone Else", getMe(ctx))
+	assert.Equal(t, 4, getRequests())
+}
+
+func TestHTTPCache(t *testing.T) {
+	ctx := context.Background()
+	handler := server.Handler()
+	var mu sync.Mutex
+	cacheControl := "max-age=60"
+	var notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+		etag := fmt.Sprintf(`"%x"`, len(recorder.Body.Bytes()))
+
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", recorder.Header().Get("Content-Type"))
+		w.WriteHeader(recorder.Code)
+		_, _ = w.Write(recorder.Body.Bytes())
+	}))
+	defer server.Close()
+
+	setCacheControl := func(value string) {
+		mu.Lock()
+		defer mu.Unlock()
+		cacheControl = value
+	}
+
+	transport := &countingTransport{wrapped: http.DefaultTransport}
+	store := graphql.NewMemoryHTTPCacheStore(0)
+	client := graphql.NewClientUsingGet(server.URL, &http.Client{Transport: transport},
+		graphql.WithHTTPCache(store))
+
+	// Fresh responses are served without a request.
+	resp, _, err := queryWithVariables(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", resp.User.Name)
+	resp, _, err = queryWithVariables(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", resp.User.Name)
+	assert.Equal(t, 1, transport.requests)
+
+	// Different variables mean a different URL.
+	resp, _, err = queryWithVariables(ctx, client, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Raven", resp.User.Name)
+	assert.Equal(t, 2, transport.requests)
+
+	// Stale responses are revalidated.
+	setCacheControl("no-cache")
+	resp, _, err = queryWithVariables(ctx, client, "3")
+	require.NoError(t, err)
+	assert.Equal(t, 3, transport.requests)
+	cachedResp, _, err := queryWithVariables(ctx, client, "3")
+	require.NoError(t, err)
+	assert.Equal(t, resp, cachedResp)
+	assert.Equal(t, 4, transport.requests)
+	assert.Equal(t, 1, notModified)
+
+	// Responses marked no-store aren't cached at all.
+	setCacheControl("no-store")
+	_, _, err = queryWithVariables(ctx, client, "4")
+	require.NoError(t, err)
+	_, _, err = queryWithVariables(ctx, client, "4")
+	require.NoError(t, err)
+	assert.Equal(t, 6, transport.requests)
+	assert.Equal(t, 1, notModified)
+
+	// Requests with different Authorization headers are cached separately,
+	// but other headers, such as those tracing adds, don't matter unless the
+	// response varies by them.
+	setCacheControl("max-age=60")
+	for _, headerCtx := range []context.Context{
+		ctx,
+		graphql.WithHeader(ctx, "Authorization", "Bearer alice"),
+		graphql.WithHeader(ctx, "Authorization", "Bearer bob"),
+	} {
+		_, _, err = queryWithVariables(headerCtx, client, "5")
+		require.NoError(t, err)
+		_, _, err = queryWithVariables(headerCtx, client, "5")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 9, transport.requests)
+	_, _, err = queryWithVariables(
+		graphql.WithHeader(ctx, "Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"),
+		client, "5")
+	require.NoError(t, err)
+	assert.Equal(t, 9, transport.requests)
+
+	// POST requests are never cached.
+	client = graphql.NewClient(server.URL, &http.Client{Transport: transport},
+		graphql.WithHTTPCache(store))
+	_, _, err = queryWithVariables(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, 10, transport.requests)
+
+	// The memory store evicts the least recently used response once full.
+	client = graphql.NewClientUsingGet(server.URL, &http.Client{Transport: transport},
+		graphql.WithHTTPCache(graphql.NewMemoryHTTPCacheStore(2)))
+	for _, id := range []string{"1", "2", "1", "3", "1", "2"} {
+		_, _, err = queryWithVariables(ctx, client, id)
+		require.NoError(t, err)
+	}
+	// "1" is used most recently, so "3" evicts "2".
+	assert.Equal(t, 14, transport.requests)
+}
+
+func TestAutomaticPersistedQueries(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+
+	transport := &countingTransport{wrapped: http.DefaultTransport}
+	httpClient := &http.Client{Transport: transport}
+	clients := []graphql.Client{
+		graphql.NewClient(server.URL, httpClient, graphql.WithAutomaticPersistedQueries()),
+		graphql.NewClientUsingGet(server.URL, httpClient, graphql.WithAutomaticPersistedQueries()),
+	}
+
+	for i, client := range clients {
+		transport.requests = 0
+		resp, _, err := queryWithVariables(ctx, client, "2")
+		require.NoError(t, err)
+		assert.Equal(t, "Raven", resp.User.Name)
+		if i == 0 {
+			// The server hasn't seen the query yet, so the client must retry
+			// with the full query.
+			assert.Equal(t, 2, transport.requests)
+		} else {
+			// The POST client already registered the query.
+			assert.Equal(t, 1, transport.requests)
+		}
+
+		transport.requests = 0
+		resp, _, err = queryWithVariables(ctx, client, "1")
+		require.NoError(t, err)
+		assert.Equal(t, "Yours Truly", resp.User.Name)
+		assert.Equal(t, 1, transport.requests)
+	}
+
+	_, _, err := createUser(ctx, clients[1], NewUser{Name: "Jill"})
+	require.EqualError(t, err, "client does not support mutations")
+}
+
+// trustedDocumentsHandler wraps a GraphQL handler to look up the query by its
+// document ID, as a server accepting only trusted documents would.  It runs
+// on the server's goroutine, so it reports problems with t.Errorf (and an
+// HTTP error), rather than require.
+func trustedDocumentsHandler(t *testing.T, documents map[string]string, wrapped http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fail := func(format string, args ...interface{}) {
+			t.Errorf(format, args...)
+			http.Error(w, fmt.Sprintf(format, args...), http.StatusBadRequest)
+		}
+
+		if r.Method == http.MethodGet {
+			params := r.URL.Query()
+			if query := params.Get("query"); query != "" {
+				fail("request has query %q as well as document ID", query)
+				return
+			}
+			params.Set("query", documents[params.Get("documentId")])
+			r.URL.RawQuery = params.Encode()
+		} else {
+			var body map[string]interface{}
+			err := json.NewDecoder(r.Body).Decode(&body)
+			if err != nil {
+				fail("invalid request body: %v", err)
+				return
+			}
+			if query, ok := body["query"]; ok {
+				fail("request has query %q as well as document ID", query)
+				return
+			}
+			documentID, _ := body["documentId"].(string)
+			body["query"] = documents[documentID]
+			b, err := json.Marshal(body)
+			if err != nil {
+				fail("can't marshal request body: %v", err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(b))
+			r.ContentLength = int64(len(b))
+		}
+		wrapped.ServeHTTP(w, r)
+	})
+}
+
+func TestTrustedDocuments(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(trustedDocumentsHandler(t, map[string]string{
+		queryWithVariables_OperationHash: queryWithVariables_Operation,
+	}, server.Handler()))
+	defer server.Close()
+
+	clients := []graphql.Client{
+		graphql.NewClient(server.URL, http.DefaultClient, graphql.WithTrustedDocuments()),
+		graphql.NewClientUsingGet(server.URL, http.DefaultClient, graphql.WithTrustedDocuments()),
+	}
+
+	for _, client := range clients {
+		resp, _, err := queryWithVariables(ctx, client, "2")
+		require.NoError(t, err)
+		assert.Equal(t, "Raven", resp.User.Name)
+	}
+
+	// Requests with no document ID are never sent.
+	err := clients[0].MakeRequest(ctx,
+		&graphql.Request{OpName: "handWritten", Query: "query handWritten { me { id } }"},
+		&graphql.Response{Data: new(simpleQueryResponse)})
+	require.EqualError(t, err,
+		"request handWritten has no DocumentID (set use_document_ids in genqlient.yaml)")
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+
+	var calls []string
+	recordCalls := func(name string) graphql.Middleware {
+		return func(next graphql.MakeRequestFunc) graphql.MakeRequestFunc {
+			return func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+				calls = append(calls, name+" before "+req.OpName)
+				err := next(ctx, req, resp)
+				calls = append(calls, name+" after "+req.OpName)
+				return err
+			}
+		}
+	}
+	errFailed := fmt.Errorf("operation failed")
+	mapErrors := func(next graphql.MakeRequestFunc) graphql.MakeRequestFunc {
+		return func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+			err := next(ctx, req, resp)
+			if len(resp.Errors) > 0 {
+				return fmt.Errorf("%s: %w", req.OpName, errFailed)
+			}
+			return err
+		}
+	}
+
+	client := graphql.WithMiddleware(
+		graphql.NewClient(server.URL, http.DefaultClient),
+		recordCalls("outer"), recordCalls("inner"), mapErrors)
+
+	resp, _, err := queryWithVariables(ctx, client, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Raven", resp.User.Name)
+	assert.Equal(t, []string{
+		"outer before queryWithVariables",
+		"inner before queryWithVariables",
+		"inner after queryWithVariables",
+		"outer after queryWithVariables",
+	}, calls)
+
+	failResp, _, err := failingQuery(ctx, client)
+	require.ErrorIs(t, err, errFailed)
+	assert.Equal(t, "failingQuery: operation failed", err.Error())
+	assert.Equal(t, "1", failResp.Me.Id)
+
+	calls = nil
+	wsClient := graphql.WithWebSocketMiddleware(
+		newRoundtripWebSocketClient(t, server.URL, nil),
+		recordCalls("outer"), mapErrors)
+	_, err = wsClient.Start(ctx)
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	dataChan, subscriptionID, err := count(ctx, wsClient)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer before count", "outer after count"}, calls)
+	msg := <-dataChan
+	require.NotNil(t, msg.Data)
+	assert.Equal(t, 0, msg.Data.Count)
+	require.NoError(t, wsClient.Unsubscribe(subscriptionID))
+}
+
+// flakyHandler wraps a GraphQL handler to first return each of the given
+// canned responses, then pass requests through to the wrapped handler.
+type flakyHandler struct {
+	last      time.Time
+	wrapped   http.Handler
+	intervals []time.Duration
+	failures  []func(w http.ResponseWriter)
+	requests  int
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	if h.requests > 0 {
+		h.intervals = append(h.intervals, now.Sub(h.last))
+	}
+	h.last = now
+	h.requests++
+	if len(h.failures) > 0 {
+		fail := h.failures[0]
+		h.failures = h.failures[1:]
+		fail(w)
+		return
+	}
+	h.wrapped.ServeHTTP(w, r)
+}
+
+func failWithStatus(status int, header http.Header) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "try again later")
+	}
+}
+
+func failWithErrorCode(code string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data":null,"errors":[{"message":"oops","extensions":{"code":%q}}]}`, code)
+	}
+}
+
+// recordingTracer is an in-memory tracing.Tracer, which records the spans it
+// creates.
+type recordingTracer struct {
+	spans []*recordedSpan
+	mu    sync.Mutex
+}
+
+type recordedSpan struct {
+	err         error
+	attributes  map[string]string
+	name        string
+	events      []recordedEvent
+	spanContext tracing.SpanContext
+	ended       bool
+}
+
+type recordedEvent struct {
+	attributes map[string]string
+	name       string
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, tracing.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &recordedSpan{name: name, attributes: map[string]string{}}
+	span.spanContext.TraceID[0] = 1
+	span.spanContext.SpanID[7] = byte(len(t.spans) + 1)
+	span.spanContext.Sampled = true
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (t *recordingTracer) Spans() []*recordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*recordedSpan(nil), t.spans...)
+}
+
+func attributeMap(attributes []tracing.Attribute) map[string]string {
+	m := map[string]string{}
+	for _, attr := range attributes {
+		m[attr.Key] = attr.Value
+	}
+	return m
+}
+
+func (s *recordedSpan) SpanContext() tracing.SpanContext { return s.spanContext }
+
+func (s *recordedSpan) SetAttributes(attributes ...tracing.Attribute) {
+	for k, v := range attributeMap(attributes) {
+		s.attributes[k] = v
+	}
+}
+
+func (s *recordedSpan) AddEvent(name string, attributes ...tracing.Attribute) {
+	s.events = append(s.events, recordedEvent{name: name, attributes: attributeMap(attributes)})
+}
+
+func (s *recordedSpan) RecordError(err error) { s.err = err }
+
+func (s *recordedSpan) End() { s.ended = true }
+
+// recordingDialer is a graphql.Dialer which records the messages the client
+// sends.
+type recordingDialer struct {
+	MyDialer
+	messages chan []byte
+}
+
+type recordingConn struct {
+	graphql.WSConn
+	messages chan []byte
+}
+
+func (d *recordingDialer) DialContext(ctx context.Context, urlStr string, requestHeader http.Header) (graphql.WSConn, error) {
+	conn, err := d.MyDialer.DialContext(ctx, urlStr, requestHeader)
+	return &recordingConn{WSConn: conn, messages: d.messages}, err
+}
+
+func (c *recordingConn) WriteMessage(messageType int, data []byte) error {
+	select {
+	case c.messages <- data:
+	default:
+	}
+	return c.WSConn.WriteMessage(messageType, data)
+}
+
+func TestTracing(t *testing.T) {
+	ctx := context.Background()
+	handler := server.Handler()
+	var mu sync.Mutex
+	var traceParents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceParents = append(traceParents, r.Header.Get("traceparent"))
+		mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	client := tracing.WithTracing(graphql.NewClient(server.URL, http.DefaultClient), tracer)
+
+	resp, _, err := queryWithVariables(ctx, client, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Raven", resp.User.Name)
+	spans := tracer.Spans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "query queryWithVariables", span.name)
+	assert.Equal(t, map[string]string{
+		tracing.AttributeOperationName: "queryWithVariables",
+		tracing.AttributeOperationType: "query",
+		tracing.AttributeDocumentHash:  queryWithVariables_OperationHash,
+	}, span.attributes)
+	assert.True(t, span.ended)
+	assert.NoError(t, span.err)
+	assert.Equal(t, []string{"00-01000000000000000000000000000000-0000000000000001-01"}, traceParents)
+
+	// GraphQL errors are recorded as events.
+	_, _, err = failingQuery(ctx, client)
+	require.Error(t, err)
+	spans = tracer.Spans()
+	require.Len(t, spans, 2)
+	span = spans[1]
+	assert.Equal(t, "query failingQuery", span.name)
+	assert.Equal(t, err, span.err)
+	assert.Equal(t, []recordedEvent{{
+		name: tracing.EventError,
+		attributes: map[string]string{
+			tracing.AttributeErrorMessage: "oh no",
+			tracing.AttributeErrorPath:    "fail",
+		},
+	}}, span.events)
+
+	// Over the WebSocket, the trace is propagated in the connection_init
+	// payload.
+	dialer := &recordingDialer{
+		MyDialer: MyDialer{Dialer: websocket.DefaultDialer},
+		messages: make(chan []byte, 1),
+	}
+	tracer = &recordingTracer{}
+	wsClient := tracing.WithWebSocketTracing(graphql.NewClientUsingWebSocket(
+		"ws"+strings.TrimPrefix(server.URL, "http"), dialer, nil), tracer)
+	_, err = wsClient.Start(ctx)
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	var initMessage struct {
+		Payload map[string]interface{} `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal(<-dialer.messages, &initMessage))
+	spans = tracer.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GraphQL connect", spans[0].name)
+	assert.Equal(t, spans[0].spanContext.TraceParent(), initMessage.Payload["traceparent"])
+
+	wsResp, _, err := simpleQuery(ctx, wsClient.(graphql.Client))
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", wsResp.Me.Name)
+	dataChan, subscriptionID, err := count(ctx, wsClient)
+	require.NoError(t, err)
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for range dataChan {
+		}
+	}()
+	require.NoError(t, wsClient.Unsubscribe(subscriptionID))
+	<-drained
+
+	spans = tracer.Spans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "query simpleQuery", spans[1].name)
+	assert.Equal(t, "subscription count", spans[2].name)
+	assert.Equal(t, "subscription", spans[2].attributes[tracing.AttributeOperationType])
+
+	// Wrapping a client which can't make queries doesn't make one which
+	// claims to.
+	_, ok := tracing.WithWebSocketTracing(
+		struct{ graphql.WebSocketClient }{graphql.NewClientUsingWebSocket(
+			"ws"+strings.TrimPrefix(server.URL, "http"), dialer, nil)},
+		tracer).(graphql.Client)
+	assert.False(t, ok)
+}
 
+func TestMetrics(t *testing.T) {
 	ctx := context.Background()
 	server := server.RunServer()
 	defer server.Close()
 
+	type metrics struct {
+		Requests            map[string]int            `json:"requests"`
+		Errors              map[string]int            `json:"errors"`
+		ErrorCodes          map[string]int            `json:"errorCodes"`
+		Latency             map[string]map[string]int `json:"latency"`
+		RequestBytes        map[string]int            `json:"requestBytes"`
+		ResponseBytes       map[string]int            `json:"responseBytes"`
+		ActiveSubscriptions int                       `json:"activeSubscriptions"`
+		Reconnects          int                       `json:"reconnects"`
+		ReconnectFailures   int                       `json:"reconnectFailures"`
+	}
+	read := func(recorder *expvarmetrics.Recorder) metrics {
+		var m metrics
+		require.NoError(t, json.Unmarshal([]byte(recorder.String()), &m))
+		return m
+	}
+
+	recorder := expvarmetrics.New()
+	for _, client := range []graphql.Client{
+		graphql.NewClient(server.URL, http.DefaultClient, graphql.WithMetrics(recorder)),
+		graphql.NewClientUsingGet(server.URL, http.DefaultClient, graphql.WithMetrics(recorder)),
+		graphql.NewClient(server.URL, http.DefaultClient, graphql.WithMetrics(recorder),
+			graphql.WithBatching(time.Millisecond, 0)),
+	} {
+		_, _, err := queryWithVariables(ctx, client, "2")
+		require.NoError(t, err)
+		_, _, err = failingQuery(ctx, client)
+		require.Error(t, err)
+	}
+	err := graphql.NewClient(server.URL, http.DefaultClient, graphql.WithMetrics(recorder)).
+		MakeRequest(ctx, &graphql.Request{Query: "query invalid { notAField }", OpName: "invalid"},
+			&graphql.Response{})
+	require.Error(t, err)
+
+	m := read(recorder)
+	assert.Equal(t, map[string]int{"queryWithVariables": 3, "failingQuery": 3, "invalid": 1}, m.Requests)
+	assert.Equal(t, map[string]int{"failingQuery": 3, "invalid": 1}, m.Errors)
+	assert.Equal(t, map[string]int{"GRAPHQL_VALIDATION_FAILED": 1}, m.ErrorCodes)
+	total := 0
+	for _, count := range m.Latency["queryWithVariables"] {
+		total += count
+	}
+	assert.Equal(t, 3, total)
+	assert.Greater(t, m.RequestBytes["queryWithVariables"], 3*len(queryWithVariables_Operation))
+	assert.Greater(t, m.ResponseBytes["queryWithVariables"], 3*len(`{"data":{"user":{}}}`))
+
+	// WebSocket clients also record their subscriptions and reconnects.
+	recorder = expvarmetrics.New()
+	dialer := &droppingDialer{}
+	wsClient := graphql.NewClientUsingWebSocket(
+		"ws"+strings.TrimPrefix(server.URL, "http"), dialer, nil,
+		graphql.WithReconnect(&graphql.ReconnectPolicy{
+			Backoff:     graphql.Backoff{InitialInterval: 10 * time.Millisecond},
+			MaxAttempts: 2,
+		}),
+		graphql.WithWebSocketMetrics(recorder))
+	_, err = wsClient.Start(ctx)
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	_, _, err = simpleQuery(ctx, wsClient.(graphql.Client))
+	require.NoError(t, err)
+	dataChan, subscriptionID, err := count(ctx, wsClient)
+	require.NoError(t, err)
+	<-dataChan
+	assert.Equal(t, 1, read(recorder).ActiveSubscriptions)
+
+	dialer.drop(false)
+	<-dataChan // after resubscribing
+	m = read(recorder)
+	assert.Equal(t, 1, m.Reconnects)
+	assert.Equal(t, 0, m.ReconnectFailures)
+	assert.Equal(t, 1, m.ActiveSubscriptions)
+	assert.Equal(t, map[string]int{"simpleQuery": 1}, m.Requests)
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for range dataChan {
+		}
+	}()
+	require.NoError(t, wsClient.Unsubscribe(subscriptionID))
+	<-drained
+	assert.Equal(t, 0, read(recorder).ActiveSubscriptions)
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	handler := &flakyHandler{wrapped: server.Handler()}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	backoff := graphql.Backoff{InitialInterval: time.Millisecond, Jitter: -1}
+	client := graphql.WithRetry(
+		graphql.NewClient(server.URL, http.DefaultClient),
+		&graphql.RetryPolicy{Backoff: backoff, RetryErrorCodes: []string{"UNAVAILABLE"}})
+
+	// Retries transient statuses, until it succeeds.
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusServiceUnavailable, nil),
+		failWithStatus(http.StatusBadGateway, nil),
+	}
+	resp, _, err := queryWithVariables(ctx, client, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Raven", resp.User.Name)
+	assert.Equal(t, 3, handler.requests)
+
+	// Gives up after MaxAttempts.
+	handler.requests = 0
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusServiceUnavailable, nil),
+		failWithStatus(http.StatusServiceUnavailable, nil),
+		failWithStatus(http.StatusServiceUnavailable, nil),
+	}
+	_, _, err = queryWithVariables(ctx, client, "2")
+	require.EqualError(t, err, "returned error 503 Service Unavailable: try again later")
+	assert.Equal(t, 3, handler.requests)
+
+	// Doesn't retry other statuses.
+	handler.requests = 0
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusBadRequest, nil),
+	}
+	_, _, err = queryWithVariables(ctx, client, "2")
+	require.Error(t, err)
+	assert.Equal(t, 1, handler.requests)
+
+	// Retries the configured GraphQL error codes, and resets the response.
+	handler.requests = 0
+	handler.failures = []func(http.ResponseWriter){failWithErrorCode("UNAVAILABLE")}
+	resp, _, err = queryWithVariables(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", resp.User.Name)
+	assert.Equal(t, 2, handler.requests)
+
+	// But not other GraphQL errors.
+	handler.requests = 0
+	handler.failures = []func(http.ResponseWriter){failWithErrorCode("BAD_USER_INPUT")}
+	_, _, err = queryWithVariables(ctx, client, "1")
+	require.EqualError(t, err, "input: oops\n")
+	assert.Equal(t, 1, handler.requests)
+
+	// Honors Retry-After.
+	handler.requests = 0
+	handler.intervals = nil
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}}),
+	}
+	_, _, err = queryWithVariables(ctx, client, "1")
+	require.NoError(t, err)
+	require.Len(t, handler.intervals, 1)
+	assert.GreaterOrEqual(t, handler.intervals[0], time.Second)
+
+	// Doesn't retry mutations by default...
+	handler.requests = 0
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusServiceUnavailable, nil),
+	}
+	_, _, err = createUser(ctx, client, NewUser{Name: "Jack"})
+	require.Error(t, err)
+	assert.Equal(t, 1, handler.requests)
+
+	// ...even if they don't start with the word "mutation"...
+	handler.requests = 0
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusServiceUnavailable, nil),
+	}
+	err = client.MakeRequest(ctx, &graphql.Request{
+		Query:     "# Creates a user.\n" + createUser_Operation,
+		Variables: map[string]interface{}{"user": map[string]interface{}{"name": "Jack"}},
+		OpName:    "createUser",
+	}, &graphql.Response{})
+	require.Error(t, err)
+	assert.Equal(t, 1, handler.requests)
+
+	// ...but does if asked.
+	client = graphql.WithRetry(
+		graphql.NewClient(server.URL, http.DefaultClient),
+		&graphql.RetryPolicy{Backoff: backoff, RetryMutations: true})
+	handler.requests = 0
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusServiceUnavailable, nil),
+	}
+	createResp, _, err := createUser(ctx, client, NewUser{Name: "Jack"})
+	require.NoError(t, err)
+	assert.Equal(t, "Jack", createResp.CreateUser.Name)
+	assert.Equal(t, 2, handler.requests)
+
+	// Stops waiting when the context is done.
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	handler.requests = 0
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusServiceUnavailable, http.Header{"Retry-After": {"60"}}),
+	}
+	_, _, err = queryWithVariables(ctx, client, "1")
+	require.EqualError(t, err, "returned error 503 Service Unavailable: try again later")
+	assert.Equal(t, 1, handler.requests)
+
+	// Gives up, rather than retrying early, if Retry-After asks for longer
+	// than the backoff's MaxInterval.
+	client = graphql.WithRetry(
+		graphql.NewClient(server.URL, http.DefaultClient),
+		&graphql.RetryPolicy{Backoff: graphql.Backoff{
+			InitialInterval: time.Millisecond, MaxInterval: 10 * time.Millisecond, Jitter: -1,
+		}})
+	handler.requests = 0
+	handler.intervals = nil
+	handler.failures = []func(http.ResponseWriter){
+		failWithStatus(http.StatusServiceUnavailable, http.Header{"Retry-After": {"60"}}),
+	}
+	_, _, err = queryWithVariables(context.Background(), client, "1")
+	require.EqualError(t, err, "returned error 503 Service Unavailable: try again later")
+	assert.Equal(t, 1, handler.requests)
+}
+
+// erroringTransport is an HTTP transport which fails each request with err.
+type erroringTransport struct {
+	err      error
+	requests int
+}
+
+func (t *erroringTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	t.requests++
+	return nil, t.err
+}
+
+func TestRetryNetworkErrors(t *testing.T) {
+	ctx := context.Background()
+	backoff := graphql.Backoff{InitialInterval: time.Millisecond, Jitter: -1}
+
 	cases := []struct {
-		connParams    map[string]interface{}
-		name          string
-		expectedError string
+		err      error
+		name     string
+		attempts int
 	}{
 		{
-			name: "authorized_user_gets_counter",
-			connParams: map[string]interface{}{
-				authKey: "authorized-user-token",
-			},
+			name:     "refused",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			attempts: 3,
 		},
 		{
-			name:          "unauthorized_user_gets_error",
-			expectedError: "input: countAuthorized unauthorized\n",
+			name:     "reset",
+			err:      &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			attempts: 3,
 		},
+		{
+			name:     "timeout",
+			err:      &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded},
+			attempts: 3,
+		},
+		{name: "closed", err: io.EOF, attempts: 3},
+		{name: "no such host", err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, attempts: 1},
+		{name: "other", err: errors.New("x509: certificate signed by unknown authority"), attempts: 1},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			wsClient := newRoundtripWebSocketClient(t, server.URL, tc.connParams)
-
-			errChan, err := wsClient.Start(ctx)
-			require.NoError(t, err)
+			transport := &erroringTransport{err: tc.err}
+			client := graphql.WithRetry(
+				graphql.NewClient("http://example.invalid", &http.Client{Transport: transport}),
+				&graphql.RetryPolicy{Backoff: backoff})
+			_, _, err := queryWithVariables(ctx, client, "1")
+			require.ErrorIs(t, err, tc.err)
+			assert.Equal(t, tc.attempts, transport.requests)
+		})
+	}
+}
 
-			dataChan, subscriptionID, err := countAuthorized(ctx, wsClient)
-			require.NoError(t, err)
-			defer wsClient.Close()
+// batchingHandler wraps a GraphQL handler to accept batches of requests, as
+// a JSON array, by passing each to the wrapped handler in turn.  It counts
+// the HTTP requests it receives, and the size of each.
+type batchingHandler struct {
+	wrapped http.Handler
+	sizes   []int
+	mu      sync.Mutex
+}
 
-			var (
-				counter = 0
-				start   = time.Now()
-			)
+func (h *batchingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var batch []json.RawMessage
+	if json.Unmarshal(body, &batch) != nil {
+		batch = []json.RawMessage{body}
+	}
+	h.mu.Lock()
+	h.sizes = append(h.sizes, len(batch))
+	h.mu.Unlock()
+
+	results := make([]json.RawMessage, len(batch))
+	for i, req := range batch {
+		opReq := r.Clone(r.Context())
+		opReq.Body = io.NopCloser(bytes.NewReader(req))
+		opReq.ContentLength = int64(len(req))
+		opResp := httptest.NewRecorder()
+		h.wrapped.ServeHTTP(opResp, opReq)
+		results[i] = opResp.Body.Bytes()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if body[0] == '[' {
+		_ = json.NewEncoder(w).Encode(results)
+	} else {
+		_, _ = w.Write(results[0])
+	}
+}
 
-			for loop := true; loop; {
-				select {
-				case resp, more := <-dataChan:
-					if !more {
-						loop = false
-						break
-					}
+func TestBatching(t *testing.T) {
+	ctx := context.Background()
+	handler := &batchingHandler{wrapped: server.Handler()}
+	server := httptest.NewServer(handler)
+	defer server.Close()
 
-					if tc.expectedError != "" {
-						require.Error(t, resp.Errors)
-						assert.Equal(t, tc.expectedError, resp.Errors.Error())
-						continue
-					}
+	client := graphql.NewClient(server.URL, http.DefaultClient,
+		graphql.WithBatching(time.Minute, 3))
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	var resp1, resp2 *queryWithVariablesResponse
+	var failResp *failingQueryResponse
+	var err1, err2, failErr error
+	go func() {
+		defer wg.Done()
+		resp1, _, err1 = queryWithVariables(ctx, client, "1")
+	}()
+	go func() {
+		defer wg.Done()
+		resp2, _, err2 = queryWithVariables(ctx, client, "2")
+	}()
+	go func() {
+		defer wg.Done()
+		failResp, _, failErr = failingQuery(ctx, client)
+	}()
+	wg.Wait()
+
+	// All three requests went in one batch...
+	assert.Equal(t, []int{3}, handler.sizes)
+	// ...but each got its own response and errors.
+	require.NoError(t, err1)
+	assert.Equal(t, "Yours Truly", resp1.User.Name)
+	require.NoError(t, err2)
+	assert.Equal(t, "Raven", resp2.User.Name)
+	require.Error(t, failErr)
+	assert.Equal(t, "1", failResp.Me.Id)
+
+	// A request which can't be marshaled fails on its own, without failing
+	// the rest of its batch.
+	handler.sizes = nil
+	client = graphql.NewClient(server.URL, http.DefaultClient,
+		graphql.WithBatching(50*time.Millisecond, 0))
+	var badErr error
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		resp1, _, err1 = queryWithVariables(ctx, client, "1")
+	}()
+	go func() {
+		defer wg.Done()
+		resp2, _, err2 = queryWithVariables(ctx, client, "2")
+	}()
+	go func() {
+		defer wg.Done()
+		badErr = client.MakeRequest(ctx, &graphql.Request{
+			Query:     queryWithVariables_Operation,
+			Variables: map[string]interface{}{"id": make(chan int)},
+			OpName:    "queryWithVariables",
+		}, &graphql.Response{Data: new(queryWithVariablesResponse)})
+	}()
+	wg.Wait()
+
+	assert.Equal(t, []int{2}, handler.sizes)
+	require.NoError(t, err1)
+	assert.Equal(t, "Yours Truly", resp1.User.Name)
+	require.NoError(t, err2)
+	assert.Equal(t, "Raven", resp2.User.Name)
+	var unsupportedErr *json.UnsupportedTypeError
+	require.ErrorAs(t, badErr, &unsupportedErr)
+
+	// A lone request is sent, unbatched, once the window elapses.
+	handler.sizes = nil
+	client = graphql.NewClient(server.URL, http.DefaultClient,
+		graphql.WithBatching(10*time.Millisecond, 0))
+	resp, _, err := queryWithVariables(ctx, client, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Raven", resp.User.Name)
+	assert.Equal(t, []int{1}, handler.sizes)
+
+	// A caller whose context is done doesn't wait for the batch.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	client = graphql.NewClient(server.URL, http.DefaultClient,
+		graphql.WithBatching(time.Minute, 0))
+	_, _, err = queryWithVariables(timeoutCtx, client, "2")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
 
-					require.NotNil(t, resp.Data)
-					assert.Equal(t, counter, resp.Data.CountAuthorized)
-					require.Nil(t, resp.Errors)
+func TestUpload(t *testing.T) {
+	_ = `# @genqlient
+	mutation uploadFiles($files: [Upload!]!, $avatar: Upload) {
+		uploadFiles(files: $files, avatar: $avatar) { filename contentType contents }
+	}`
 
-					if time.Since(start) > 5*time.Second {
-						err := wsClient.Unsubscribe(subscriptionID)
-						require.NoError(t, err)
-						loop = false
-					}
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+	client := graphql.NewClient(server.URL, http.DefaultClient)
 
-					counter++
+	resp, _, err := uploadFiles(ctx, client,
+		[]graphql.Upload{
+			{File: strings.NewReader("hello"), Filename: "hello.txt", ContentType: "text/plain"},
+			{File: strings.NewReader("{}"), Filename: "data.json", ContentType: "application/json"},
+		},
+		graphql.Upload{File: strings.NewReader("not really a PNG"), Filename: "avatar.png"})
+	require.NoError(t, err)
 
-				case err := <-errChan:
-					require.NoError(t, err)
+	require.Len(t, resp.UploadFiles, 3)
+	assert.Equal(t, "hello.txt", resp.UploadFiles[0].Filename)
+	assert.Equal(t, "text/plain", resp.UploadFiles[0].ContentType)
+	assert.Equal(t, "hello", resp.UploadFiles[0].Contents)
+	assert.Equal(t, "data.json", resp.UploadFiles[1].Filename)
+	assert.Equal(t, "application/json", resp.UploadFiles[1].ContentType)
+	assert.Equal(t, "{}", resp.UploadFiles[1].Contents)
+	assert.Equal(t, "avatar.png", resp.UploadFiles[2].Filename)
+	assert.Equal(t, "application/octet-stream", resp.UploadFiles[2].ContentType)
+	assert.Equal(t, "not really a PNG", resp.UploadFiles[2].Contents)
+
+	// A zero Upload is sent as null.
+	resp, _, err = uploadFiles(ctx, client,
+		[]graphql.Upload{{File: strings.NewReader("hi"), Filename: "hi.txt"}},
+		graphql.Upload{})
+	require.NoError(t, err)
+	require.Len(t, resp.UploadFiles, 1)
+	assert.Equal(t, "hi", resp.UploadFiles[0].Contents)
+
+	// The GET client can't send uploads.
+	getClient := graphql.NewClientUsingGet(server.URL, http.DefaultClient)
+	err = getClient.MakeRequest(ctx, &graphql.Request{
+		Query:     "query q($f: Upload) { me { id } }",
+		Variables: map[string]interface{}{"f": graphql.Upload{File: strings.NewReader("")}},
+	}, &graphql.Response{})
+	require.EqualError(t, err, "client does not support uploads")
+}
 
-				case <-time.After(10 * time.Second):
-					require.NoError(t, fmt.Errorf("subscription timed out"))
-				}
-			}
-		})
+func TestDefer(t *testing.T) {
+	_ = `# @genqlient
+	fragment DeferredUserFields on User {
+		hair { color }
 	}
+
+	query queryWithDefer($id: ID!) {
+		user(id: $id) {
+			id
+			name
+			...DeferredUserFields @defer
+			... on User @defer(label: "lucky") { luckyNumber }
+		}
+	}`
+
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+
+	var progress []queryWithDeferResponse
+	ctx = graphql.WithIncrementalHandler(ctx, func(resp *graphql.Response) {
+		progress = append(progress, *resp.Data.(*queryWithDeferResponse))
+	})
+
+	client := graphql.NewClient(server.URL, http.DefaultClient)
+	resp, _, err := queryWithDefer(ctx, client, "1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1", resp.User.Id)
+	assert.Equal(t, "Yours Truly", resp.User.Name)
+	assert.True(t, bool(resp.User.DeferredUserFieldsReceived))
+	assert.Equal(t, "Black", resp.User.Hair.Color)
+	assert.True(t, bool(resp.User.LuckyReceived))
+	assert.Equal(t, 17, resp.User.LuckyNumber)
+
+	// The initial response has only the non-deferred fields, then we get
+	// each deferred fragment in turn.
+	require.Len(t, progress, 3)
+	assert.Equal(t, "1", progress[0].User.Id)
+	assert.Equal(t, "Yours Truly", progress[0].User.Name)
+	assert.False(t, bool(progress[0].User.DeferredUserFieldsReceived))
+	assert.Equal(t, "", progress[0].User.Hair.Color)
+	assert.False(t, bool(progress[0].User.LuckyReceived))
+	assert.Equal(t, 0, progress[0].User.LuckyNumber)
+	assert.Equal(t, *resp, progress[2])
+
+	// A server which doesn't support incremental delivery just sends the
+	// whole response, markers and all.  (gqlgen does support it, but
+	// silently drops the deferred fragments for GET requests, so we fake it.)
+	progress = nil
+	plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": {"user": {
+			"id": "1", "name": "Yours Truly", "hair": {"color": "Black"},
+			"genqlient_deferred_DeferredUserFields": "User",
+			"luckyNumber": 17, "genqlient_deferred_lucky": "User"
+		}}}`))
+	}))
+	defer plainServer.Close()
+
+	client = graphql.NewClientUsingGet(plainServer.URL, http.DefaultClient)
+	plainResp, _, err := queryWithDefer(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, resp, plainResp)
+	require.Len(t, progress, 1)
+	assert.Equal(t, *resp, progress[0])
+
+	// A server may also send a deferred fragment inline, e.g. for
+	// @defer(if: false); that fragment never gets a labeled payload, but it's
+	// still received once the response is complete.
+	progress = nil
+	inlineServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", `multipart/mixed; boundary="-"; deferSpec=20220824`)
+		_, _ = w.Write([]byte("\r\n---\r\nContent-Type: application/json\r\n\r\n" +
+			`{"data": {"user": {
+				"id": "1", "name": "Yours Truly",
+				"genqlient_deferred_DeferredUserFields": "User",
+				"luckyNumber": 17, "genqlient_deferred_lucky": "User"
+			}}, "hasNext": true}` +
+			"\r\n---\r\nContent-Type: application/json\r\n\r\n" +
+			`{"incremental": [{
+				"data": {"hair": {"color": "Black"}},
+				"path": ["user"], "label": "DeferredUserFields"
+			}], "hasNext": false}` +
+			"\r\n-----\r\n"))
+	}))
+	defer inlineServer.Close()
+
+	client = graphql.NewClient(inlineServer.URL, http.DefaultClient)
+	inlineResp, _, err := queryWithDefer(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, resp, inlineResp)
+	require.Len(t, progress, 2)
+	assert.False(t, bool(progress[0].User.DeferredUserFieldsReceived))
+	assert.False(t, bool(progress[0].User.LuckyReceived))
+	assert.Equal(t, 17, progress[0].User.LuckyNumber)
+	assert.Equal(t, *resp, progress[1])
 }
 
 func TestServerError(t *testing.T) {
@@ -245,6 +1912,164 @@ func TestServerError(t *testing.T) {
 	}
 }
 
+func TestErrorHelpers(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+	client := graphql.NewClient(server.URL, http.DefaultClient)
+
+	resp, _, err := failingQuery(ctx, client)
+	require.Error(t, err)
+	assert.True(t, resp.FailFailed(err))
+	assert.False(t, resp.MeFailed(err))
+	assert.Len(t, graphql.ErrorsAt(err), 1)
+	assert.Len(t, graphql.ErrorsAt(err, "fail"), 1)
+	assert.Empty(t, graphql.ErrorsAt(err, "me"))
+	assert.Empty(t, graphql.ErrorsAt(err, "fail", 0))
+	assert.Empty(t, graphql.ErrorsAt(nil, "fail"))
+	assert.False(t, resp.FailFailed(nil))
+
+	codeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"data":{"user":null},"errors":[{"message":"slow down","path":["user","friends",1,"name"],`+
+			`"extensions":{"code":"RATE_LIMITED","retryAfter":30}}]}`)
+	}))
+	defer codeServer.Close()
+	client = graphql.NewClient(codeServer.URL, http.DefaultClient)
+
+	_, _, err = queryWithVariables(ctx, client, "1")
+	require.Error(t, err)
+	assert.True(t, graphql.HasErrorCode(err, "RATE_LIMITED"))
+	assert.False(t, graphql.HasErrorCode(err, "BAD_USER_INPUT"))
+	assert.Len(t, graphql.ErrorsAt(fmt.Errorf("wrapped: %w", err), "user", "friends", 1), 1)
+	assert.Empty(t, graphql.ErrorsAt(err, "user", "friends", 0))
+	assert.Empty(t, graphql.ErrorsAt(err, "user", "friends", "1"))
+
+	errs := graphql.ErrorsAt(err, "user")
+	require.Len(t, errs, 1)
+	var ext struct {
+		Code       string `json:"code"`
+		RetryAfter int    `json:"retryAfter"`
+	}
+	require.NoError(t, graphql.DecodeErrorExtensions(errs[0], &ext))
+	assert.Equal(t, "RATE_LIMITED", ext.Code)
+	assert.Equal(t, 30, ext.RetryAfter)
+}
+
+func TestResponseMetadata(t *testing.T) {
+	ctx := context.Background()
+	handler := server.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-"+r.Method)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	for _, client := range newRoundtripClients(t, server.URL) {
+		var md graphql.ResponseMetadata
+		before := time.Now()
+		_, _, err := simpleQuery(graphql.WithResponseMetadata(ctx, &md), client)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, md.StatusCode)
+		assert.Contains(t, []string{"req-GET", "req-POST"}, md.Header.Get("X-Request-Id"))
+		assert.False(t, md.StartTime.Before(before))
+		assert.Positive(t, md.Duration)
+	}
+
+	// Metadata is filled in on error...
+	var md graphql.ResponseMetadata
+	client := graphql.NewClient(server.URL, http.DefaultClient)
+	err := client.MakeRequest(graphql.WithResponseMetadata(ctx, &md),
+		&graphql.Request{Query: "query { notAField }"}, &graphql.Response{})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, md.StatusCode)
+	assert.Equal(t, "req-POST", md.Header.Get("X-Request-Id"))
+
+	// ...and for batched requests.
+	md = graphql.ResponseMetadata{}
+	client = graphql.NewClient(server.URL, http.DefaultClient,
+		graphql.WithBatching(time.Millisecond, 0))
+	_, _, err = simpleQuery(graphql.WithResponseMetadata(ctx, &md), client)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, md.StatusCode)
+	assert.Equal(t, "req-POST", md.Header.Get("X-Request-Id"))
+
+	// But not if there was no response.
+	md = graphql.ResponseMetadata{StatusCode: 500}
+	client = graphql.NewClient("https://nothing.invalid/graphql", http.DefaultClient)
+	_, _, err = simpleQuery(graphql.WithResponseMetadata(ctx, &md), client)
+	require.Error(t, err)
+	assert.Equal(t, 0, md.StatusCode)
+	assert.Nil(t, md.Header)
+}
+
+func TestContextHeadersAndExtensions(t *testing.T) {
+	ctx := context.Background()
+	handler := server.Handler()
+	var mu sync.Mutex
+	var headers []http.Header
+	var extensions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		var payload struct {
+			Extensions json.RawMessage `json:"extensions"`
+		}
+		if r.Method == http.MethodGet {
+			payload.Extensions = json.RawMessage(r.URL.Query().Get("extensions"))
+		} else {
+			_ = json.Unmarshal(body, &payload)
+		}
+		mu.Lock()
+		headers = append(headers, r.Header)
+		extensions = append(extensions, string(payload.Extensions))
+		mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	ctx = graphql.WithHeader(ctx, "X-Tenant", "khan")
+	ctx = graphql.WithHeader(ctx, "x-flags", "a")
+	ctx = graphql.WithHeader(ctx, "X-Flags", "b")
+	ctx = graphql.WithExtensions(ctx, map[string]interface{}{"a": 1, "b": 2})
+	ctx = graphql.WithExtensions(ctx, map[string]interface{}{"b": 3})
+
+	for _, client := range newRoundtripClients(t, server.URL) {
+		headers, extensions = nil, nil
+		resp, _, err := simpleQuery(ctx, client)
+		require.NoError(t, err)
+		assert.Equal(t, "1", resp.Me.Id)
+		require.Len(t, headers, 1)
+		assert.Equal(t, "khan", headers[0].Get("X-Tenant"))
+		assert.Equal(t, []string{"a", "b"}, headers[0].Values("X-Flags"))
+		assert.JSONEq(t, `{"a": 1, "b": 3}`, extensions[0])
+	}
+
+	// Requests with headers aren't batched, since the headers would apply to
+	// the whole batch.
+	headers, extensions = nil, nil
+	client := graphql.NewClient(server.URL, http.DefaultClient,
+		graphql.WithBatching(10*time.Millisecond, 0))
+	var wg sync.WaitGroup
+	wg.Add(2)
+	var err1, err2 error
+	go func() {
+		defer wg.Done()
+		_, _, err1 = simpleQuery(ctx, client)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _, err2 = simpleQuery(context.Background(), client)
+	}()
+	wg.Wait()
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	require.Len(t, headers, 2)
+	tenants := []string{headers[0].Get("X-Tenant"), headers[1].Get("X-Tenant")}
+	assert.ElementsMatch(t, []string{"khan", ""}, tenants)
+}
+
 func TestNetworkError(t *testing.T) {
 	ctx := context.Background()
 	clients := newRoundtripClients(t, "https://nothing.invalid/graphql")
@@ -262,6 +2087,58 @@ func TestNetworkError(t *testing.T) {
 	}
 }
 
+func TestHTTPError(t *testing.T) {
+	ctx := context.Background()
+	server := server.RunServer()
+	defer server.Close()
+	client := graphql.NewClient(server.URL, http.DefaultClient)
+
+	// gqlgen responds to invalid queries with a 422, and the errors.
+	resp := graphql.Response{}
+	err := client.MakeRequest(ctx,
+		&graphql.Request{Query: "query { notAField }"}, &resp)
+	var httpErr *graphql.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, httpErr.StatusCode)
+	assert.Contains(t, string(httpErr.Body), "notAField")
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "notAField")
+
+	// Other bodies are left alone.
+	plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failWithStatus(http.StatusBadGateway, http.Header{"X-Why": {"oops"}})(w)
+	}))
+	defer plainServer.Close()
+	client = graphql.NewClient(plainServer.URL, http.DefaultClient)
+	simpleResp, _, err := simpleQuery(ctx, client)
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadGateway, httpErr.StatusCode)
+	assert.Equal(t, "oops", httpErr.Header.Get("X-Why"))
+	assert.Equal(t, "try again later", string(httpErr.Body))
+	assert.EqualError(t, err, "returned error 502 Bad Gateway: try again later")
+	assert.Equal(t, new(simpleQueryResponse), simpleResp)
+
+	// Servers using the GraphQL response media type may return partial data
+	// with a non-2xx status.
+	var accept string
+	specServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/graphql-response+json; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"data":{"me":{"id":"1","name":"Yours Truly"}},"errors":[{"message":"oops"}]}`)
+	}))
+	defer specServer.Close()
+	client = graphql.NewClient(specServer.URL, http.DefaultClient)
+	simpleResp, _, err = simpleQuery(ctx, client)
+	assert.Equal(t, "application/graphql-response+json, application/json", accept)
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusInternalServerError, httpErr.StatusCode)
+	var errList gqlerror.List
+	require.ErrorAs(t, err, &errList)
+	assert.Equal(t, "oops", errList[0].Message)
+	assert.Equal(t, "Yours Truly", simpleResp.Me.Name)
+}
+
 func TestVariables(t *testing.T) {
 	_ = `# @genqlient
 	query queryWithVariables($id: ID!) { user(id: $id) { id name luckyNumber } }`
@@ -900,6 +2777,343 @@ func TestFlatten(t *testing.T) {
 	}
 }
 
+// fakeT is a testing.TB which records its failures, for testing test
+// helpers.
+type fakeT struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+	mu       sync.Mutex
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// Fatalf records the error, and stops the goroutine (which should not be the
+// test's own).
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (t *fakeT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+// finish runs the test's cleanups, and returns its errors.
+func (t *fakeT) finish() []string {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.errors
+}
+
+func TestMockClient(t *testing.T) {
+	ctx := context.Background()
+	mockT := &fakeT{TB: t}
+	client := graphqltest.NewClient(mockT)
+
+	client.Expect("queryWithVariables").
+		WithVariables(map[string]interface{}{"id": "2"}).
+		Respond(&queryWithVariablesResponse{User: queryWithVariablesUser{Id: "2", Name: "Raven"}})
+	client.Expect("queryWithVariables").
+		WithVariablesMatching(func(variables map[string]interface{}) bool {
+			return variables["id"] != "2"
+		}).
+		RespondJSON(`{"data": {"user": null}, "errors": [{"message": "not found", "path": ["user"]}]}`).
+		Times(2)
+	client.Expect("simpleQuery").RespondError(errors.New("network down"))
+
+	resp, _, err := queryWithVariables(ctx, client, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Raven", resp.User.Name)
+
+	for _, id := range []string{"3", "4"} {
+		resp, _, err = queryWithVariables(ctx, client, id)
+		require.Error(t, err)
+		assert.True(t, resp.UserFailed(err))
+	}
+
+	_, _, err = simpleQuery(ctx, client)
+	assert.EqualError(t, err, "network down")
+
+	// Each expectation is used only as many times as expected.
+	_, _, err = queryWithVariables(ctx, client, "2")
+	assert.EqualError(t, err,
+		`graphqltest: unexpected operation queryWithVariables with variables {"id":"2"}`)
+
+	// Subscriptions stream the data the test sends.
+	stream := client.ExpectSubscription("count")
+	stream.Send(&countResponse{Count: 1})
+	dataChan, _, err := count(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, 1, (<-dataChan).Data.Count)
+	stream.Send(&countResponse{Count: 2})
+	stream.SendJSON(`{"data": null, "errors": [{"message": "oh no"}]}`)
+	stream.Complete()
+	assert.Equal(t, 2, (<-dataChan).Data.Count)
+	assert.EqualError(t, (<-dataChan).Errors, "input: oh no\n")
+	_, more := <-dataChan
+	assert.False(t, more)
+
+	// Subscriptions can't be made via MakeRequest, however the query begins.
+	err = client.MakeRequest(ctx,
+		&graphql.Request{Query: "# a comment\n" + count_Operation, OpName: "count"},
+		&graphql.Response{})
+	assert.EqualError(t, err, "graphqltest: subscription count made via MakeRequest; use Subscribe")
+
+	// At the end of the test, it checks that each expectation was met.
+	client.Expect("createUser")
+	client.ExpectSubscription("countAuthorized")
+	assert.Equal(t, []string{
+		`graphqltest: unexpected operation queryWithVariables with variables {"id":"2"}`,
+		"graphqltest: subscription count made via MakeRequest; use Subscribe",
+		"graphqltest: expected operation createUser 1 times, but it was made 0 times",
+		"graphqltest: expected subscription countAuthorized, but it was not made",
+	}, mockT.finish())
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir() + "/recording.json"
+	t.Setenv(graphqltest.RecordEnvVar, "")
+
+	// useClients makes some requests, and checks their responses, which are
+	// the same whether recorded or replayed.
+	useClients := func(
+		reqCtx context.Context,
+		client graphql.Client,
+		wsClient graphql.WebSocketClient,
+		reverse bool,
+	) {
+		ids := []string{"1", "2"}
+		names := []string{"Yours Truly", "Raven"}
+		if reverse {
+			ids = []string{"2", "1"}
+			names = []string{"Raven", "Yours Truly"}
+		}
+		for i, id := range ids {
+			resp, _, err := queryWithVariables(reqCtx, client, id)
+			require.NoError(t, err)
+			assert.Equal(t, names[i], resp.User.Name)
+		}
+		_, _, err := failingQuery(reqCtx, client)
+		assert.EqualError(t, err, "input: fail oh no\n")
+
+		_, err = wsClient.Start(ctx)
+		require.NoError(t, err)
+		resp, _, err := simpleQuery(reqCtx, wsClient.(graphql.Client))
+		require.NoError(t, err)
+		assert.Equal(t, "Yours Truly", resp.Me.Name)
+		dataChan, subscriptionID, err := countAuthorized(reqCtx, wsClient)
+		require.NoError(t, err)
+		assert.Equal(t, 0, (<-dataChan).Data.CountAuthorized)
+		assert.Equal(t, 1, (<-dataChan).Data.CountAuthorized)
+		drained := make(chan struct{})
+		go func() {
+			defer close(drained)
+			for range dataChan {
+			}
+		}()
+		require.NoError(t, wsClient.Unsubscribe(subscriptionID))
+		<-drained
+		require.NoError(t, wsClient.Close())
+	}
+
+	authKey := server.AuthKey
+	server := server.RunServer()
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+	connParams := map[string]interface{}{authKey: "authorized-user-token"}
+
+	// The first time, the requests are recorded...
+	mockT := &fakeT{TB: t}
+	rec := graphqltest.NewRecorder(mockT, path, graphqltest.RedactHeaders("X-Secret", authKey))
+	assert.True(t, rec.Recording())
+	useClients(
+		graphql.WithHeader(graphql.WithHeader(ctx, "Authorization", "Bearer secret-token"),
+			"X-Secret", "secret-value"),
+		graphql.NewClient(server.URL, rec.Doer(http.DefaultClient)),
+		graphql.NewClientUsingWebSocketWithConnectionParams(endpoint,
+			rec.Dialer(&MyDialer{Dialer: websocket.DefaultDialer}), nil, connParams),
+		false)
+	assert.Empty(t, mockT.finish())
+	server.Close()
+
+	recording, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(recording), "REDACTED")
+	assert.NotContains(t, string(recording), "secret")
+	assert.NotContains(t, string(recording), "authorized-user-token")
+
+	// ...and then replayed, even though the server is gone.
+	mockT = &fakeT{TB: t}
+	rec = graphqltest.NewRecorder(mockT, path)
+	assert.False(t, rec.Recording())
+	client := graphql.NewClient(server.URL, rec.Doer(nil))
+	useClients(ctx, client,
+		graphql.NewClientUsingWebSocketWithConnectionParams(endpoint, rec.Dialer(nil), nil, nil),
+		true)
+
+	// Requests which weren't recorded fail.
+	_, _, err = queryWithVariables(ctx, client, "3")
+	require.Error(t, err)
+	assert.Equal(t, []string{err.Error()}, mockT.finish())
+}
+
+func TestFakeServer(t *testing.T) {
+	ctx := context.Background()
+	opts := []graphqltest.ServerOption{
+		graphqltest.WithResolver("Query", "user",
+			func(_ context.Context, args map[string]interface{}) (interface{}, error) {
+				if args["id"] != "1" {
+					return nil, nil
+				}
+				return map[string]interface{}{"id": "1", "name": "Ada"}, nil
+			}),
+		graphqltest.WithResolver("Query", "fail",
+			func(context.Context, map[string]interface{}) (interface{}, error) {
+				return nil, errors.New("oh no")
+			}),
+		graphqltest.WithScalar("Date", func(r *rand.Rand) interface{} {
+			return time.Date(2000, 1, 1+r.Intn(365), 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+		}),
+		graphqltest.WithScalar("MyGreatScalar", func(r *rand.Rand) interface{} {
+			return "great " + strconv.Itoa(r.Intn(10))
+		}),
+	}
+	fakeServer := graphqltest.NewServer(t, []string{"schema.graphql"}, opts...)
+	client := graphql.NewClient(fakeServer.URL, http.DefaultClient)
+
+	// Fields with no resolver get fake data, which is the same every time...
+	resp, _, err := simpleQuery(ctx, client)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Me.Id)
+	assert.True(t, strings.HasPrefix(resp.Me.Name, "name "), resp.Me.Name)
+	assert.NotNil(t, resp.Me.LuckyNumber)
+	again, _, err := simpleQuery(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, resp, again)
+
+	// ...unless the seed changes.
+	otherServer := graphqltest.NewServer(t, []string{"schema.graphql"},
+		append(opts, graphqltest.WithSeed(1))...)
+	other, _, err := simpleQuery(ctx, graphql.NewClient(otherServer.URL, http.DefaultClient))
+	require.NoError(t, err)
+	assert.NotEqual(t, resp, other)
+
+	// Resolvers override the fake data, which fills in any fields they omit.
+	userResp, _, err := queryWithVariables(ctx, client, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "1", userResp.User.Id)
+	assert.Equal(t, "Ada", userResp.User.Name)
+	assert.NotNil(t, userResp.User.LuckyNumber)
+	userResp, _, err = queryWithVariables(ctx, client, "2")
+	require.NoError(t, err)
+	assert.Zero(t, userResp.User)
+
+	_, _, err = failingQuery(ctx, client)
+	assert.EqualError(t, err, "input:3: fail oh no\n")
+
+	// Interfaces get a concrete type, and lists the default length.
+	beingsResp, _, err := queryWithFragments(ctx, client, []string{"1"})
+	require.NoError(t, err)
+	require.Len(t, beingsResp.Beings, 2)
+	for _, being := range beingsResp.Beings {
+		switch being := being.(type) {
+		case *queryWithFragmentsBeingsUser:
+			assert.Equal(t, "User", being.Typename)
+		case *queryWithFragmentsBeingsAnimal:
+			assert.Equal(t, "Animal", being.Typename)
+			assert.Contains(t, []Species{SpeciesDog, SpeciesCoelacanth}, being.Species)
+		default:
+			t.Errorf("unexpected being %#v", being)
+		}
+	}
+
+	usersResp, _, err := queryWithCustomMarshal(ctx, client, time.Now())
+	require.NoError(t, err)
+	require.Len(t, usersResp.UsersBornOn, 2)
+	assert.Equal(t, 2000, usersResp.UsersBornOn[0].Birthdate.Year())
+
+	// Subscriptions are served over a WebSocket.
+	wsClient := graphql.NewClientUsingWebSocket(fakeServer.WebSocketURL(),
+		&MyDialer{Dialer: websocket.DefaultDialer}, nil)
+	_, err = wsClient.Start(ctx)
+	require.NoError(t, err)
+	defer wsClient.Close()
+	dataChan, _, err := count(ctx, wsClient)
+	require.NoError(t, err)
+	var events int
+	for event := range dataChan {
+		require.Nil(t, event.Errors)
+		events++
+	}
+	assert.Equal(t, 3, events)
+
+	// The server can't fake custom scalars on its own.  (Upload is used
+	// only as an argument, so it's fine.)
+	mockT := &fakeT{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		graphqltest.NewServer(mockT, []string{"schema.graphql"})
+	}()
+	<-done
+	assert.Equal(t, []string{
+		"graphqltest: no fake values for custom scalars Date, MyGreatScalar; see WithScalar",
+	}, mockT.finish())
+}
+
+func TestHandlerClient(t *testing.T) {
+	ctx := context.Background()
+	handler := server.Handler()
+	client := graphql.NewClientForHandler(handler)
+
+	resp, _, err := simpleQuery(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", resp.Me.Name)
+
+	userResp, _, err := createUser(ctx, client, NewUser{Name: "Jack"})
+	require.NoError(t, err)
+	assert.Equal(t, "Jack", userResp.CreateUser.Name)
+
+	_, _, err = failingQuery(ctx, client)
+	assert.EqualError(t, err, "input: fail oh no\n")
+
+	getClient := graphql.NewClientUsingGet("http://example.com/", graphql.NewHandlerDoer(handler))
+	resp, _, err = simpleQuery(ctx, getClient)
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", resp.Me.Name)
+
+	wsClient := graphql.NewClientUsingWebSocket("ws://localhost/", graphql.NewHandlerDialer(handler), nil)
+	_, err = wsClient.Start(ctx)
+	require.NoError(t, err)
+	resp, _, err = simpleQuery(ctx, wsClient.(graphql.Client))
+	require.NoError(t, err)
+	assert.Equal(t, "Yours Truly", resp.Me.Name)
+
+	dataChan, subscriptionID, err := count(ctx, wsClient)
+	require.NoError(t, err)
+	assert.Equal(t, 0, (<-dataChan).Data.Count)
+	assert.Equal(t, 1, (<-dataChan).Data.Count)
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		for range dataChan {
+		}
+	}()
+	require.NoError(t, wsClient.Unsubscribe(subscriptionID))
+	<-drained
+	require.NoError(t, wsClient.Close())
+}
+
 func TestGeneratedCode(t *testing.T) {
 	// TODO(benkraft): Check that gqlgen is up to date too.  In practice that's
 	// less likely to be a problem, since it should only change if you update
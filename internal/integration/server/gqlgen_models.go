@@ -39,10 +39,25 @@ type Hair struct {
 	Color *string `json:"color,omitempty"`
 }
 
+type Mutation struct {
+}
+
 type NewUser struct {
 	Name string `json:"name"`
 }
 
+type Query struct {
+}
+
+type Subscription struct {
+}
+
+type UploadedFile struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Contents    string `json:"contents"`
+}
+
 type User struct {
 	ID          string  `json:"id"`
 	Name        string  `json:"name"`
@@ -2,14 +2,25 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/google/uuid"
 )
 
 func strptr(v string) *string { return &v }
@@ -156,6 +167,25 @@ func (m mutationResolver) CreateUser(ctx context.Context, input NewUser) (*User,
 	return &newUser, nil
 }
 
+func (m mutationResolver) UploadFiles(ctx context.Context, files []*graphql.Upload, avatar *graphql.Upload) ([]*UploadedFile, error) {
+	if avatar != nil {
+		files = append(files, avatar)
+	}
+	uploaded := make([]*UploadedFile, len(files))
+	for i, file := range files {
+		contents, err := io.ReadAll(file.File)
+		if err != nil {
+			return nil, err
+		}
+		uploaded[i] = &UploadedFile{
+			Filename:    file.Filename,
+			ContentType: file.ContentType,
+			Contents:    string(contents),
+		}
+	}
+	return uploaded, nil
+}
+
 func (s *subscriptionResolver) Count(ctx context.Context) (<-chan int, error) {
 	respChan := make(chan int, 1)
 	go func(respChan chan int) {
@@ -198,12 +228,184 @@ func getAuthToken(ctx context.Context) string {
 	return ""
 }
 
-func RunServer() *httptest.Server {
-	gqlgenServer := handler.New(NewExecutableSchema(Config{Resolvers: &resolver{}}))
+// multipartMixed is a gqlgen transport which sends incremental (@defer)
+// responses as multipart/mixed, as described in the incremental delivery
+// RFC.  gqlgen implements @defer, but doesn't (yet) have such a transport.
+type multipartMixed struct{}
+
+func (multipartMixed) Supports(r *http.Request) bool {
+	return r.Method == http.MethodPost &&
+		strings.Contains(r.Header.Get("Accept"), "multipart/mixed")
+}
+
+func (multipartMixed) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	ctx := r.Context()
+	params := &graphql.RawParams{Headers: r.Header}
+	err := json.NewDecoder(r.Body).Decode(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	form := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+form.Boundary()+"; deferSpec=20220824")
+	writePart := func(response *graphql.Response) {
+		part, err := form.CreatePart(textproto.MIMEHeader{"Content-Type": {"application/json"}})
+		if err == nil {
+			_ = json.NewEncoder(part).Encode(response)
+		}
+		w.(http.Flusher).Flush()
+	}
+
+	rc, opErr := exec.CreateOperationContext(ctx, params)
+	if opErr != nil {
+		writePart(exec.DispatchError(graphql.WithOperationContext(ctx, rc), opErr))
+	} else {
+		responses, responseCtx := exec.DispatchOperation(graphql.WithOperationContext(ctx, rc), rc)
+		for response := responses(responseCtx); response != nil; response = responses(responseCtx) {
+			writePart(response)
+		}
+	}
+	_ = form.Close()
+}
+
+// sseSingleConnection serves the "single connection mode" of the graphql-sse
+// protocol, which gqlgen doesn't support (its SSE transport supports only
+// "distinct connections mode").  It handles PUT requests, which reserve an
+// event stream, and requests for a reserved stream; anything else is passed
+// to next.
+type sseSingleConnection struct {
+	next    http.Handler
+	exec    *executor.Executor
+	streams map[string]*sseStream
+	mu      sync.Mutex
+}
+
+type sseStream struct {
+	events  chan []byte
+	cancels map[string]context.CancelFunc
+	mu      sync.Mutex
+}
+
+func (s *sseSingleConnection) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		token := uuid.NewString()
+		s.mu.Lock()
+		s.streams[token] = &sseStream{events: make(chan []byte), cancels: map[string]context.CancelFunc{}}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(token))
+		return
+	}
+
+	token := r.Header.Get("X-GraphQL-Event-Stream-Token")
+	if token == "" {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+	s.mu.Lock()
+	stream := s.streams[token]
+	s.mu.Unlock()
+	if stream == nil {
+		http.Error(w, "unknown stream", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case event := <-stream.events:
+				_, _ = w.Write(event)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				s.mu.Lock()
+				delete(s.streams, token)
+				s.mu.Unlock()
+				stream.mu.Lock()
+				for _, cancel := range stream.cancels {
+					cancel()
+				}
+				stream.mu.Unlock()
+				return
+			}
+		}
+
+	case http.MethodPost:
+		params := &graphql.RawParams{Headers: r.Header}
+		err := json.NewDecoder(r.Body).Decode(params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		opID, _ := params.Extensions["operationId"].(string)
+		// The operation outlives this request, so it gets its own context.
+		ctx, cancel := context.WithCancel(graphql.StartOperationTrace(context.Background()))
+		stream.mu.Lock()
+		stream.cancels[opID] = cancel
+		stream.mu.Unlock()
+		go s.execute(ctx, stream, opID, params)
+		w.WriteHeader(http.StatusAccepted)
+
+	case http.MethodDelete:
+		opID := r.URL.Query().Get("operationId")
+		stream.mu.Lock()
+		if cancel := stream.cancels[opID]; cancel != nil {
+			cancel()
+		}
+		delete(stream.cancels, opID)
+		stream.mu.Unlock()
+
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+	}
+}
+
+// execute executes the given operation, sending its responses on the stream.
+func (s *sseSingleConnection) execute(ctx context.Context, stream *sseStream, opID string, params *graphql.RawParams) {
+	send := func(eventType string, payload *graphql.Response) bool {
+		data, _ := json.Marshal(map[string]interface{}{"id": opID, "payload": payload})
+		select {
+		case stream.events <- []byte("event: " + eventType + "\ndata: " + string(data) + "\n\n"):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	rc, opErr := s.exec.CreateOperationContext(ctx, params)
+	if opErr != nil {
+		if !send("next", s.exec.DispatchError(graphql.WithOperationContext(ctx, rc), opErr)) {
+			return
+		}
+	} else {
+		responses, responseCtx := s.exec.DispatchOperation(graphql.WithOperationContext(ctx, rc), rc)
+		for response := responses(responseCtx); response != nil; response = responses(responseCtx) {
+			if !send("next", response) {
+				return
+			}
+		}
+	}
+	send("complete", nil)
+}
+
+// Handler returns the test server's http.Handler, for tests which need to
+// wrap it.  Most tests should just use RunServer.
+func Handler() http.Handler {
+	schema := NewExecutableSchema(Config{Resolvers: &resolver{}})
+	gqlgenServer := handler.New(schema)
+	gqlgenServer.AddTransport(multipartMixed{})
+	gqlgenServer.AddTransport(transport.SSE{})
 	gqlgenServer.AddTransport(transport.POST{})
 	gqlgenServer.AddTransport(transport.GET{})
+	gqlgenServer.AddTransport(transport.MultipartForm{})
 
 	gqlgenServer.AddTransport(transport.Websocket{
+		// The server pings the client (and expects a pong) this often, so
+		// that subscription tests exercise the client's responses.
+		PingPongInterval: 200 * time.Millisecond,
 		InitFunc: func(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
 			if authToken, ok := initPayload[AuthKey].(string); ok && authToken != "" {
 				ctx = withAuthToken(ctx, authToken)
@@ -212,11 +414,21 @@ func RunServer() *httptest.Server {
 		},
 	})
 
+	gqlgenServer.Use(extension.AutomaticPersistedQuery{Cache: lru.New(100)})
+
 	gqlgenServer.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
 		graphql.RegisterExtension(ctx, "foobar", "test")
 		return next(ctx)
 	})
-	return httptest.NewServer(gqlgenServer)
+	return &sseSingleConnection{
+		next:    gqlgenServer,
+		exec:    executor.New(schema),
+		streams: map[string]*sseStream{},
+	}
+}
+
+func RunServer() *httptest.Server {
+	return httptest.NewServer(Handler())
 }
 
 type (
@@ -224,6 +436,7 @@ type (
 	queryResolver        struct{}
 	mutationResolver     struct{}
 	subscriptionResolver struct{}
+	userResolver         struct{}
 )
 
 func (r *resolver) Mutation() MutationResolver {
@@ -236,4 +449,14 @@ func (r *resolver) Subscription() SubscriptionResolver {
 	return &subscriptionResolver{}
 }
 
-//go:generate go run github.com/99designs/gqlgen@v0.17.35
+func (r *resolver) User() UserResolver { return &userResolver{} }
+
+func (r *userResolver) LuckyNumber(ctx context.Context, obj *User) (*int, error) {
+	return obj.LuckyNumber, nil
+}
+
+func (r *userResolver) Hair(ctx context.Context, obj *User) (*Hair, error) {
+	return obj.Hair, nil
+}
+
+//go:generate go run github.com/99designs/gqlgen@v0.17.44
@@ -0,0 +1,336 @@
+// Package wsconn implements just enough of the WebSocket protocol (RFC 6455)
+// for genqlient's testing support (the in-memory handler dialer, and the
+// fake server), which, unlike genqlient's own tests, may not depend on a
+// WebSocket library.
+//
+// It supports what GraphQL-over-WebSocket needs: whole text or binary
+// messages, pings, and close messages; it does not support extensions.
+package wsconn
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// The message types, as in [github.com/gorilla/websocket].
+const (
+	continuationFrame = 0
+	TextMessage       = 1
+	BinaryMessage     = 2
+	CloseMessage      = 8
+	PingMessage       = 9
+	PongMessage       = 10
+)
+
+// CloseNoStatusReceived is the close code reported when the peer's close
+// message has no code.
+const CloseNoStatusReceived = 1005
+
+// maxMessageSize is the largest message we accept (whether in one frame or
+// several), to avoid allocating without bound for a corrupt frame.
+const maxMessageSize = 32 << 20
+
+// acceptGUID is the GUID used to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// CloseError is returned by [Conn.ReadMessage] when the peer closes the
+// connection.
+type CloseError struct {
+	Text string
+	Code int
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// Conn is either end of a WebSocket connection.  ReadMessage may be called
+// concurrently with WriteMessage, and WriteMessage concurrently with itself.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	subprotocol string
+	writeMu     sync.Mutex
+	closeSent   bool
+	// Whether this is the client end, which must mask the frames it sends.
+	isClient bool
+}
+
+// IsUpgrade returns whether r asks to upgrade to a WebSocket connection.
+func IsUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") &&
+		headerContains(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade upgrades the HTTP connection of r to a WebSocket connection,
+// choosing the first of the subprotocols the client requested which is among
+// those given.  If it fails, it has already responded with an HTTP error.
+func Upgrade(w http.ResponseWriter, r *http.Request, subprotocols []string) (*Conn, error) {
+	key := r.Header.Get("Sec-Websocket-Key")
+	if !IsUpgrade(r) || key == "" || r.Header.Get("Sec-Websocket-Version") != "13" {
+		http.Error(w, "not a websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket: response does not implement http.Hijacker", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not implement http.Hijacker")
+	}
+
+	var subprotocol string
+	for _, requested := range strings.Split(r.Header.Get("Sec-Websocket-Protocol"), ",") {
+		requested = strings.TrimSpace(requested)
+		for _, supported := range subprotocols {
+			if subprotocol == "" && requested == supported {
+				subprotocol = supported
+			}
+		}
+	}
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if subprotocol != "" {
+		response += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	_, err = netConn.Write([]byte(response + "\r\n"))
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return &Conn{conn: netConn, br: brw.Reader, subprotocol: subprotocol}, nil
+}
+
+// Handshake performs the client's side of the WebSocket handshake over conn,
+// which should be connected to the server for urlStr, sending the given
+// headers in addition to those the handshake requires.
+func Handshake(conn net.Conn, urlStr string, header http.Header) (*Conn, error) {
+	req, err := http.NewRequest(http.MethodGet, urlStr, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	switch req.URL.Scheme {
+	case "ws":
+		req.URL.Scheme = "http"
+	case "wss":
+		req.URL.Scheme = "https"
+	}
+	var nonce [16]byte
+	_, err = rand.Read(nonce[:])
+	if err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-Websocket-Key", key)
+	req.Header.Set("Sec-Websocket-Version", "13")
+
+	err = req.Write(conn)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("websocket: bad handshake: status %v", resp.Status)
+	}
+	if resp.Header.Get("Sec-Websocket-Accept") != acceptKey(key) {
+		return nil, errors.New("websocket: bad handshake: invalid Sec-WebSocket-Accept")
+	}
+	return &Conn{
+		conn:        conn,
+		br:          br,
+		subprotocol: resp.Header.Get("Sec-Websocket-Protocol"),
+		isClient:    true,
+	}, nil
+}
+
+// acceptKey computes the Sec-WebSocket-Accept header for the given
+// Sec-WebSocket-Key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContains returns whether the comma-separated header name contains
+// the given token, case-insensitively.
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, elem := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(elem), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Subprotocol returns the subprotocol chosen during the handshake, if any.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Close closes the underlying connection, without sending a close message.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// WriteMessage writes a message of the given type.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	var maskBit byte
+	if c.isClient {
+		maskBit = 0x80
+	}
+	frame := make([]byte, 0, len(data)+14)
+	frame = append(frame, 0x80|byte(messageType))
+	switch n := len(data); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if c.isClient {
+		var mask [4]byte
+		_, err := rand.Read(mask[:])
+		if err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, data...)
+		for i := range data {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, data...)
+	}
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// ReadMessage reads the next text or binary message.  It responds to pings
+// and close messages; the latter are returned as a [*CloseError].
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	for {
+		fin, opcode, payload, err := c.readFrame(len(p))
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case PingMessage:
+			err = c.WriteMessage(PongMessage, payload)
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			closeErr := &CloseError{Code: CloseNoStatusReceived}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Text = string(payload[2:])
+				payload = payload[:2]
+			}
+			// The protocol requires we echo the close, unless we sent it.
+			c.writeMu.Lock()
+			closeSent := c.closeSent
+			c.writeMu.Unlock()
+			if !closeSent {
+				_ = c.WriteMessage(CloseMessage, payload)
+			}
+			return 0, nil, closeErr
+		case continuationFrame:
+			p = append(p, payload...)
+		default:
+			messageType, p = opcode, payload
+		}
+		if fin && messageType != 0 {
+			return messageType, p, nil
+		}
+	}
+}
+
+// readFrame reads a single frame, unmasking its payload if needed.  buffered
+// is the length of the message so far, to which a continuation frame adds.
+func (c *Conn) readFrame(buffered int) (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	_, err = io.ReadFull(c.br, header[:])
+	if err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0f)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		_, err = io.ReadFull(c.br, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, err = io.ReadFull(c.br, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if err != nil {
+		return false, 0, nil, err
+	}
+	limit := uint64(maxMessageSize)
+	if opcode == continuationFrame {
+		limit -= uint64(buffered)
+	}
+	if length > limit {
+		return false, 0, nil, fmt.Errorf("websocket: message of more than %d bytes is too large", maxMessageSize)
+	}
+	var mask [4]byte
+	if masked {
+		_, err = io.ReadFull(c.br, mask[:])
+		if err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	_, err = io.ReadFull(c.br, payload)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
@@ -0,0 +1,184 @@
+package wsconn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn is a net.Conn which reads from r, and records what's written.
+type fakeConn struct {
+	net.Conn
+	r       io.Reader
+	written bytes.Buffer
+}
+
+func (c *fakeConn) Read(p []byte) (int, error)  { return c.r.Read(p) }
+func (c *fakeConn) Write(p []byte) (int, error) { return c.written.Write(p) }
+
+// newConn returns a Conn which reads the given bytes.
+func newConn(input []byte, isClient bool) (*Conn, *fakeConn) {
+	conn := &fakeConn{r: bytes.NewReader(input)}
+	return &Conn{conn: conn, br: bufio.NewReader(conn), isClient: isClient}, conn
+}
+
+// concat returns the concatenation of the given byte slices.
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestWriteMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantHeader  []byte
+		length      int
+		messageType int
+	}{
+		{"Empty", []byte{0x81, 0}, 0, TextMessage},
+		{"Short", []byte{0x81, 125}, 125, TextMessage},
+		{"Length16", []byte{0x82, 126, 0, 126}, 126, BinaryMessage},
+		{"MaxLength16", []byte{0x82, 126, 0xff, 0xff}, 0xffff, BinaryMessage},
+		{"Length64", []byte{0x82, 127, 0, 0, 0, 0, 0, 1, 0, 0}, 0x10000, BinaryMessage},
+		{"Close", []byte{0x88, 2}, 2, CloseMessage},
+	}
+
+	for _, test := range tests {
+		test := test
+		data := bytes.Repeat([]byte("x"), test.length)
+
+		t.Run(test.name, func(t *testing.T) {
+			c, conn := newConn(nil, false)
+			require.NoError(t, c.WriteMessage(test.messageType, data))
+			assert.Equal(t, concat(test.wantHeader, data), conn.written.Bytes())
+			assert.Equal(t, test.messageType == CloseMessage, c.closeSent)
+		})
+
+		t.Run(test.name+"Masked", func(t *testing.T) {
+			c, conn := newConn(nil, true)
+			require.NoError(t, c.WriteMessage(test.messageType, data))
+
+			written := conn.written.Bytes()
+			header := append([]byte{}, test.wantHeader...)
+			header[1] |= 0x80
+			require.Len(t, written, len(header)+4+len(data))
+			assert.Equal(t, header, written[:len(header)])
+			mask := written[len(header) : len(header)+4]
+			payload := written[len(header)+4:]
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+			assert.Equal(t, data, payload)
+		})
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	hello := []byte("Hello")
+	long16 := bytes.Repeat([]byte("y"), 300)
+	long64 := bytes.Repeat([]byte("z"), 0x10000)
+	tooLong := make([]byte, 8)
+	binary.BigEndian.PutUint64(tooLong, maxMessageSize+1)
+	rest := make([]byte, 8)
+	binary.BigEndian.PutUint64(rest, maxMessageSize-4)
+
+	tests := []struct {
+		wantClose   *CloseError
+		name        string
+		wantErr     string
+		input       []byte
+		want        []byte
+		wantWritten []byte
+		wantType    int
+	}{
+		{
+			name:     "Unmasked",
+			input:    concat([]byte{0x81, 5}, hello),
+			wantType: TextMessage,
+			want:     hello,
+		},
+		{
+			// The example from RFC 6455, section 5.7.
+			name:     "Masked",
+			input:    []byte{0x81, 0x85, 0x37, 0xfa, 0x21, 0x3d, 0x7f, 0x9f, 0x4d, 0x51, 0x58},
+			wantType: TextMessage,
+			want:     hello,
+		},
+		{
+			name:     "Length16",
+			input:    concat([]byte{0x82, 126, 0x01, 0x2c}, long16),
+			wantType: BinaryMessage,
+			want:     long16,
+		},
+		{
+			name:     "Length64",
+			input:    concat([]byte{0x82, 127, 0, 0, 0, 0, 0, 1, 0, 0}, long64),
+			wantType: BinaryMessage,
+			want:     long64,
+		},
+		{
+			name: "FragmentedWithPing",
+			input: concat(
+				[]byte{0x01, 3}, hello[:3],
+				[]byte{0x89, 2}, []byte("hi"),
+				[]byte{0x80, 2}, hello[3:]),
+			wantType:    TextMessage,
+			want:        hello,
+			wantWritten: concat([]byte{0x8a, 2}, []byte("hi")),
+		},
+		{
+			name:        "CloseWithCode",
+			input:       concat([]byte{0x88, 5, 0x03, 0xe8}, []byte("bye")),
+			wantClose:   &CloseError{Code: 1000, Text: "bye"},
+			wantWritten: []byte{0x88, 2, 0x03, 0xe8},
+		},
+		{
+			name:        "CloseWithoutCode",
+			input:       []byte{0x88, 0},
+			wantClose:   &CloseError{Code: CloseNoStatusReceived},
+			wantWritten: []byte{0x88, 0},
+		},
+		{
+			name:    "FrameTooLarge",
+			input:   concat([]byte{0x82, 127}, tooLong),
+			wantErr: "websocket: message of more than 33554432 bytes is too large",
+		},
+		{
+			// Each frame is within the limit, but together they're not.
+			name:    "MessageTooLarge",
+			input:   concat([]byte{0x02, 5}, hello, []byte{0x80, 127}, rest),
+			wantErr: "websocket: message of more than 33554432 bytes is too large",
+		},
+		{
+			name:    "Truncated",
+			input:   concat([]byte{0x81, 5}, hello[:3]),
+			wantErr: io.ErrUnexpectedEOF.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			c, conn := newConn(test.input, false)
+			messageType, p, err := c.ReadMessage()
+			switch {
+			case test.wantClose != nil:
+				var closeErr *CloseError
+				require.ErrorAs(t, err, &closeErr)
+				assert.Equal(t, test.wantClose, closeErr)
+			case test.wantErr != "":
+				require.EqualError(t, err, test.wantErr)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, test.wantType, messageType)
+				assert.Equal(t, test.want, p)
+			}
+			assert.Equal(t, test.wantWritten, conn.written.Bytes())
+		})
+	}
+}